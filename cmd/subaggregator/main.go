@@ -3,17 +3,21 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // часовые пояса пользователей проверяются и в образе без tzdata
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 
 	"github.com/vnchk1/subscription-aggregator/internal/config"
 	"github.com/vnchk1/subscription-aggregator/internal/db"
 	"github.com/vnchk1/subscription-aggregator/internal/handler"
 	logging "github.com/vnchk1/subscription-aggregator/internal/logger"
+	"github.com/vnchk1/subscription-aggregator/internal/middleware"
 	"github.com/vnchk1/subscription-aggregator/internal/migration"
 	"github.com/vnchk1/subscription-aggregator/internal/repository"
 	"github.com/vnchk1/subscription-aggregator/internal/server"
@@ -56,16 +60,78 @@ func main() {
 
 	logger.Debug("Connected to database")
 
+	command := "serve"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	switch command {
+	case "serve":
+		serve(cfg, logger, pool)
+	case "set-rate":
+		if err = setRate(ctx, pool, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to set exchange rate: %v", err)
+		}
+	case "import-rates":
+		if err = importRates(ctx, pool, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to import exchange rates: %v", err)
+		}
+	case "import-subscriptions":
+		if err = importSubscriptions(ctx, pool, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to import subscriptions: %v", err)
+		}
+	case "export-subscriptions":
+		if err = exportSubscriptions(ctx, pool, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to export subscriptions: %v", err)
+		}
+	case "purge-deleted":
+		if err = purgeDeleted(ctx, pool, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to purge deleted subscriptions: %v", err)
+		}
+	case "purge-idempotency-keys":
+		if err = purgeIdempotencyKeys(ctx, pool, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to purge idempotency keys: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown command %q, expected one of: serve, set-rate, import-rates, import-subscriptions, "+
+			"export-subscriptions, purge-deleted, purge-idempotency-keys", command)
+	}
+}
+
+func serve(cfg *config.Config, logger *slog.Logger, pool *pgxpool.Pool) {
+	serviceRepo := repository.NewServiceRepository(pool)
+	serviceCatalogService := service.NewServiceCatalogService(serviceRepo)
+	serviceCatalogHandler := handler.NewServiceCatalogHandler(serviceCatalogService)
+
+	userRepo := repository.NewUserRepository(pool)
+	userService := service.NewUserService(userRepo)
+	userHandler := handler.NewUserHandler(userService)
+
 	subscriptionRepo := repository.NewSubscriptionRepository(pool)
-	subscriptionService := service.NewSubscriptionService(subscriptionRepo)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, serviceRepo, userRepo)
 	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
 
+	exchangeRateRepo := repository.NewExchangeRateRepository(pool)
+	exchangeRateService := service.NewExchangeRateService(exchangeRateRepo)
+	exchangeRateHandler := handler.NewExchangeRateHandler(exchangeRateService)
+
+	idempotencyRepo := repository.NewIdempotencyRepository(pool)
+	idempotency := middleware.IdempotencyMiddleware(idempotencyRepo, time.Duration(cfg.Server.IdempotencyKeyTTL)*time.Hour)
+
 	srv := server.New(cfg.Server, logger)
 
-	server.SetupRouter(srv.GetEchoInstance(), subscriptionHandler, logger)
+	server.SetupRouter(
+		srv.GetEchoInstance(),
+		subscriptionHandler,
+		exchangeRateHandler,
+		serviceCatalogHandler,
+		userHandler,
+		idempotency,
+		logger,
+	)
 
 	go func() {
-		if err = srv.Start(); err != nil {
+		if err := srv.Start(); err != nil {
 			log.Printf("Server error: %v", err)
 		}
 	}()
@@ -80,7 +146,7 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	if err = srv.Shutdown(ctx); err != nil {
+	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
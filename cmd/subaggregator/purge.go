@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vnchk1/subscription-aggregator/internal/repository"
+	"github.com/vnchk1/subscription-aggregator/internal/service"
+)
+
+// purgeDeleted permanently removes subscriptions deleted more than N days
+// ago: purge-deleted [-days N].
+func purgeDeleted(ctx context.Context, pool *pgxpool.Pool, args []string) error {
+	flags := flag.NewFlagSet("purge-deleted", flag.ContinueOnError)
+	days := flags.Int("days", 30, "remove subscriptions deleted more than this many days ago")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 0 {
+		return errors.New("usage: purge-deleted [-days N]")
+	}
+
+	subscriptionService := service.NewSubscriptionService(
+		repository.NewSubscriptionRepository(pool),
+		repository.NewServiceRepository(pool),
+		repository.NewUserRepository(pool),
+	)
+
+	count, err := subscriptionService.PurgeDeletedSubscriptions(ctx, *days)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Purged %d subscriptions deleted more than %d days ago", count, *days)
+
+	return nil
+}
+
+// purgeIdempotencyKeys removes expired idempotency keys: purge-idempotency-keys.
+func purgeIdempotencyKeys(ctx context.Context, pool *pgxpool.Pool, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: purge-idempotency-keys")
+	}
+
+	count, err := repository.NewIdempotencyRepository(pool).DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Purged %d expired idempotency keys", count)
+
+	return nil
+}
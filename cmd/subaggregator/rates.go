@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/repository"
+	"github.com/vnchk1/subscription-aggregator/internal/service"
+)
+
+// setRate stores one exchange rate: set-rate YYYY-MM-DD BASE QUOTE RATE.
+func setRate(ctx context.Context, pool *pgxpool.Pool, args []string) error {
+	if len(args) != 4 {
+		return errors.New("usage: set-rate YYYY-MM-DD BASE QUOTE RATE")
+	}
+
+	rateDate, err := time.Parse(time.DateOnly, args[0])
+	if err != nil {
+		return fmt.Errorf("invalid rate date: %w", err)
+	}
+
+	value, err := strconv.ParseFloat(args[3], 64)
+	if err != nil {
+		return fmt.Errorf("invalid rate: %w", err)
+	}
+
+	rateService := service.NewExchangeRateService(repository.NewExchangeRateRepository(pool))
+
+	key := models.ExchangeRateKey{
+		RateDate:      rateDate,
+		BaseCurrency:  args[1],
+		QuoteCurrency: args[2],
+	}
+
+	rate, err := rateService.PutExchangeRate(ctx, key, &models.PutExchangeRateRequest{Rate: value})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Exchange rate %s/%s on %s set to %v", rate.BaseCurrency, rate.QuoteCurrency, rate.RateDate, rate.Rate)
+
+	return nil
+}
+
+// importRates loads a rate file from disk: import-rates [-format csv|cbr] FILE.
+func importRates(ctx context.Context, pool *pgxpool.Pool, args []string) error {
+	flags := flag.NewFlagSet("import-rates", flag.ContinueOnError)
+	format := flags.String("format", string(models.RateFileFormatCSV), "file format: csv or cbr (CBR XML_daily)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: import-rates [-format csv|cbr] FILE")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	rateService := service.NewExchangeRateService(repository.NewExchangeRateRepository(pool))
+
+	count, err := rateService.ImportExchangeRates(ctx, models.RateFileFormat(*format), file)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Imported %d exchange rates from %s", count, flags.Arg(0))
+
+	return nil
+}
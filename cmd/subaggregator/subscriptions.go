@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/repository"
+	"github.com/vnchk1/subscription-aggregator/internal/service"
+)
+
+// importSubscriptions loads subscriptions from a CSV file:
+// import-subscriptions [-dry-run] FILE.
+func importSubscriptions(ctx context.Context, pool *pgxpool.Pool, args []string) error {
+	flags := flag.NewFlagSet("import-subscriptions", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate the file without importing it")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: import-subscriptions [-dry-run] FILE")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	subscriptionService := service.NewSubscriptionService(
+		repository.NewSubscriptionRepository(pool),
+		repository.NewServiceRepository(pool),
+		repository.NewUserRepository(pool),
+	)
+
+	result, err := subscriptionService.ImportSubscriptions(ctx, file, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, rowErr := range result.Errors {
+		log.Printf("Line %d: %s", rowErr.Line, rowErr.Error)
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: %d of %d rows of %s are valid", result.Valid, result.Rows, flags.Arg(0))
+	} else {
+		log.Printf("Imported %d of %d subscriptions from %s", result.Imported, result.Rows, flags.Arg(0))
+	}
+
+	return nil
+}
+
+// exportSubscriptions writes the subscriptions matching the list filters to a
+// file or stdout: export-subscriptions [-format csv|ndjson|xlsx] [-o FILE] [filters].
+func exportSubscriptions(ctx context.Context, pool *pgxpool.Pool, args []string) error {
+	var req models.ListSubscriptionsRequest
+
+	flags := flag.NewFlagSet("export-subscriptions", flag.ContinueOnError)
+	format := flags.String("format", string(models.ExportFormatCSV), "file format: csv, ndjson or xlsx")
+	output := flags.String("o", "", "output file (default stdout)")
+	dateFormat := flags.String("date-format", string(models.DateFormatLegacy), "subscription dates: legacy (MM-YYYY) or iso")
+	userID := flags.String("user-id", "", "user ID")
+	serviceID := flags.String("service-id", "", "catalog service ID")
+	flags.StringVar(&req.ServiceName, "service-name", "", "exact service name")
+	flags.StringVar(&req.ServiceNameSearch, "service-name-search", "", "service name prefix")
+	flags.StringVar(&req.MinPrice, "min-price", "", "minimum price")
+	flags.StringVar(&req.MaxPrice, "max-price", "", "maximum price")
+	flags.StringVar(&req.ActiveOn, "active-on", "", "month MM-YYYY the subscription is active in")
+	flags.StringVar(&req.Status, "status", "", "active, ended or future")
+	flags.StringVar(&req.StartFrom, "start-from", "", "earliest start month MM-YYYY")
+	flags.StringVar(&req.StartTo, "start-to", "", "latest start month MM-YYYY")
+	flags.StringVar(&req.Category, "category", "", "category")
+	flags.Func("tag", "tag the subscription has; repeat for several tags", func(tag string) error {
+		req.Tags = append(req.Tags, tag)
+
+		return nil
+	})
+	flags.StringVar(&req.Sort, "sort", "", "sort keys, e.g. price,-start_date")
+	flags.BoolVar(&req.IncludeDeleted, "include-deleted", false, "include deleted subscriptions")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 0 {
+		return errors.New("usage: export-subscriptions [-format csv|ndjson|xlsx] [-o FILE] [filters]")
+	}
+
+	// Формат дат проверяется до создания файла, как и остальные параметры
+	subscriptionDates, err := models.ParseDateFormat(*dateFormat)
+	if err != nil {
+		return err
+	}
+
+	if *userID != "" {
+		id, err := uuid.Parse(*userID)
+		if err != nil {
+			return fmt.Errorf("invalid user ID: %w", err)
+		}
+
+		req.UserID = &id
+	}
+
+	if *serviceID != "" {
+		id, err := uuid.Parse(*serviceID)
+		if err != nil {
+			return fmt.Errorf("invalid service ID: %w", err)
+		}
+
+		req.ServiceID = &id
+	}
+
+	exportFormat := models.ExportFormat(*format)
+	if !exportFormat.IsValid() {
+		return fmt.Errorf("invalid format %q, expected csv, ndjson or xlsx", *format)
+	}
+
+	subscriptionService := service.NewSubscriptionService(
+		repository.NewSubscriptionRepository(pool),
+		repository.NewServiceRepository(pool),
+		repository.NewUserRepository(pool),
+	)
+
+	// Фильтры проверяются до создания файла, чтобы не затереть существующий
+	if err = subscriptionService.ValidateListRequest(&req); err != nil {
+		return err
+	}
+
+	ctx = models.WithDateFormat(ctx, subscriptionDates)
+
+	if *output == "" {
+		return subscriptionService.ExportSubscriptions(ctx, &req, exportFormat, os.Stdout)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	err = subscriptionService.ExportSubscriptions(ctx, &req, exportFormat, file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close file: %w", closeErr)
+	}
+
+	// Недописанный файл удаляется, чтобы его не приняли за полную выгрузку
+	if err != nil {
+		_ = os.Remove(*output)
+
+		return err
+	}
+
+	return nil
+}
@@ -16,10 +16,12 @@ type LoggerConfig struct {
 	LogLevel string
 }
 
+// ServerConfig holds timeouts in seconds and IdempotencyKeyTTL in hours.
 type ServerConfig struct {
-	Port         string
-	ReadTimeout  int
-	WriteTimeout int
+	Port              string
+	ReadTimeout       int
+	WriteTimeout      int
+	IdempotencyKeyTTL int
 }
 
 type DatabaseConfig struct {
@@ -61,9 +63,10 @@ func Load() (*Config, error) {
 			LogLevel: getEnv("LOG_LEVEL", "info"),
 		},
 		Server: ServerConfig{
-			Port:         getEnv("SERVER_PORT", "8080"),
-			ReadTimeout:  getEnvAsInt("SERVER_READ_TIMEOUT", 10),
-			WriteTimeout: getEnvAsInt("SERVER_WRITE_TIMEOUT", 10),
+			Port:              getEnv("SERVER_PORT", "8080"),
+			ReadTimeout:       getEnvAsInt("SERVER_READ_TIMEOUT", 10),
+			WriteTimeout:      getEnvAsInt("SERVER_WRITE_TIMEOUT", 10),
+			IdempotencyKeyTTL: getEnvAsInt("SERVER_IDEMPOTENCY_KEY_TTL", 24),
 		},
 		Database: dbConfig,
 	}, nil
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfMatch     = "If-Match"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// subscriptionETag is the strong entity tag of a subscription version.
+func subscriptionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseIfMatch returns the subscription version required by If-Match; nil
+// when the header is absent or "*". Only a single entity tag is supported:
+// anything else never matches and fails the request with 412.
+func parseIfMatch(c echo.Context) (*int, *models.ErrorResponse) {
+	header := strings.TrimSpace(c.Request().Header.Get(headerIfMatch))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	// Weak теги не подходят для If-Match
+	tag, err := strconv.Unquote(header)
+	if err == nil {
+		if version, err := strconv.Atoi(tag); err == nil {
+			return &version, nil
+		}
+	}
+
+	return nil, &models.ErrorResponse{
+		Error:   http.StatusText(http.StatusPreconditionFailed),
+		Message: models.ErrPreconditionFailed.Error(),
+	}
+}
+
+// noneMatch reports whether If-None-Match lists etag, comparing weakly.
+func noneMatch(c echo.Context, etag string) bool {
+	header := c.Request().Header.Get(headerIfNoneMatch)
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
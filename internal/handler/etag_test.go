@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/service"
+	"github.com/vnchk1/subscription-aggregator/internal/service/mocks"
+)
+
+func newETagTestSubscription(id uuid.UUID, version int) *models.Subscription {
+	return &models.Subscription{
+		ID:              id,
+		ServiceName:     "Netflix",
+		Price:           models.NewMoney(79900, "RUB"),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          uuid.New(),
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Version:         version,
+	}
+}
+
+func newTestSubscriptionHandler(ctrl *gomock.Controller, repo *mocks.MockSubscriptionRepository) *SubscriptionHandler {
+	return NewSubscriptionHandler(service.NewSubscriptionService(
+		repo,
+		mocks.NewMockServiceRepository(ctrl),
+		mocks.NewMockUserRepository(ctrl),
+	))
+}
+
+// serveSubscription runs handle for the subscription id with the given
+// precondition header.
+func serveSubscription(
+	handle echo.HandlerFunc,
+	method string,
+	id uuid.UUID,
+	body string,
+	header, value string,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/subscriptions/"+id.String(), strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id.String())
+
+	_ = handle(c)
+
+	return rec
+}
+
+func TestSubscriptionHandler_UpdateSubscription_IfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	handler := newTestSubscriptionHandler(ctrl, mockRepo)
+
+	id := uuid.New()
+	body := `{"service_name": "Netflix", "price": "799", "start_date": "01-2024"}`
+
+	testCases := []struct {
+		name       string
+		ifMatch    string
+		getByID    bool
+		update     bool
+		wantStatus int
+		wantETag   string
+	}{
+		{name: "matching etag", ifMatch: `"3"`, getByID: true, update: true, wantStatus: http.StatusOK, wantETag: `"4"`},
+		{name: "any etag", ifMatch: "*", getByID: true, update: true, wantStatus: http.StatusOK, wantETag: `"4"`},
+		{name: "stale etag", ifMatch: `"2"`, getByID: true, wantStatus: http.StatusPreconditionFailed},
+		{name: "weak etag", ifMatch: `W/"3"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "several etags", ifMatch: `"2", "3"`, wantStatus: http.StatusPreconditionFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.getByID {
+				mockRepo.EXPECT().
+					GetByID(gomock.Any(), id, false).
+					Return(newETagTestSubscription(id, 3), nil)
+			}
+
+			if tc.update {
+				mockRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+						sub.Version++
+						return nil
+					})
+			}
+
+			rec := serveSubscription(handler.UpdateSubscription, http.MethodPut, id, body, headerIfMatch, tc.ifMatch)
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			assert.Equal(t, tc.wantETag, rec.Header().Get(headerETag))
+		})
+	}
+}
+
+func TestSubscriptionHandler_UpdateSubscription_IfMatchConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	handler := newTestSubscriptionHandler(ctrl, mockRepo)
+
+	id := uuid.New()
+
+	// Версия изменилась между чтением и записью
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), id, false).
+		Return(newETagTestSubscription(id, 3), nil)
+	mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		Return(models.ErrVersionConflict)
+
+	body := `{"service_name": "Netflix", "price": "799", "start_date": "01-2024"}`
+	rec := serveSubscription(handler.UpdateSubscription, http.MethodPut, id, body, headerIfMatch, `"3"`)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+}
+
+func TestSubscriptionHandler_GetSubscription_IfNoneMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	handler := newTestSubscriptionHandler(ctrl, mockRepo)
+
+	id := uuid.New()
+
+	testCases := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{name: "no header", wantStatus: http.StatusOK},
+		{name: "matching etag", ifNoneMatch: `"3"`, wantStatus: http.StatusNotModified},
+		{name: "weak matching etag", ifNoneMatch: `W/"3"`, wantStatus: http.StatusNotModified},
+		{name: "one of several etags", ifNoneMatch: `"1", "3"`, wantStatus: http.StatusNotModified},
+		{name: "any etag", ifNoneMatch: "*", wantStatus: http.StatusNotModified},
+		{name: "stale etag", ifNoneMatch: `"2"`, wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetByID(gomock.Any(), id, false).
+				Return(newETagTestSubscription(id, 3), nil)
+
+			header := ""
+			if tc.ifNoneMatch != "" {
+				header = headerIfNoneMatch
+			}
+
+			rec := serveSubscription(handler.GetSubscription, http.MethodGet, id, "", header, tc.ifNoneMatch)
+
+			require.Equal(t, tc.wantStatus, rec.Code)
+			assert.Equal(t, `"3"`, rec.Header().Get(headerETag))
+
+			if tc.wantStatus == http.StatusNotModified {
+				assert.Empty(t, rec.Body.String())
+			} else {
+				assert.Contains(t, rec.Body.String(), id.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ExchangeRateHandler struct {
+	service service.ExchangeRateService
+}
+
+func NewExchangeRateHandler(service service.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		service: service,
+	}
+}
+
+// CreateExchangeRate godoc
+// @Summary Добавить курс валюты
+// @Description Сохраняет курс: одна единица базовой валюты стоит rate единиц котируемой на дату rate_date
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param request body models.CreateExchangeRateRequest true "Курс валюты"
+// @Success 201 {object} models.ExchangeRateResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /exchange-rates [post].
+func (h *ExchangeRateHandler) CreateExchangeRate(c echo.Context) error {
+	var req models.CreateExchangeRateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	rate, err := h.service.CreateExchangeRate(c.Request().Context(), &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, rate)
+}
+
+// ListExchangeRates godoc
+// @Summary Список курсов валют
+// @Description Возвращает курсы валют с пагинацией и фильтрацией по валютам и датам
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param base_currency query string false "Базовая валюта"
+// @Param quote_currency query string false "Котируемая валюта"
+// @Param from query string false "Начальная дата (формат: YYYY-MM-DD)"
+// @Param to query string false "Конечная дата (формат: YYYY-MM-DD)"
+// @Param page query int false "Номер страницы (по умолчанию 1)" default(1)
+// @Param limit query int false "Количество записей на странице (по умолчанию 20)" default(20)
+// @Success 200 {object} models.ListResponse{data=[]models.ExchangeRateResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /exchange-rates [get].
+func (h *ExchangeRateHandler) ListExchangeRates(c echo.Context) error {
+	var req models.ListExchangeRatesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid query parameters",
+			Message: err.Error(),
+		})
+	}
+
+	response, err := h.service.ListExchangeRates(c.Request().Context(), &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetExchangeRate godoc
+// @Summary Получить курс валюты
+// @Description Возвращает курс валютной пары на дату
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param base path string true "Базовая валюта" example(USD)
+// @Param quote path string true "Котируемая валюта" example(RUB)
+// @Param date path string true "Дата курса (формат: YYYY-MM-DD)" example(2024-01-15)
+// @Success 200 {object} models.ExchangeRateResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /exchange-rates/{base}/{quote}/{date} [get].
+func (h *ExchangeRateHandler) GetExchangeRate(c echo.Context) error {
+	key, errResp := h.parseKey(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	rate, err := h.service.GetExchangeRate(c.Request().Context(), key)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, rate)
+}
+
+// PutExchangeRate godoc
+// @Summary Задать курс валюты
+// @Description Создает или заменяет курс валютной пары на дату
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param base path string true "Базовая валюта" example(USD)
+// @Param quote path string true "Котируемая валюта" example(RUB)
+// @Param date path string true "Дата курса (формат: YYYY-MM-DD)" example(2024-01-15)
+// @Param request body models.PutExchangeRateRequest true "Курс валюты"
+// @Success 200 {object} models.ExchangeRateResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /exchange-rates/{base}/{quote}/{date} [put].
+func (h *ExchangeRateHandler) PutExchangeRate(c echo.Context) error {
+	key, errResp := h.parseKey(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	var req models.PutExchangeRateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	rate, err := h.service.PutExchangeRate(c.Request().Context(), key, &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, rate)
+}
+
+// DeleteExchangeRate godoc
+// @Summary Удалить курс валюты
+// @Description Удаляет курс валютной пары на дату
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param base path string true "Базовая валюта" example(USD)
+// @Param quote path string true "Котируемая валюта" example(RUB)
+// @Param date path string true "Дата курса (формат: YYYY-MM-DD)" example(2024-01-15)
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /exchange-rates/{base}/{quote}/{date} [delete].
+func (h *ExchangeRateHandler) DeleteExchangeRate(c echo.Context) error {
+	key, errResp := h.parseKey(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	if err := h.service.DeleteExchangeRate(c.Request().Context(), key); err != nil {
+		return handleError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *ExchangeRateHandler) parseKey(c echo.Context) (models.ExchangeRateKey, *models.ErrorResponse) {
+	rateDate, err := time.Parse(time.DateOnly, c.Param("date"))
+	if err != nil {
+		return models.ExchangeRateKey{}, &models.ErrorResponse{
+			Error:   "Invalid rate date",
+			Message: "Rate date must be in YYYY-MM-DD format",
+		}
+	}
+
+	return models.ExchangeRateKey{
+		RateDate:      rateDate,
+		BaseCurrency:  c.Param("base"),
+		QuoteCurrency: c.Param("quote"),
+	}, nil
+}
@@ -0,0 +1,351 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type ServiceCatalogHandler struct {
+	service service.ServiceCatalogService
+}
+
+func NewServiceCatalogHandler(service service.ServiceCatalogService) *ServiceCatalogHandler {
+	return &ServiceCatalogHandler{
+		service: service,
+	}
+}
+
+// CreateService godoc
+// @Summary Добавить сервис в каталог
+// @Description Создает сервис каталога. Подписки с названием или псевдонимом сервиса в любом регистре связываются с ним
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param request body models.ServiceRequest true "Данные сервиса"
+// @Success 201 {object} models.Service
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services [post].
+func (h *ServiceCatalogHandler) CreateService(c echo.Context) error {
+	var req models.ServiceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	service, err := h.service.CreateService(c.Request().Context(), &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, service)
+}
+
+// ListServices godoc
+// @Summary Список сервисов каталога
+// @Description Возвращает сервисы каталога с пагинацией. search ищет по началу названия или псевдонима без учета регистра
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param category query string false "Категория"
+// @Param search query string false "Начало названия или псевдонима"
+// @Param page query int false "Номер страницы (по умолчанию 1)" default(1)
+// @Param limit query int false "Количество записей на странице (по умолчанию 20)" default(20)
+// @Success 200 {object} models.ListResponse{data=[]models.Service}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services [get].
+func (h *ServiceCatalogHandler) ListServices(c echo.Context) error {
+	var req models.ListServicesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid query parameters",
+			Message: err.Error(),
+		})
+	}
+
+	response, err := h.service.ListServices(c.Request().Context(), &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetService godoc
+// @Summary Получить сервис каталога
+// @Description Возвращает сервис каталога по ID
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Success 200 {object} models.Service
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services/{id} [get].
+func (h *ServiceCatalogHandler) GetService(c echo.Context) error {
+	id, errResp := parseServiceID(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	service, err := h.service.GetService(c.Request().Context(), id)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, service)
+}
+
+// UpdateService godoc
+// @Summary Обновить сервис каталога
+// @Description Заменяет сервис каталога. Связанные подписки сохраняют название, с которым были созданы
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Param request body models.ServiceRequest true "Данные сервиса"
+// @Success 200 {object} models.Service
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services/{id} [put].
+func (h *ServiceCatalogHandler) UpdateService(c echo.Context) error {
+	id, errResp := parseServiceID(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	var req models.ServiceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	service, err := h.service.UpdateService(c.Request().Context(), id, &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, service)
+}
+
+// DeleteService godoc
+// @Summary Удалить сервис каталога
+// @Description Удаляет сервис каталога, если с ним не связаны подписки
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services/{id} [delete].
+func (h *ServiceCatalogHandler) DeleteService(c echo.Context) error {
+	id, errResp := parseServiceID(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	if err := h.service.DeleteService(c.Request().Context(), id); err != nil {
+		return handleError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func parseServiceID(c echo.Context) (uuid.UUID, *models.ErrorResponse) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, &models.ErrorResponse{
+			Error:   "Invalid service ID",
+			Message: "Service ID must be a valid UUID",
+		}
+	}
+
+	return id, nil
+}
+
+// CreatePlan godoc
+// @Summary Добавить тариф сервиса
+// @Description Создает тариф сервиса с ценами за один период оплаты в каждой валюте
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Param request body models.ServicePlanRequest true "Данные тарифа"
+// @Success 201 {object} models.ServicePlanResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services/{id}/plans [post].
+func (h *ServiceCatalogHandler) CreatePlan(c echo.Context) error {
+	serviceID, errResp := parseServiceID(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	var req models.ServicePlanRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	plan, err := h.service.CreatePlan(c.Request().Context(), serviceID, &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, plan)
+}
+
+// ListPlans godoc
+// @Summary Список тарифов сервиса
+// @Description Возвращает тарифы сервиса с их ценами
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Success 200 {array} models.ServicePlanResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services/{id}/plans [get].
+func (h *ServiceCatalogHandler) ListPlans(c echo.Context) error {
+	serviceID, errResp := parseServiceID(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	plans, err := h.service.ListPlans(c.Request().Context(), serviceID)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, plans)
+}
+
+// GetPlan godoc
+// @Summary Получить тариф сервиса
+// @Description Возвращает тариф сервиса по ID
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Param plan_id path string true "ID тарифа"
+// @Success 200 {object} models.ServicePlanResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services/{id}/plans/{plan_id} [get].
+func (h *ServiceCatalogHandler) GetPlan(c echo.Context) error {
+	serviceID, planID, errResp := parsePlanID(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	plan, err := h.service.GetPlan(c.Request().Context(), serviceID, planID)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, plan)
+}
+
+// UpdatePlan godoc
+// @Summary Обновить тариф сервиса
+// @Description Заменяет название и цены тарифа. Цены уже оформленных подписок не меняются
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Param plan_id path string true "ID тарифа"
+// @Param request body models.ServicePlanRequest true "Данные тарифа"
+// @Success 200 {object} models.ServicePlanResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services/{id}/plans/{plan_id} [put].
+func (h *ServiceCatalogHandler) UpdatePlan(c echo.Context) error {
+	serviceID, planID, errResp := parsePlanID(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	var req models.ServicePlanRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	plan, err := h.service.UpdatePlan(c.Request().Context(), serviceID, planID, &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, plan)
+}
+
+// DeletePlan godoc
+// @Summary Удалить тариф сервиса
+// @Description Удаляет тариф сервиса, если на него не ссылаются подписки
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Param plan_id path string true "ID тарифа"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services/{id}/plans/{plan_id} [delete].
+func (h *ServiceCatalogHandler) DeletePlan(c echo.Context) error {
+	serviceID, planID, errResp := parsePlanID(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	if err := h.service.DeletePlan(c.Request().Context(), serviceID, planID); err != nil {
+		return handleError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func parsePlanID(c echo.Context) (uuid.UUID, uuid.UUID, *models.ErrorResponse) {
+	serviceID, errResp := parseServiceID(c)
+	if errResp != nil {
+		return uuid.Nil, uuid.Nil, errResp
+	}
+
+	planID, err := uuid.Parse(c.Param("plan_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, &models.ErrorResponse{
+			Error:   "Invalid plan ID",
+			Message: "Plan ID must be a valid UUID",
+		}
+	}
+
+	return serviceID, planID, nil
+}
@@ -1,8 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/vnchk1/subscription-aggregator/internal/models"
 	"github.com/vnchk1/subscription-aggregator/internal/service"
@@ -21,6 +26,22 @@ func NewSubscriptionHandler(service service.SubscriptionService) *SubscriptionHa
 	}
 }
 
+// CreateSubscription godoc
+// @Summary Создать подписку
+// @Description Создает новую подписку для пользователя
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body models.CreateSubscriptionRequest true "Данные для создания подписки"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 201 {object} models.SubscriptionResponse
+// @Header 201 {string} ETag "Версия подписки"
+// @Header 201 {string} Idempotent-Replayed "true, если ответ повторен по ключу идемпотентности"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Запрос с этим ключом еще выполняется"
+// @Failure 422 {object} models.ErrorResponse "Ключ уже использован с другим телом запроса"
+// @Failure 500 {object} models.ErrorResponse
 // @Router /subscriptions [post].
 func (h *SubscriptionHandler) CreateSubscription(c echo.Context) error {
 	var req models.CreateSubscriptionRequest
@@ -33,15 +54,29 @@ func (h *SubscriptionHandler) CreateSubscription(c echo.Context) error {
 
 	subscription, err := h.service.CreateSubscription(c.Request().Context(), &req)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Failed to create subscription",
-			Message: err.Error(),
-		})
+		return handleError(c, err)
 	}
 
+	c.Response().Header().Set(headerETag, subscriptionETag(subscription.Version))
+
 	return c.JSON(http.StatusCreated, subscription)
 }
 
+// GetSubscription godoc
+// @Summary Получить подписку
+// @Description Возвращает подписку по её ID
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param include_deleted query bool false "Возвращать и удаленную подписку"
+// @Param If-None-Match header string false "ETag сохраненной версии; если версия не изменилась, ответ 304"
+// @Success 200 {object} models.SubscriptionResponse
+// @Header 200 {string} ETag "Версия подписки"
+// @Success 304 "Not Modified"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /subscriptions/{id} [get].
 func (h *SubscriptionHandler) GetSubscription(c echo.Context) error {
 	idStr := c.Param("id")
@@ -54,14 +89,44 @@ func (h *SubscriptionHandler) GetSubscription(c echo.Context) error {
 		})
 	}
 
-	subscription, err := h.service.GetSubscription(c.Request().Context(), id)
+	includeDeleted, err := parseIncludeDeleted(c)
 	if err != nil {
-		return h.handleError(c, err)
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid query parameters",
+			Message: err.Error(),
+		})
+	}
+
+	subscription, err := h.service.GetSubscription(c.Request().Context(), id, includeDeleted)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	etag := subscriptionETag(subscription.Version)
+	c.Response().Header().Set(headerETag, etag)
+
+	if noneMatch(c, etag) {
+		return c.NoContent(http.StatusNotModified)
 	}
 
 	return c.JSON(http.StatusOK, subscription)
 }
 
+// UpdateSubscription godoc
+// @Summary Обновить подписку
+// @Description Обновляет данные подписки
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param request body models.UpdateSubscriptionRequest true "Данные для обновления подписки"
+// @Param If-Match header string false "Версия подписки из ETag; при несовпадении запрос отклоняется с 412"
+// @Success 200 {object} models.SubscriptionResponse
+// @Header 200 {string} ETag "Версия подписки"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /subscriptions/{id} [put].
 func (h *SubscriptionHandler) UpdateSubscription(c echo.Context) error {
 	idStr := c.Param("id")
@@ -82,14 +147,160 @@ func (h *SubscriptionHandler) UpdateSubscription(c echo.Context) error {
 		})
 	}
 
-	subscription, err := h.service.UpdateSubscription(c.Request().Context(), id, &req)
+	version, errResp := parseIfMatch(c)
+	if errResp != nil {
+		return c.JSON(http.StatusPreconditionFailed, errResp)
+	}
+
+	subscription, err := h.service.UpdateSubscription(c.Request().Context(), id, &req, version)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	c.Response().Header().Set(headerETag, subscriptionETag(subscription.Version))
+
+	return c.JSON(http.StatusOK, subscription)
+}
+
+// mergePatchContentType is the media type of RFC 7396 merge patches.
+const mergePatchContentType = "application/merge-patch+json"
+
+// PatchSubscription godoc
+// @Summary Частично обновить подписку
+// @Description Применяет JSON merge patch (RFC 7396): переданные поля заменяются, null удаляет значение
+// @Tags subscriptions
+// @Accept application/merge-patch+json,json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param patch body object true "Изменяемые поля подписки в формате UpdateSubscriptionRequest"
+// @Param If-Match header string false "Версия подписки из ETag; при несовпадении запрос отклоняется с 412"
+// @Success 200 {object} models.SubscriptionResponse
+// @Header 200 {string} ETag "Версия подписки"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 415 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /subscriptions/{id} [patch].
+func (h *SubscriptionHandler) PatchSubscription(c echo.Context) error {
+	idStr := c.Param("id")
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid subscription ID",
+			Message: "Subscription ID must be a valid UUID",
+		})
+	}
+
+	contentType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if contentType != mergePatchContentType && contentType != echo.MIMEApplicationJSON {
+		return c.JSON(http.StatusUnsupportedMediaType, models.ErrorResponse{
+			Error:   http.StatusText(http.StatusUnsupportedMediaType),
+			Message: "Content-Type must be " + mergePatchContentType,
+		})
+	}
+
+	patch, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	version, errResp := parseIfMatch(c)
+	if errResp != nil {
+		return c.JSON(http.StatusPreconditionFailed, errResp)
+	}
+
+	subscription, err := h.service.PatchSubscription(c.Request().Context(), id, patch, version)
 	if err != nil {
-		return h.handleError(c, err)
+		return handleError(c, err)
 	}
 
+	c.Response().Header().Set(headerETag, subscriptionETag(subscription.Version))
+
 	return c.JSON(http.StatusOK, subscription)
 }
 
+// GetSubscriptionPrices godoc
+// @Summary История цен подписки
+// @Description Возвращает цены подписки с датами начала и окончания их действия, начиная с самой ранней
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Success 200 {object} models.SubscriptionPricesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /subscriptions/{id}/prices [get].
+func (h *SubscriptionHandler) GetSubscriptionPrices(c echo.Context) error {
+	idStr := c.Param("id")
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid subscription ID",
+			Message: "Subscription ID must be a valid UUID",
+		})
+	}
+
+	prices, err := h.service.GetSubscriptionPrices(c.Request().Context(), id)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, prices)
+}
+
+// GetSubscriptionHistory godoc
+// @Summary Журнал изменений подписки
+// @Description Возвращает изменения подписки от самого раннего: состояние до и после, измененные поля,
+// @Description автора (X-Actor) и ID запроса (X-Request-ID). Журнал доступен и после удаления подписки
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Success 200 {object} models.SubscriptionHistoryResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /subscriptions/{id}/history [get].
+func (h *SubscriptionHandler) GetSubscriptionHistory(c echo.Context) error {
+	idStr := c.Param("id")
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid subscription ID",
+			Message: "Subscription ID must be a valid UUID",
+		})
+	}
+
+	history, err := h.service.GetSubscriptionHistory(c.Request().Context(), id)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
+// DeleteSubscription godoc
+// @Summary Удалить подписку
+// @Description Удаляет подписку по её ID. Подписка помечается удаленной и пропадает из списка и отчетов;
+// @Description ее можно восстановить, пока она не очищена командой purge-deleted
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param If-Match header string false "Версия подписки из ETag; при несовпадении запрос отклоняется с 412"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /subscriptions/{id} [delete].
 func (h *SubscriptionHandler) DeleteSubscription(c echo.Context) error {
 	idStr := c.Param("id")
@@ -102,27 +313,392 @@ func (h *SubscriptionHandler) DeleteSubscription(c echo.Context) error {
 		})
 	}
 
-	if err := h.service.DeleteSubscription(c.Request().Context(), id); err != nil {
-		return h.handleError(c, err)
+	version, errResp := parseIfMatch(c)
+	if errResp != nil {
+		return c.JSON(http.StatusPreconditionFailed, errResp)
+	}
+
+	if err := h.service.DeleteSubscription(c.Request().Context(), id, version); err != nil {
+		return handleError(c, err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
+// RestoreSubscription godoc
+// @Summary Восстановить подписку
+// @Description Восстанавливает удаленную подписку
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Success 200 {object} models.SubscriptionResponse
+// @Header 200 {string} ETag "Версия подписки"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /subscriptions/{id}/restore [post].
+func (h *SubscriptionHandler) RestoreSubscription(c echo.Context) error {
+	idStr := c.Param("id")
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid subscription ID",
+			Message: "Subscription ID must be a valid UUID",
+		})
+	}
+
+	subscription, err := h.service.RestoreSubscription(c.Request().Context(), id)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	c.Response().Header().Set(headerETag, subscriptionETag(subscription.Version))
+
+	return c.JSON(http.StatusOK, subscription)
+}
+
+// ProcessBatch godoc
+// @Summary Пакетные изменения подписок
+// @Description Создает, обновляет и удаляет подписки одним запросом (не более 1000 операций).
+// @Description В режиме atomic изменения применяются, только если все операции успешны; в режиме best_effort
+// @Description успешные операции сохраняются. Результат каждой операции содержит ее HTTP-статус
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body models.BatchRequest true "Операции над подписками"
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
+// @Success 200 {object} models.BatchResponse
+// @Header 200 {string} Idempotent-Replayed "true, если ответ повторен по ключу идемпотентности"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Запрос с этим ключом еще выполняется"
+// @Failure 422 {object} models.ErrorResponse "Ключ уже использован с другим телом запроса"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /subscriptions/batch [post].
+func (h *SubscriptionHandler) ProcessBatch(c echo.Context) error {
+	var req models.BatchRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	response, err := h.service.ProcessBatch(c.Request().Context(), &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	for _, result := range response.Results {
+		if result.Err != nil {
+			result.Status = errorStatus(result.Err)
+			result.Error = result.Err.Error()
+
+			continue
+		}
+
+		switch result.Op {
+		case models.BatchOperationCreate:
+			result.Status = http.StatusCreated
+		case models.BatchOperationDelete:
+			result.Status = http.StatusNoContent
+		default:
+			result.Status = http.StatusOK
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ImportSubscriptions godoc
+// @Summary Импорт подписок из CSV
+// @Description Загружает подписки из CSV-файла. Каждая строка проверяется как тело POST /subscriptions;
+// @Description строки с ошибками пропускаются и перечисляются с номерами строк файла
+// @Tags subscriptions
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "CSV-файл с заголовком: service_name, price, user_id, start_date и необязательные колонки"
+// @Param dry_run query bool false "Только проверить файл"
+// @Success 200 {object} models.ImportResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /subscriptions/import [post].
+func (h *SubscriptionHandler) ImportSubscriptions(c echo.Context) error {
+	dryRun := false
+
+	if value := c.QueryParam("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid query parameters",
+				Message: "dry_run must be true or false",
+			})
+		}
+
+		dryRun = parsed
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: "multipart field file with a CSV file is required",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return handleError(c, fmt.Errorf("failed to open uploaded file: %w", err))
+	}
+	defer file.Close()
+
+	response, err := h.service.ImportSubscriptions(c.Request().Context(), file, dryRun)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ListSubscriptions godoc
+// @Summary Список подписок
+// @Description Возвращает список подписок с пагинацией и фильтрацией
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param user_id query string false "ID пользователя для фильтрации"
+// @Param service_id query string false "ID сервиса из каталога для фильтрации"
+// @Param service_name query string false "Точное название сервиса"
+// @Param service_name_search query string false "Начало названия сервиса без учета регистра"
+// @Param category query string false "Категория"
+// @Param tag query []string false "Теги, которые есть у подписки" collectionFormat(multi)
+// @Param min_price query string false "Минимальная цена"
+// @Param max_price query string false "Максимальная цена"
+// @Param active_on query string false "Месяц, в котором подписка активна (формат: MM-YYYY)"
+// @Param status query string false "Статус на текущую дату" Enums(active, ended, future)
+// @Param start_from query string false "Самый ранний месяц начала (формат: MM-YYYY)"
+// @Param start_to query string false "Самый поздний месяц начала (формат: MM-YYYY)"
+// @Param sort query string false "Ключи сортировки через запятую, - означает по убыванию, например price,-start_date"
+// @Param include_deleted query bool false "Включать удаленные подписки"
+// @Param date_format query string false "Формат дат подписки в ответе (по умолчанию legacy - MM-YYYY)" Enums(legacy, iso)
+// @Param page query int false "Номер страницы (по умолчанию 1)" default(1)
+// @Param limit query int false "Количество записей на странице (по умолчанию 20)" default(20)
+// @Param cursor query string false "Курсор из next_cursor предыдущего ответа вместо page"
+// @Success 200 {object} models.ListResponse{data=[]models.SubscriptionResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /subscriptions [get].
 func (h *SubscriptionHandler) ListSubscriptions(c echo.Context) error {
-	// Парсинг параметров запроса
-	page, _ := strconv.Atoi(c.QueryParam("page"))
-	if page < 1 {
-		page = 1
+	req, errResp := h.bindListRequest(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	response, err := h.service.ListSubscriptions(c.Request().Context(), req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ExportSubscriptions godoc
+// @Summary Выгрузка подписок
+// @Description Выгружает все подписки, подходящие под фильтры и сортировку списка, без пагинации
+// @Tags subscriptions
+// @Produce text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Формат файла (по умолчанию csv)" Enums(csv, ndjson, xlsx)
+// @Param user_id query string false "ID пользователя для фильтрации"
+// @Param service_id query string false "ID сервиса из каталога для фильтрации"
+// @Param service_name query string false "Точное название сервиса"
+// @Param service_name_search query string false "Начало названия сервиса без учета регистра"
+// @Param category query string false "Категория"
+// @Param tag query []string false "Теги, которые есть у подписки" collectionFormat(multi)
+// @Param min_price query string false "Минимальная цена"
+// @Param max_price query string false "Максимальная цена"
+// @Param active_on query string false "Месяц, в котором подписка активна (формат: MM-YYYY)"
+// @Param status query string false "Статус на текущую дату" Enums(active, ended, future)
+// @Param start_from query string false "Самый ранний месяц начала (формат: MM-YYYY)"
+// @Param start_to query string false "Самый поздний месяц начала (формат: MM-YYYY)"
+// @Param sort query string false "Ключи сортировки через запятую, - означает по убыванию, например price,-start_date"
+// @Param include_deleted query bool false "Включать удаленные подписки"
+// @Param date_format query string false "Формат дат подписки в ответе (по умолчанию legacy - MM-YYYY)" Enums(legacy, iso)
+// @Success 200 {file} file
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /subscriptions/export [get].
+func (h *SubscriptionHandler) ExportSubscriptions(c echo.Context) error {
+	req, errResp := h.bindListRequest(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	format := models.ExportFormat(c.QueryParam("format"))
+	if format == "" {
+		format = models.ExportFormatCSV
+	}
+
+	if !format.IsValid() {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid query parameters",
+			Message: "format must be csv, ndjson or xlsx",
+		})
+	}
+
+	// Выгрузка большой базы может идти дольше WriteTimeout сервера
+	_ = http.NewResponseController(c.Response()).SetWriteDeadline(time.Time{})
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, format.ContentType())
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="subscriptions.%s"`, format))
+
+	err := h.service.ExportSubscriptions(c.Request().Context(), req, format, c.Response())
+	if err != nil {
+		// После начала выгрузки статус уже отправлен, и ошибку остается только залогировать
+		if c.Response().Committed {
+			return err
+		}
+
+		header.Del(echo.HeaderContentType)
+		header.Del(echo.HeaderContentDisposition)
+
+		return handleError(c, err)
+	}
+
+	if !c.Response().Committed {
+		c.Response().WriteHeader(http.StatusOK)
+	}
+
+	return nil
+}
+
+// bindListRequest reads the list filters from the query string.
+func (h *SubscriptionHandler) bindListRequest(c echo.Context) (*models.ListSubscriptionsRequest, *models.ErrorResponse) {
+	var req models.ListSubscriptionsRequest
+
+	if err := c.Bind(&req); err != nil {
+		return nil, &models.ErrorResponse{
+			Error:   "Invalid query parameters",
+			Message: err.Error(),
+		}
+	}
+
+	if userIDStr := c.QueryParam("user_id"); userIDStr != "" {
+		id, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return nil, &models.ErrorResponse{
+				Error:   "Invalid user ID",
+				Message: "User ID must be a valid UUID",
+			}
+		}
+
+		req.UserID = &id
+	}
+
+	if serviceIDStr := c.QueryParam("service_id"); serviceIDStr != "" {
+		id, err := uuid.Parse(serviceIDStr)
+		if err != nil {
+			return nil, &models.ErrorResponse{
+				Error:   "Invalid service ID",
+				Message: "Service ID must be a valid UUID",
+			}
+		}
+
+		req.ServiceID = &id
+	}
+
+	return &req, nil
+}
+
+// CalculateTotalCost godoc
+// @Summary Общая стоимость подписок
+// @Description Вычисляет общую стоимость подписок за указанный период с возможностью фильтрации
+// @Description и группировки
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param user_id query string false "ID пользователя для фильтрации"
+// @Param service_id query string false "ID сервиса из каталога для фильтрации"
+// @Param service_name query string false "Название сервиса для фильтрации"
+// @Param category query string false "Категория для фильтрации"
+// @Param tag query []string false "Теги, которые есть у подписки" collectionFormat(multi)
+// @Param start_period query string true "Начало периода (формат: MM-YYYY или YYYY-MM-DD)" example("01-2024")
+// @Param end_period query string true "Конец периода (формат: MM-YYYY или YYYY-MM-DD)" example("12-2024")
+// @Param target_currency query string false "Валюта отчета (по умолчанию валюта пользователя или RUB)"
+// @Param basis query string false "База расчета: charges или monthly" Enums(charges, monthly)
+// @Param proration query string false "daily - учитывать неполные периоды пропорционально дням" Enums(daily)
+// @Param include_deleted query bool false "Учитывать удаленные подписки"
+// @Param group_by query string false "Группировка через запятую: service_name, user_id, month, category"
+// @Success 200 {object} models.TotalCostResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /subscriptions/total-cost [get].
+func (h *SubscriptionHandler) CalculateTotalCost(c echo.Context) error {
+	req, errResp := h.bindTotalCostRequest(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	response, err := h.service.CalculateTotalCost(c.Request().Context(), req)
+	if err != nil {
+		return handleError(c, err)
 	}
 
-	limit, _ := strconv.Atoi(c.QueryParam("limit"))
-	if limit < 1 || limit > 100 {
-		limit = 20
+	return c.JSON(http.StatusOK, response)
+}
+
+// CalculateTotalCostBreakdown godoc
+// @Summary Стоимость подписок по месяцам
+// @Description Разбивает общую стоимость подписок за период по календарным месяцам и сервисам
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param user_id query string false "ID пользователя для фильтрации"
+// @Param service_id query string false "ID сервиса из каталога для фильтрации"
+// @Param service_name query string false "Название сервиса для фильтрации"
+// @Param category query string false "Категория для фильтрации"
+// @Param tag query []string false "Теги, которые есть у подписки" collectionFormat(multi)
+// @Param start_period query string true "Начало периода (формат: MM-YYYY или YYYY-MM-DD)" example("01-2024")
+// @Param end_period query string true "Конец периода (формат: MM-YYYY или YYYY-MM-DD)" example("12-2024")
+// @Param target_currency query string false "Валюта отчета (по умолчанию валюта пользователя или RUB)"
+// @Param basis query string false "База расчета: charges или monthly" Enums(charges, monthly)
+// @Param proration query string false "daily - учитывать неполные периоды пропорционально дням" Enums(daily)
+// @Param include_deleted query bool false "Учитывать удаленные подписки"
+// @Success 200 {object} models.TotalCostBreakdownResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /subscriptions/total-cost/breakdown [get].
+func (h *SubscriptionHandler) CalculateTotalCostBreakdown(c echo.Context) error {
+	req, errResp := h.bindTotalCostRequest(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
 	}
 
-	var userID *uuid.UUID
+	response, err := h.service.CalculateTotalCostBreakdown(c.Request().Context(), req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ComparePlanPrices godoc
+// @Summary Сравнить цены подписок с тарифами
+// @Description Сравнивает цену действующих и будущих подписок с тарифом с текущей ценой тарифа в той же валюте и периоде оплаты, умноженной на billing_interval. Положительная разница означает, что подписка дороже тарифа
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param user_id query string false "ID пользователя"
+// @Param service_id query string false "ID сервиса"
+// @Success 200 {object} models.PlanPriceReportResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /subscriptions/plan-prices [get].
+func (h *SubscriptionHandler) ComparePlanPrices(c echo.Context) error {
+	var req models.PlanPriceReportRequest
 
 	if userIDStr := c.QueryParam("user_id"); userIDStr != "" {
 		id, err := uuid.Parse(userIDStr)
@@ -133,57 +709,109 @@ func (h *SubscriptionHandler) ListSubscriptions(c echo.Context) error {
 			})
 		}
 
-		userID = &id
+		req.UserID = &id
 	}
 
-	response, err := h.service.ListSubscriptions(c.Request().Context(), userID, page, limit)
+	if serviceIDStr := c.QueryParam("service_id"); serviceIDStr != "" {
+		id, err := uuid.Parse(serviceIDStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid service ID",
+				Message: "Service ID must be a valid UUID",
+			})
+		}
+
+		req.ServiceID = &id
+	}
+
+	response, err := h.service.ComparePlanPrices(c.Request().Context(), &req)
 	if err != nil {
-		return h.handleError(c, err)
+		return handleError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
-// @Router /subscriptions/total-cost [get].
-func (h *SubscriptionHandler) CalculateTotalCost(c echo.Context) error {
+func (h *SubscriptionHandler) bindTotalCostRequest(c echo.Context) (*models.TotalCostRequest, *models.ErrorResponse) {
 	var req models.TotalCostRequest
 
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		return nil, &models.ErrorResponse{
 			Error:   "Invalid query parameters",
 			Message: err.Error(),
-		})
+		}
 	}
 
 	if userIDStr := c.QueryParam("user_id"); userIDStr != "" {
 		id, err := uuid.Parse(userIDStr)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			return nil, &models.ErrorResponse{
 				Error:   "Invalid user ID",
 				Message: "User ID must be a valid UUID",
-			})
+			}
 		}
 
 		req.UserID = &id
 	}
 
-	response, err := h.service.CalculateTotalCost(c.Request().Context(), &req)
+	if serviceIDStr := c.QueryParam("service_id"); serviceIDStr != "" {
+		id, err := uuid.Parse(serviceIDStr)
+		if err != nil {
+			return nil, &models.ErrorResponse{
+				Error:   "Invalid service ID",
+				Message: "Service ID must be a valid UUID",
+			}
+		}
+
+		req.ServiceID = &id
+	}
+
+	return &req, nil
+}
+
+func parseIncludeDeleted(c echo.Context) (bool, error) {
+	value := c.QueryParam("include_deleted")
+	if value == "" {
+		return false, nil
+	}
+
+	includeDeleted, err := strconv.ParseBool(value)
 	if err != nil {
-		return h.handleError(c, err)
+		return false, fmt.Errorf("invalid include_deleted: %s", value)
 	}
 
-	return c.JSON(http.StatusOK, response)
+	return includeDeleted, nil
 }
 
-func (h *SubscriptionHandler) handleError(c echo.Context, err error) error {
+func handleError(c echo.Context, err error) error {
 	if err == nil {
 		return nil
 	}
 
+	status := errorStatus(err)
+
+	return c.JSON(status, models.ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: err.Error(),
+	})
+}
+
+// errorStatus returns the HTTP status that reports err.
+func errorStatus(err error) int {
 	status := http.StatusInternalServerError
-	errorMsg := err.Error()
 
 	switch {
+	case errors.Is(err, models.ErrMissingExchangeRate), errors.Is(err, models.ErrAmountOverflow):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, models.ErrExchangeRateExists), errors.Is(err, models.ErrVersionConflict),
+		errors.Is(err, models.ErrServiceNameTaken), errors.Is(err, models.ErrServiceInUse),
+		errors.Is(err, models.ErrPlanNameTaken), errors.Is(err, models.ErrPlanInUse),
+		errors.Is(err, models.ErrUserEmailTaken), errors.Is(err, models.ErrUserInUse):
+		status = http.StatusConflict
+	case errors.Is(err, models.ErrPreconditionFailed):
+		status = http.StatusPreconditionFailed
+	case errors.Is(err, models.ErrBatchRolledBack):
+		status = http.StatusFailedDependency
 	case err.Error() == "subscription not found":
 		status = http.StatusNotFound
 	case err.Error() == "invalid subscription data":
@@ -199,10 +827,7 @@ func (h *SubscriptionHandler) handleError(c echo.Context, err error) error {
 		}
 	}
 
-	return c.JSON(status, models.ErrorResponse{
-		Error:   http.StatusText(status),
-		Message: errorMsg,
-	})
+	return status
 }
 
 func contains(s, substr string) bool {
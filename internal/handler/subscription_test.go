@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/service"
+	"github.com/vnchk1/subscription-aggregator/internal/service/mocks"
+)
+
+func TestSubscriptionHandler_CreateSubscription_ErrorStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	services := mocks.NewMockServiceRepository(ctrl)
+	handler := NewSubscriptionHandler(service.NewSubscriptionService(mockRepo, services, mocks.NewMockUserRepository(ctrl)))
+
+	services.EXPECT().FindByName(gomock.Any(), gomock.Any()).Return(nil, models.ErrServiceNotFound).AnyTimes()
+
+	testCases := []struct {
+		name       string
+		body       string
+		repoErr    error
+		wantStatus int
+	}{
+		{
+			name:       "invalid request",
+			body:       `{"service_name": "Netflix", "price": "799", "start_date": "2024-13"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			// Ошибка сервера не должна сохраняться под Idempotency-Key как 400
+			name:       "repository failure",
+			body:       `{"service_name": "Netflix", "price": "799", "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba", "start_date": "01-2024"}`,
+			repoErr:    errors.New("connection refused"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.repoErr != nil {
+				mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(tc.repoErr)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			rec := httptest.NewRecorder()
+			_ = handler.CreateSubscription(echo.New().NewContext(req, rec))
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+		})
+	}
+}
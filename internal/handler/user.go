@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type UserHandler struct {
+	service service.UserService
+}
+
+func NewUserHandler(service service.UserService) *UserHandler {
+	return &UserHandler{
+		service: service,
+	}
+}
+
+// CreateUser godoc
+// @Summary Создать пользователя
+// @Description Создает пользователя. locale, default_currency и timezone получают значения по умолчанию, если не переданы
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body models.UserRequest true "Данные пользователя"
+// @Success 201 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users [post].
+func (h *UserHandler) CreateUser(c echo.Context) error {
+	var req models.UserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	user, err := h.service.CreateUser(c.Request().Context(), &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, user)
+}
+
+// ListUsers godoc
+// @Summary Список пользователей
+// @Description Возвращает пользователей с пагинацией. search ищет по началу имени или email без учета регистра
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param search query string false "Начало имени или email"
+// @Param page query int false "Номер страницы (по умолчанию 1)" default(1)
+// @Param limit query int false "Количество записей на странице (по умолчанию 20)" default(20)
+// @Success 200 {object} models.ListResponse{data=[]models.User}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users [get].
+func (h *UserHandler) ListUsers(c echo.Context) error {
+	var req models.ListUsersRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid query parameters",
+			Message: err.Error(),
+		})
+	}
+
+	response, err := h.service.ListUsers(c.Request().Context(), &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetUser godoc
+// @Summary Получить пользователя
+// @Description Возвращает пользователя по ID
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/{id} [get].
+func (h *UserHandler) GetUser(c echo.Context) error {
+	id, errResp := parseUserID(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	user, err := h.service.GetUser(c.Request().Context(), id)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// UpdateUser godoc
+// @Summary Обновить пользователя
+// @Description Заменяет данные пользователя
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Param request body models.UserRequest true "Данные пользователя"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/{id} [put].
+func (h *UserHandler) UpdateUser(c echo.Context) error {
+	id, errResp := parseUserID(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	var req models.UserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	user, err := h.service.UpdateUser(c.Request().Context(), id, &req)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser godoc
+// @Summary Удалить пользователя
+// @Description Удаляет пользователя. Пока у пользователя есть подписки, возвращает 409; с cascade=true его подписки окончательно удаляются вместе с ним
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Param cascade query bool false "Удалить подписки пользователя" default(false)
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/{id} [delete].
+func (h *UserHandler) DeleteUser(c echo.Context) error {
+	id, errResp := parseUserID(c)
+	if errResp != nil {
+		return c.JSON(http.StatusBadRequest, errResp)
+	}
+
+	cascade := false
+
+	if value := c.QueryParam("cascade"); value != "" {
+		var err error
+
+		if cascade, err = strconv.ParseBool(value); err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid query parameters",
+				Message: "invalid cascade: " + value,
+			})
+		}
+	}
+
+	if err := h.service.DeleteUser(c.Request().Context(), id, cascade); err != nil {
+		return handleError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func parseUserID(c echo.Context) (uuid.UUID, *models.ErrorResponse) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, &models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		}
+	}
+
+	return id, nil
+}
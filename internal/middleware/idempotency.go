@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderIdempotencyKey names the header a client marks retries of the
+	// same request with.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is set on responses replayed from a previous request.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored with the response body.
+var replayedHeaders = []string{echo.HeaderContentType, echo.HeaderLocation, "ETag"}
+
+// IdempotencyMiddleware makes a route safe to retry: the response to the first
+// request with an Idempotency-Key is stored for ttl and replayed to every
+// request with the same key instead of running the handler again. Reusing a
+// key with another request fails with 422. Responses with a 5xx status are
+// not stored, so the request can be retried; handlers therefore have to
+// report server errors as 5xx. The outcome is saved even when the client
+// cancels the request.
+func IdempotencyMiddleware(repo repository.IdempotencyRepository, ttl time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				return next(c)
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   http.StatusText(http.StatusBadRequest),
+					Message: "Idempotency-Key must be at most 255 characters",
+				})
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   "Invalid request body",
+					Message: err.Error(),
+				})
+			}
+
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := c.Request().Context()
+			record := &models.IdempotencyRecord{
+				Key:         key,
+				Scope:       c.Request().Method + " " + c.Path(),
+				RequestHash: requestHash(c.Request(), body),
+				ExpiresAt:   time.Now().Add(ttl),
+			}
+
+			reserved, err := repo.Reserve(ctx, record)
+			if err != nil {
+				return err
+			}
+
+			if !reserved {
+				return replay(c, repo, record)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			err = next(c)
+
+			// Ключ освобождается и после отмены запроса клиентом, иначе повтор получит 409
+			storeCtx := context.WithoutCancel(ctx)
+
+			if err != nil || c.Response().Status >= http.StatusInternalServerError {
+				return errors.Join(err, repo.Release(storeCtx, record.Key, record.Scope))
+			}
+
+			record.StatusCode = c.Response().Status
+			record.ResponseBody = recorder.body.Bytes()
+			record.ResponseHeaders = map[string]string{}
+
+			for _, name := range replayedHeaders {
+				if value := c.Response().Header().Get(name); value != "" {
+					record.ResponseHeaders[name] = value
+				}
+			}
+
+			return repo.Complete(storeCtx, record)
+		}
+	}
+}
+
+// requestHash fingerprints the method, URL and body of the request.
+func requestHash(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replay answers a request whose key is already taken with the stored response.
+func replay(c echo.Context, repo repository.IdempotencyRepository, record *models.IdempotencyRecord) error {
+	stored, err := repo.Get(c.Request().Context(), record.Key, record.Scope)
+
+	switch {
+	case errors.Is(err, models.ErrIdempotencyKeyNotFound):
+		// Первый запрос завершился ошибкой и освободил ключ
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   http.StatusText(http.StatusConflict),
+			Message: "request with this Idempotency-Key failed, retry it",
+		})
+	case err != nil:
+		return err
+	case stored.RequestHash != record.RequestHash:
+		return c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+			Error:   http.StatusText(http.StatusUnprocessableEntity),
+			Message: "Idempotency-Key was already used with a different request",
+		})
+	case stored.StatusCode == 0:
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   http.StatusText(http.StatusConflict),
+			Message: "request with this Idempotency-Key is still in progress",
+		})
+	}
+
+	for name, value := range stored.ResponseHeaders {
+		c.Response().Header().Set(name, value)
+	}
+
+	c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+
+	return c.Blob(stored.StatusCode, stored.ResponseHeaders[echo.HeaderContentType], stored.ResponseBody)
+}
+
+// responseRecorder keeps a copy of the response body written through it.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+
+	return r.ResponseWriter.Write(b)
+}
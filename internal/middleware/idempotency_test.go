@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+)
+
+// fakeIdempotencyRepository keeps the records in memory. Like the database it
+// ignores expired records and fails writes made with a canceled context.
+type fakeIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+func newFakeIdempotencyRepository() *fakeIdempotencyRepository {
+	return &fakeIdempotencyRepository{records: map[string]models.IdempotencyRecord{}}
+}
+
+func (r *fakeIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.records[record.Scope+record.Key]; ok && stored.ExpiresAt.After(time.Now()) {
+		return false, nil
+	}
+
+	r.records[record.Scope+record.Key] = models.IdempotencyRecord{
+		Key:         record.Key,
+		Scope:       record.Scope,
+		RequestHash: record.RequestHash,
+		ExpiresAt:   record.ExpiresAt,
+	}
+
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepository) Get(ctx context.Context, key, scope string) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.records[scope+key]
+	if !ok {
+		return nil, models.ErrIdempotencyKeyNotFound
+	}
+
+	return &stored, nil
+}
+
+func (r *fakeIdempotencyRepository) Complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records[record.Scope+record.Key] = *record
+
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) Release(ctx context.Context, key, scope string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, scope+key)
+
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+// newIdempotentServer serves handler at POST /subscriptions behind the middleware.
+func newIdempotentServer(repo *fakeIdempotencyRepository, handler echo.HandlerFunc) *echo.Echo {
+	e := echo.New()
+	e.POST("/subscriptions", handler, IdempotencyMiddleware(repo, time.Hour))
+
+	return e
+}
+
+func postIdempotent(ctx context.Context, e *echo.Echo, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(body)).WithContext(ctx)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(HeaderIdempotencyKey, key)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestIdempotencyMiddleware_ReplaysResponse(t *testing.T) {
+	var calls atomic.Int32
+
+	e := newIdempotentServer(newFakeIdempotencyRepository(), func(c echo.Context) error {
+		calls.Add(1)
+		c.Response().Header().Set("ETag", `"1"`)
+
+		return c.JSON(http.StatusCreated, map[string]int32{"call": calls.Load()})
+	})
+
+	first := postIdempotent(context.Background(), e, "key-1", `{"price": "100"}`)
+	second := postIdempotent(context.Background(), e, "key-1", `{"price": "100"}`)
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, `"1"`, second.Header().Get("ETag"))
+	assert.Equal(t, "true", second.Header().Get(HeaderIdempotentReplayed))
+	assert.Empty(t, first.Header().Get(HeaderIdempotentReplayed))
+}
+
+func TestIdempotencyMiddleware_DifferentBody(t *testing.T) {
+	var calls atomic.Int32
+
+	e := newIdempotentServer(newFakeIdempotencyRepository(), func(c echo.Context) error {
+		calls.Add(1)
+
+		return c.NoContent(http.StatusCreated)
+	})
+
+	require.Equal(t, http.StatusCreated, postIdempotent(context.Background(), e, "key-1", `{"price": "100"}`).Code)
+
+	rec := postIdempotent(context.Background(), e, "key-1", `{"price": "200"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyMiddleware_InProgress(t *testing.T) {
+	started := make(chan struct{})
+	finish := make(chan struct{})
+
+	e := newIdempotentServer(newFakeIdempotencyRepository(), func(c echo.Context) error {
+		close(started)
+		<-finish
+
+		return c.NoContent(http.StatusCreated)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+
+	go func() {
+		done <- postIdempotent(context.Background(), e, "key-1", `{"price": "100"}`)
+	}()
+
+	<-started
+
+	// Первый запрос еще выполняется
+	rec := postIdempotent(context.Background(), e, "key-1", `{"price": "100"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "still in progress")
+
+	close(finish)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+}
+
+func TestIdempotencyMiddleware_ServerErrorReleasesKey(t *testing.T) {
+	var calls atomic.Int32
+
+	e := newIdempotentServer(newFakeIdempotencyRepository(), func(c echo.Context) error {
+		if calls.Add(1) == 1 {
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.NoContent(http.StatusCreated)
+	})
+
+	require.Equal(t, http.StatusInternalServerError, postIdempotent(context.Background(), e, "key-1", `{"price": "100"}`).Code)
+
+	rec := postIdempotent(context.Background(), e, "key-1", `{"price": "100"}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyMiddleware_CanceledRequestReleasesKey(t *testing.T) {
+	var calls atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	e := newIdempotentServer(newFakeIdempotencyRepository(), func(c echo.Context) error {
+		if calls.Add(1) == 1 {
+			// Клиент отключился, пока запрос выполнялся
+			cancel()
+
+			return c.NoContent(http.StatusServiceUnavailable)
+		}
+
+		return c.NoContent(http.StatusCreated)
+	})
+
+	postIdempotent(ctx, e, "key-1", `{"price": "100"}`)
+
+	rec := postIdempotent(context.Background(), e, "key-1", `{"price": "100"}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -2,11 +2,19 @@ package middleware
 
 import (
 	"log/slog"
+	"net/http"
 	"time"
 
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
+// HeaderActor names the header a client identifies the person making the
+// change with. It is recorded in the subscription change log as is.
+const HeaderActor = "X-Actor"
+
 func LoggingMiddleware(logger *slog.Logger) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error { //nolint:varnamelen
@@ -16,6 +24,7 @@ func LoggingMiddleware(logger *slog.Logger) echo.MiddlewareFunc {
 			defer func() {
 				latency := time.Since(start)
 				logger.Info("completed request",
+					"request_id", c.Response().Header().Get(echo.HeaderXRequestID),
 					"method", c.Request().Method,
 					"path", c.Request().URL.Path,
 					"status", c.Response().Status,
@@ -27,3 +36,53 @@ func LoggingMiddleware(logger *slog.Logger) echo.MiddlewareFunc {
 		}
 	}
 }
+
+// DateFormatMiddleware stores the response date format requested with the
+// date_format query parameter ("iso" or "legacy") in the request context.
+func DateFormatMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			value := c.QueryParam("date_format")
+			if value == "" {
+				return next(c)
+			}
+
+			format, err := models.ParseDateFormat(value)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   http.StatusText(http.StatusBadRequest),
+					Message: err.Error(),
+				})
+			}
+
+			ctx := models.WithDateFormat(c.Request().Context(), format)
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
+
+// RequestInfoMiddleware stores the actor and request ID of the request in the
+// request context for the change log. The request ID is taken from the
+// X-Request-ID header or generated, and echoed in the response.
+func RequestInfoMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			requestID := c.Request().Header.Get(echo.HeaderXRequestID)
+			if requestID == "" {
+				requestID = uuid.NewString()
+			}
+
+			c.Response().Header().Set(echo.HeaderXRequestID, requestID)
+
+			ctx := models.WithRequestInfo(c.Request().Context(), models.RequestInfo{
+				Actor:     c.Request().Header.Get(HeaderActor),
+				RequestID: requestID,
+			})
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
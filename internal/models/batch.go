@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// MaxBatchOperations limits the number of operations of one batch request.
+const MaxBatchOperations = 1000
+
+// ErrBatchRolledBack marks an operation of an atomic batch that succeeded but
+// was rolled back because another operation failed.
+var ErrBatchRolledBack = errors.New("rolled back because another operation of the batch failed")
+
+// BatchMode says whether a batch is applied as a whole or operation by operation.
+type BatchMode string
+
+// An atomic batch runs in one transaction and changes nothing unless every
+// operation succeeds; a best-effort batch keeps the operations that succeeded.
+const (
+	BatchModeAtomic     BatchMode = "atomic"
+	BatchModeBestEffort BatchMode = "best_effort"
+)
+
+// BatchOperationType is the kind of change a batch operation makes.
+type BatchOperationType string
+
+const (
+	BatchOperationCreate BatchOperationType = "create"
+	BatchOperationUpdate BatchOperationType = "update"
+	BatchOperationDelete BatchOperationType = "delete"
+)
+
+// BatchOperation creates a subscription from Data as CreateSubscriptionRequest,
+// replaces subscription ID with Data as UpdateSubscriptionRequest or deletes
+// it. Version works as If-Match for updates and deletes.
+type BatchOperation struct {
+	Op      BatchOperationType `json:"op"`
+	ID      uuid.UUID          `json:"id,omitempty"`
+	Version *int               `json:"version,omitempty"`
+	Data    json.RawMessage    `json:"data,omitempty"    swaggertype:"object"`
+}
+
+// BatchRequest is atomic unless Mode says otherwise.
+type BatchRequest struct {
+	Mode       BatchMode         `json:"mode,omitempty"`
+	Operations []*BatchOperation `json:"operations"`
+}
+
+// BatchItemResult is the outcome of the operation at Index. Status is the HTTP
+// status the operation would have had as a separate request; Err is the error
+// behind a failed one.
+type BatchItemResult struct {
+	Index        int                   `json:"index"`
+	Op           BatchOperationType    `json:"op"`
+	ID           *uuid.UUID            `json:"id,omitempty"`
+	Status       int                   `json:"status"`
+	Subscription *SubscriptionResponse `json:"subscription,omitempty"`
+	Error        string                `json:"error,omitempty"`
+
+	Err error `json:"-"`
+}
+
+// BatchResponse lists the results in the order of the operations.
+type BatchResponse struct {
+	Mode      BatchMode          `json:"mode"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []*BatchItemResult `json:"results"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "errors"
+
+// BillingPeriod is the unit of the interval between two charges of a subscription.
+type BillingPeriod string
+
+const (
+	BillingPeriodWeekly    BillingPeriod = "weekly"
+	BillingPeriodMonthly   BillingPeriod = "monthly"
+	BillingPeriodQuarterly BillingPeriod = "quarterly"
+	BillingPeriodYearly    BillingPeriod = "yearly"
+)
+
+// DefaultBillingPeriod is used for subscriptions created without a billing period.
+const DefaultBillingPeriod = BillingPeriodMonthly
+
+var ErrInvalidBillingPeriod = errors.New("invalid billing period: must be weekly, monthly, quarterly or yearly")
+
+func (p BillingPeriod) IsValid() bool {
+	switch p {
+	case BillingPeriodWeekly, BillingPeriodMonthly, BillingPeriodQuarterly, BillingPeriodYearly:
+		return true
+	default:
+		return false
+	}
+}
+
+// CostBasis selects how a cost report bills subscriptions.
+type CostBasis string
+
+const (
+	// CostBasisCharges counts the charges that actually fall inside the period.
+	CostBasisCharges CostBasis = "charges"
+	// CostBasisMonthly spreads every charge evenly over the months it pays for
+	// and bills the monthly equivalent for every active month of the period.
+	CostBasisMonthly CostBasis = "monthly"
+)
+
+// Proration selects how a cost report bills charges that only partly fall
+// inside the period.
+type Proration string
+
+const (
+	// ProrationNone bills whole charges.
+	ProrationNone Proration = ""
+	// ProrationDaily bills the share of every charge proportional to the days
+	// of its billing period that fall inside the report period and the
+	// subscription dates.
+	ProrationDaily Proration = "daily"
+)
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// DefaultCurrency is used for subscriptions created without a currency and
+// as the target currency of cost reports.
+const DefaultCurrency = "RUB"
+
+var (
+	ErrInvalidCurrency     = errors.New("invalid currency: must be an ISO 4217 code")
+	ErrUnsupportedCurrency = errors.New("invalid currency: only currencies with 2 decimal places are supported")
+	ErrMissingExchangeRate = errors.New("missing exchange rate")
+	currencyCodePattern    = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// nonDecimalCurrencies are the ISO 4217 codes whose minor unit is not a
+// hundredth: zero-decimal (JPY), three- and four-decimal (KWD, CLF) currencies
+// and the units without a minor unit at all (XAU, XDR). Money and migration
+// 003 scale every amount by minorUnits, so these codes are rejected.
+var nonDecimalCurrencies = map[string]bool{
+	// 0 знаков после запятой
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true, "JPY": true,
+	"KMF": true, "KRW": true, "PYG": true, "RWF": true, "UGX": true, "UYI": true,
+	"VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+	// 3 и 4 знака после запятой
+	"BHD": true, "IQD": true, "JOD": true, "KWD": true, "LYD": true, "OMR": true,
+	"TND": true, "CLF": true, "UYW": true,
+	// Без дробных единиц: металлы, расчетные единицы и тестовые коды
+	"XAG": true, "XAU": true, "XBA": true, "XBB": true, "XBC": true, "XBD": true,
+	"XDR": true, "XPD": true, "XPT": true, "XSU": true, "XTS": true, "XUA": true,
+	"XXX": true,
+}
+
+// NormalizeCurrency upper-cases the ISO 4217 code of an amount and checks its
+// format. Only currencies with two decimal places are accepted (see minorUnits).
+func NormalizeCurrency(code string) (string, error) {
+	code, err := NormalizeCurrencyCode(code)
+	if err != nil {
+		return "", err
+	}
+
+	if nonDecimalCurrencies[code] {
+		return "", ErrUnsupportedCurrency
+	}
+
+	return code, nil
+}
+
+// NormalizeCurrencyCode upper-cases an ISO 4217 code and checks its format.
+// Exchange rates are per unit, so any currency may be quoted.
+func NormalizeCurrencyCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	if !currencyCodePattern.MatchString(code) {
+		return "", ErrInvalidCurrency
+	}
+
+	return code, nil
+}
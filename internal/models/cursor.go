@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListCursor is the position of a subscription in the list ordered by
+// (created_at, id) descending. Clients receive it as an opaque string.
+type ListCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func NewListCursor(sub *Subscription) *ListCursor {
+	return &ListCursor{
+		CreatedAt: sub.CreatedAt,
+		ID:        sub.ID,
+	}
+}
+
+func (c *ListCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeListCursor(s string) (*ListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAtStr, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtStr)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &ListCursor{
+		CreatedAt: createdAt,
+		ID:        id,
+	}, nil
+}
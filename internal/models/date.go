@@ -0,0 +1,95 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// MonthLayout is the legacy month-precision date format.
+	MonthLayout = "01-2006"
+	// DateLayout is the ISO 8601 day-precision date format.
+	DateLayout = time.DateOnly
+)
+
+var (
+	ErrInvalidDate       = errors.New("invalid date: must be YYYY-MM-DD or MM-YYYY")
+	ErrInvalidDateFormat = errors.New("invalid date_format: must be iso or legacy")
+)
+
+// ParseRangeStart parses the first day of a date range. A legacy month
+// starts on its first day.
+func ParseRangeStart(value string) (time.Time, error) {
+	if date, err := time.Parse(DateLayout, value); err == nil {
+		return date, nil
+	}
+
+	month, err := time.Parse(MonthLayout, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+
+	return month, nil
+}
+
+// ParseRangeEnd parses the last day of a date range, inclusive. A legacy
+// month ends on its last day.
+func ParseRangeEnd(value string) (time.Time, error) {
+	if date, err := time.Parse(DateLayout, value); err == nil {
+		return date, nil
+	}
+
+	month, err := time.Parse(MonthLayout, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+
+	return month.AddDate(0, 1, -1), nil
+}
+
+// DateFormat selects how subscription dates are rendered in responses.
+type DateFormat string
+
+const (
+	// DateFormatLegacy renders dates as MM-YYYY.
+	DateFormatLegacy DateFormat = "legacy"
+	// DateFormatISO renders dates as YYYY-MM-DD.
+	DateFormatISO DateFormat = "iso"
+)
+
+// ParseDateFormat checks that value names a supported date format.
+func ParseDateFormat(value string) (DateFormat, error) {
+	switch format := DateFormat(value); format {
+	case DateFormatISO, DateFormatLegacy:
+		return format, nil
+	default:
+		return "", ErrInvalidDateFormat
+	}
+}
+
+// Layout returns the time layout of the format.
+func (f DateFormat) Layout() string {
+	if f == DateFormatISO {
+		return DateLayout
+	}
+
+	return MonthLayout
+}
+
+type dateFormatKey struct{}
+
+// WithDateFormat returns a copy of ctx carrying the response date format.
+func WithDateFormat(ctx context.Context, format DateFormat) context.Context {
+	return context.WithValue(ctx, dateFormatKey{}, format)
+}
+
+// DateFormatFromContext returns the response date format of ctx,
+// DateFormatLegacy when none was set.
+func DateFormatFromContext(ctx context.Context) DateFormat {
+	if format, ok := ctx.Value(dateFormatKey{}).(DateFormat); ok {
+		return format
+	}
+
+	return DateFormatLegacy
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionEventAction is the kind of change a subscription event records.
+type SubscriptionEventAction string
+
+// A deleted subscription can be restored until it is purged, i.e. removed for good.
+const (
+	SubscriptionEventCreated  SubscriptionEventAction = "created"
+	SubscriptionEventUpdated  SubscriptionEventAction = "updated"
+	SubscriptionEventDeleted  SubscriptionEventAction = "deleted"
+	SubscriptionEventRestored SubscriptionEventAction = "restored"
+	SubscriptionEventPurged   SubscriptionEventAction = "purged"
+)
+
+// SubscriptionEvent is an entry of the subscription change log. Before and
+// After hold the subscription row as JSON; Before is empty for a created
+// subscription and After for a purged one.
+type SubscriptionEvent struct {
+	ID             int64                   `db:"id"              json:"id"`
+	SubscriptionID uuid.UUID               `db:"subscription_id" json:"subscription_id"`
+	Action         SubscriptionEventAction `db:"action"          json:"action"`
+	Before         json.RawMessage         `db:"before"          json:"before,omitempty"`
+	After          json.RawMessage         `db:"after"           json:"after,omitempty"`
+	Actor          string                  `db:"actor"           json:"actor,omitempty"`
+	RequestID      string                  `db:"request_id"      json:"request_id,omitempty"`
+	CreatedAt      time.Time               `db:"created_at"      json:"created_at"`
+}
+
+// SubscriptionEventResponse lists the fields an update changed in
+// ChangedFields.
+type SubscriptionEventResponse struct {
+	ID            int64                   `json:"id"`
+	Action        SubscriptionEventAction `json:"action"`
+	Before        json.RawMessage         `json:"before,omitempty"         swaggertype:"object"`
+	After         json.RawMessage         `json:"after,omitempty"          swaggertype:"object"`
+	ChangedFields []string                `json:"changed_fields,omitempty"`
+	Actor         string                  `json:"actor,omitempty"`
+	RequestID     string                  `json:"request_id,omitempty"`
+	CreatedAt     time.Time               `json:"created_at"`
+}
+
+// SubscriptionHistoryResponse lists the events of a subscription, oldest first.
+type SubscriptionHistoryResponse struct {
+	SubscriptionID uuid.UUID                    `json:"subscription_id"`
+	Events         []*SubscriptionEventResponse `json:"events"`
+}
+
+// RequestInfo identifies who made a request and which one, for the change log.
+type RequestInfo struct {
+	Actor     string
+	RequestID string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a copy of ctx carrying the request info.
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext returns the request info of ctx, empty when none
+// was set.
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+
+	return info
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrExchangeRateExists   = errors.New("exchange rate already exists")
+)
+
+// ExchangeRate says that one unit of BaseCurrency costs Rate units of
+// QuoteCurrency on RateDate.
+type ExchangeRate struct {
+	RateDate      time.Time `db:"rate_date"      json:"rate_date"`
+	BaseCurrency  string    `db:"base_currency"  json:"base_currency"`
+	QuoteCurrency string    `db:"quote_currency" json:"quote_currency"`
+	Rate          float64   `db:"rate"           json:"rate"`
+	CreatedAt     time.Time `db:"created_at"     json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"     json:"updated_at"`
+}
+
+// ExchangeRateKey identifies a rate by its date and currency pair.
+type ExchangeRateKey struct {
+	RateDate      time.Time
+	BaseCurrency  string
+	QuoteCurrency string
+}
+
+type CreateExchangeRateRequest struct {
+	RateDate      string  `json:"rate_date"`
+	BaseCurrency  string  `json:"base_currency"`
+	QuoteCurrency string  `json:"quote_currency"`
+	Rate          float64 `json:"rate"`
+}
+
+type PutExchangeRateRequest struct {
+	Rate float64 `json:"rate"`
+}
+
+type ListExchangeRatesRequest struct {
+	BaseCurrency  string `query:"base_currency"`
+	QuoteCurrency string `query:"quote_currency"`
+	From          string `query:"from"`
+	To            string `query:"to"`
+	Page          int    `query:"page"`
+	Limit         int    `query:"limit"`
+}
+
+// ExchangeRateFilter narrows the rate list. Empty fields are not applied.
+type ExchangeRateFilter struct {
+	BaseCurrency  string
+	QuoteCurrency string
+	From          *time.Time
+	To            *time.Time
+}
+
+type ExchangeRateResponse struct {
+	RateDate      string    `json:"rate_date"`
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          float64   `json:"rate"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// RateFileFormat is the format of an exchange rate file accepted by the importer.
+type RateFileFormat string
+
+const (
+	// RateFileFormatCSV has a rate_date,base_currency,quote_currency,rate header
+	// and dates as YYYY-MM-DD.
+	RateFileFormatCSV RateFileFormat = "csv"
+	// RateFileFormatCBR is the daily XML of the Central Bank of Russia
+	// (XML_daily.asp): rates of foreign currencies to RUB on one date.
+	RateFileFormatCBR RateFileFormat = "cbr"
+)
+
+// AppliedExchangeRate is a rate a cost report used to convert charges of
+// Currency billed in Month. Charges on different dates of one month can use
+// different rates, so a currency and month can have several of them.
+type AppliedExchangeRate struct {
+	Currency string
+	Month    time.Time
+	RateDate time.Time
+	Rate     float64
+}
+
+type AppliedExchangeRateResponse struct {
+	Currency string  `json:"currency"`
+	Month    string  `json:"month"`
+	RateDate string  `json:"rate_date"`
+	Rate     float64 `json:"rate"`
+}
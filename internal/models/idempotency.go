@@ -0,0 +1,23 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+// IdempotencyRecord is the outcome of the first request made with an
+// Idempotency-Key. Scope is the method and route the key was used with;
+// RequestHash fingerprints the request so that a reused key with another
+// body is detected. StatusCode is zero while the request is in progress.
+type IdempotencyRecord struct {
+	Key             string            `db:"key"`
+	Scope           string            `db:"scope"`
+	RequestHash     string            `db:"request_hash"`
+	StatusCode      int               `db:"status_code"`
+	ResponseHeaders map[string]string `db:"response_headers"`
+	ResponseBody    []byte            `db:"response_body"`
+	CreatedAt       time.Time         `db:"created_at"`
+	ExpiresAt       time.Time         `db:"expires_at"`
+}
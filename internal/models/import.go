@@ -0,0 +1,36 @@
+package models
+
+// MaxImportRows limits the number of rows of one subscription import.
+const MaxImportRows = 10000
+
+// SubscriptionCSVColumns are the columns of a subscription import file, in
+// any order. The columns of CreateSubscriptionRequest with omitempty may be
+// left out of the header.
+var SubscriptionCSVColumns = []string{
+	"service_name", "price", "currency", "billing_period", "billing_interval", "user_id", "start_date",
+	"end_date", "service_id", "plan_id", "category", "tags",
+}
+
+// IgnoredSubscriptionCSVColumns are the columns of a CSV export that an import
+// skips, so that an exported file can be imported back.
+var IgnoredSubscriptionCSVColumns = []string{"id", "created_at", "updated_at", "deleted_at", "version"}
+
+// TagSeparator joins the tags of a subscription in the tags column of import
+// and export files.
+const TagSeparator = ","
+
+// ImportRowError reports why the row at Line of the file was not imported.
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportResponse counts the rows of the file. A dry run imports nothing; a
+// real run imports the Valid rows and skips the rows listed in Errors.
+type ImportResponse struct {
+	DryRun   bool              `json:"dry_run"`
+	Rows     int               `json:"rows"`
+	Valid    int               `json:"valid"`
+	Imported int               `json:"imported"`
+	Errors   []*ImportRowError `json:"errors"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionStatus is the state of a subscription on the current date
+// (CURRENT_DATE of the database): active when it has started and has not
+// ended, ended when its end date has passed and future when its start date is
+// still ahead. A subscription ending today is still active.
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive SubscriptionStatus = "active"
+	SubscriptionStatusEnded  SubscriptionStatus = "ended"
+	SubscriptionStatusFuture SubscriptionStatus = "future"
+)
+
+type ListSubscriptionsRequest struct {
+	UserID            *uuid.UUID `query:"user_id"`
+	ServiceID         *uuid.UUID `query:"service_id"`
+	ServiceName       string     `query:"service_name"`
+	ServiceNameSearch string     `query:"service_name_search"`
+	Category          string     `query:"category"`
+	Tags              []string   `query:"tag"`
+	MinPrice          string     `query:"min_price"`
+	MaxPrice          string     `query:"max_price"`
+	ActiveOn          string     `query:"active_on"`
+	Status            string     `query:"status"`
+	StartFrom         string     `query:"start_from"`
+	StartTo           string     `query:"start_to"`
+	Sort              string     `query:"sort"`
+	Page              int        `query:"page"`
+	Limit             int        `query:"limit"`
+	Cursor            string     `query:"cursor"`
+	IncludeDeleted    bool       `query:"include_deleted"`
+}
+
+// SubscriptionSortFields lists the keys ListSubscriptions can be sorted by.
+var SubscriptionSortFields = map[string]bool{
+	"service_name": true,
+	"price":        true,
+	"currency":     true,
+	"user_id":      true,
+	"start_date":   true,
+	"end_date":     true,
+	"created_at":   true,
+	"updated_at":   true,
+}
+
+// SortField is one key of the list order, e.g. "-price" is {Field: "price", Desc: true}.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// SubscriptionListFilter narrows ListSubscriptions. Nil fields are not applied.
+// ServiceNamePrefix matches case-insensitively; dates are month-precision.
+// Prices are in minor units and compared regardless of currency.
+// Sort keys are applied before the default (created_at, id) descending order.
+// Deleted subscriptions are skipped unless IncludeDeleted is set.
+// ServiceID, Category and Tags match as in SubscriptionFilter.
+type SubscriptionListFilter struct {
+	UserID            *uuid.UUID
+	ServiceID         *uuid.UUID
+	ServiceName       *string
+	ServiceNamePrefix *string
+	Category          *string
+	Tags              []string
+	MinPrice          *int64
+	MaxPrice          *int64
+	ActiveOn          *time.Time
+	Status            *SubscriptionStatus
+	StartFrom         *time.Time
+	StartTo           *time.Time
+	Sort              []SortField
+	IncludeDeleted    bool
+}
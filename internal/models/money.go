@@ -0,0 +1,143 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// minorUnits is the number of minor units (kopecks, cents) in a unit of
+// every supported currency. Amounts are stored with two decimal places, so
+// NormalizeCurrency rejects currencies with a different ISO 4217 exponent.
+const minorUnits = 100
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount: must be a decimal with at most 2 decimal places")
+	ErrAmountOverflow   = errors.New("amount overflow")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money is an amount in minor units of Currency. It is marshaled to JSON as a
+// decimal string of the amount, e.g. "299.99"; the currency is reported by a
+// separate field of the enclosing object.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses a decimal amount such as "149.5" into minor units.
+func ParseMoney(amount, currency string) (Money, error) {
+	minor, err := parseMinorUnits(amount)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+func parseMinorUnits(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	units, fraction, hasFraction := strings.Cut(s, ".")
+	if !isDigits(units) || (hasFraction && !isDigits(fraction)) || len(fraction) > 2 {
+		return 0, ErrInvalidAmount
+	}
+
+	whole, err := strconv.ParseInt(units, 10, 64)
+	if err != nil {
+		return 0, ErrAmountOverflow
+	}
+
+	// "149.5" означает 149 рублей 50 копеек
+	minor, _ := strconv.ParseInt(fraction+strings.Repeat("0", 2-len(fraction)), 10, 64)
+
+	if whole > (math.MaxInt64-minor)/minorUnits {
+		return 0, ErrAmountOverflow
+	}
+
+	amount := whole*minorUnits + minor
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Add returns the sum of two amounts of the same currency. It fails instead
+// of wrapping around when the sum does not fit into int64.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// String formats the amount as a decimal with two decimal places.
+func (m Money) String() string {
+	sign := ""
+	amount := uint64(m.Amount)
+
+	if m.Amount < 0 {
+		sign = "-"
+		amount = uint64(-(m.Amount + 1)) + 1
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, amount/minorUnits, amount%minorUnits)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON accepts both a decimal string ("299.99") and a JSON number
+// (299.99) so that clients sending whole prices as numbers keep working.
+// The currency is left untouched.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	amount, err := parseMinorUnits(text)
+	if err != nil {
+		return err
+	}
+
+	m.Amount = amount
+
+	return nil
+}
@@ -2,14 +2,26 @@ package models
 
 import "errors"
 
-var ErrNotFound = errors.New("subscription not found")
+var (
+	ErrNotFound = errors.New("subscription not found")
+	// ErrVersionConflict means the subscription changed after it was read.
+	ErrVersionConflict = errors.New("subscription was modified concurrently")
+	// ErrPreconditionFailed means the subscription version does not match If-Match.
+	ErrPreconditionFailed = errors.New("subscription version does not match If-Match")
+)
 
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
 }
 
+// ListResponse is a page of a list. Page is omitted when the page was
+// requested by cursor; NextCursor is set while there are more items.
 type ListResponse struct {
-	Total int         `json:"total"`
-	Data  interface{} `json:"data"`
+	Total      int         `json:"total"`
+	Page       int         `json:"page,omitempty"`
+	Limit      int         `json:"limit"`
+	HasMore    bool        `json:"has_more"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Data       interface{} `json:"data"`
 }
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrServiceNotFound = errors.New("service not found")
+	// ErrServiceNameTaken means another service already has the name or alias.
+	ErrServiceNameTaken = errors.New("service name or alias is already used by another service")
+	// ErrServiceInUse means subscriptions still refer to the service.
+	ErrServiceInUse = errors.New("service is used by subscriptions")
+)
+
+// Service is an entry of the service catalog. Subscriptions created with Name
+// or one of Aliases, in any letter case, are linked to the service.
+// DefaultCurrency is the currency of such subscriptions unless they set one.
+type Service struct {
+	ID              uuid.UUID `db:"id"               json:"id"`
+	Name            string    `db:"name"             json:"name"`
+	Aliases         []string  `db:"aliases"          json:"aliases"`
+	Category        string    `db:"category"         json:"category,omitempty"`
+	Website         string    `db:"website"          json:"website,omitempty"`
+	DefaultCurrency string    `db:"default_currency" json:"default_currency"`
+	CreatedAt       time.Time `db:"created_at"       json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at"       json:"updated_at"`
+}
+
+// ServiceRequest creates or replaces a service. DefaultCurrency defaults to
+// DefaultCurrency.
+type ServiceRequest struct {
+	Name            string   `json:"name"`
+	Aliases         []string `json:"aliases,omitempty"`
+	Category        string   `json:"category,omitempty"`
+	Website         string   `json:"website,omitempty"`
+	DefaultCurrency string   `json:"default_currency,omitempty"`
+}
+
+type ListServicesRequest struct {
+	Category string `query:"category"`
+	Search   string `query:"search"`
+	Page     int    `query:"page"`
+	Limit    int    `query:"limit"`
+}
+
+// ServiceFilter narrows the service list. Search matches the beginning of the
+// name or an alias case-insensitively. Empty fields are not applied.
+type ServiceFilter struct {
+	Category string
+	Search   string
+}
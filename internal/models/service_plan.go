@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPlanNotFound = errors.New("plan not found")
+	// ErrPlanNameTaken means the service already has a plan with the name.
+	ErrPlanNameTaken = errors.New("plan name is already used by another plan of the service")
+	// ErrPlanInUse means subscriptions still refer to the plan.
+	ErrPlanInUse = errors.New("plan is used by subscriptions")
+)
+
+// ServicePlan is a plan of a catalog service, e.g. "Spotify Family", with its
+// list prices.
+type ServicePlan struct {
+	ID        uuid.UUID
+	ServiceID uuid.UUID
+	Name      string
+	Prices    []*PlanPrice
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PlanPrice is the list price of a plan for one BillingPeriod in Price.Currency.
+type PlanPrice struct {
+	BillingPeriod BillingPeriod
+	Price         Money
+}
+
+// ListPrice returns the price of one billing period of the plan in the currency.
+func (p *ServicePlan) ListPrice(currency string, period BillingPeriod) (Money, bool) {
+	for _, price := range p.Prices {
+		if price.Price.Currency == currency && price.BillingPeriod == period {
+			return price.Price, true
+		}
+	}
+
+	return Money{}, false
+}
+
+// ServicePlanRequest creates or replaces a plan together with all its prices.
+type ServicePlanRequest struct {
+	Name   string              `json:"name"`
+	Prices []*PlanPriceRequest `json:"prices"`
+}
+
+// PlanPriceRequest is a list price; BillingPeriod defaults to DefaultBillingPeriod
+// and Currency to DefaultCurrency.
+type PlanPriceRequest struct {
+	Currency      string `json:"currency,omitempty"`
+	BillingPeriod string `json:"billing_period,omitempty"`
+	Price         Money  `json:"price"                    swaggertype:"string"`
+}
+
+type ServicePlanResponse struct {
+	ID        uuid.UUID            `json:"id"`
+	ServiceID uuid.UUID            `json:"service_id"`
+	Name      string               `json:"name"`
+	Prices    []*PlanPriceResponse `json:"prices"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+type PlanPriceResponse struct {
+	Currency      string        `json:"currency"`
+	BillingPeriod BillingPeriod `json:"billing_period"`
+	Price         Money         `json:"price"          swaggertype:"string"`
+}
+
+type PlanPriceReportRequest struct {
+	UserID    *uuid.UUID `query:"user_id"`
+	ServiceID *uuid.UUID `query:"service_id"`
+}
+
+// PlanPriceFilter selects the subscriptions of the plan price report. Nil
+// fields are not applied.
+type PlanPriceFilter struct {
+	UserID    *uuid.UUID
+	ServiceID *uuid.UUID
+}
+
+// PlanPriceComparison is the price of a subscription and the current list
+// price of its plan for the same currency and billing period, multiplied by
+// the billing interval. ListPrice is nil when the plan has no such price.
+type PlanPriceComparison struct {
+	SubscriptionID  uuid.UUID
+	UserID          uuid.UUID
+	ServiceName     string
+	PlanID          uuid.UUID
+	PlanName        string
+	BillingPeriod   BillingPeriod
+	BillingInterval int
+	Price           Money
+	ListPrice       *Money
+}
+
+// PlanPriceComparisonResponse reports Difference as Price minus ListPrice, so
+// a positive difference means the subscription costs more than the plan now.
+type PlanPriceComparisonResponse struct {
+	SubscriptionID  uuid.UUID     `json:"subscription_id"`
+	UserID          uuid.UUID     `json:"user_id"`
+	ServiceName     string        `json:"service_name"`
+	PlanID          uuid.UUID     `json:"plan_id"`
+	PlanName        string        `json:"plan_name"`
+	BillingPeriod   BillingPeriod `json:"billing_period"`
+	BillingInterval int           `json:"billing_interval"`
+	Currency        string        `json:"currency"`
+	Price           Money         `json:"price"                swaggertype:"string"`
+	ListPrice       *Money        `json:"list_price,omitempty" swaggertype:"string"`
+	Difference      *Money        `json:"difference,omitempty" swaggertype:"string"`
+}
+
+// PlanPriceReportResponse lists the active and future subscriptions with a plan.
+type PlanPriceReportResponse struct {
+	Subscriptions []*PlanPriceComparisonResponse `json:"subscriptions"`
+}
@@ -6,38 +6,126 @@ import (
 	"github.com/google/uuid"
 )
 
+// Subscription is billed Price every BillingInterval BillingPeriods starting
+// from StartDate. Price.Currency is stored in the currency column. Price is the
+// latest price of the subscription; earlier prices are kept in its price
+// history.
+//
+// PriceEffectiveFrom is not stored in the subscription: when set, Update adds
+// Price to the history from that date instead of replacing the history.
+//
+// A deleted subscription keeps its row with DeletedAt set until it is purged.
+// Version grows with every change and is the ETag of the subscription.
+// ServiceID links the subscription to the service catalog and PlanID to a
+// plan of that service. Category and Tags are lower-case labels set by the
+// user; Tags are sorted and unique.
 type Subscription struct {
-	ID          uuid.UUID  `db:"id"           json:"id"`
-	ServiceName string     `db:"service_name" json:"service_name"`
-	Price       int        `db:"price"        json:"price"`
-	UserID      uuid.UUID  `db:"user_id"      json:"user_id"`
-	StartDate   time.Time  `db:"start_date"   json:"start_date"`
-	EndDate     *time.Time `db:"end_date"     json:"end_date,omitempty"`
-	CreatedAt   time.Time  `db:"created_at"   json:"created_at"`
-	UpdatedAt   time.Time  `db:"updated_at"   json:"updated_at"`
+	ID              uuid.UUID     `db:"id"               json:"id"`
+	ServiceID       *uuid.UUID    `db:"service_id"       json:"service_id,omitempty"`
+	PlanID          *uuid.UUID    `db:"plan_id"          json:"plan_id,omitempty"`
+	ServiceName     string        `db:"service_name"     json:"service_name"`
+	Price           Money         `db:"price"            json:"price"                swaggertype:"string"`
+	BillingPeriod   BillingPeriod `db:"billing_period"   json:"billing_period"`
+	BillingInterval int           `db:"billing_interval" json:"billing_interval"`
+	UserID          uuid.UUID     `db:"user_id"          json:"user_id"`
+	StartDate       time.Time     `db:"start_date"       json:"start_date"`
+	EndDate         *time.Time    `db:"end_date"         json:"end_date,omitempty"`
+	CreatedAt       time.Time     `db:"created_at"       json:"created_at"`
+	UpdatedAt       time.Time     `db:"updated_at"       json:"updated_at"`
+	DeletedAt       *time.Time    `db:"deleted_at"       json:"deleted_at,omitempty"`
+	Version         int           `db:"version"          json:"version"`
+	Category        string        `db:"category"         json:"category,omitempty"`
+	Tags            []string      `db:"tags"             json:"tags"`
+
+	PriceEffectiveFrom *time.Time `db:"-" json:"-"`
+}
+
+// SubscriptionPrice is the price of a subscription from EffectiveFrom until
+// the next price of its history.
+type SubscriptionPrice struct {
+	SubscriptionID uuid.UUID `db:"subscription_id" json:"subscription_id"`
+	EffectiveFrom  time.Time `db:"effective_from"  json:"effective_from"`
+	Price          Money     `db:"price"           json:"price"           swaggertype:"string"`
+	CreatedAt      time.Time `db:"created_at"      json:"created_at"`
 }
 
+// CreateSubscriptionRequest bills monthly unless BillingPeriod is set;
+// BillingInterval defaults to 1. The subscription is linked to the catalog
+// service ServiceID or, without it, to the service named ServiceName, and
+// takes the canonical name of the service. ServiceName may be empty when
+// ServiceID is set.
+//
+// PlanID subscribes to a plan: the subscription belongs to the service of the
+// plan, and without Price it is charged the list price of the plan for its
+// currency and billing period times BillingInterval.
+//
+// The subscription is open-ended unless EndDate is set.
 type CreateSubscriptionRequest struct {
-	ServiceName string    `json:"service_name"`
-	Price       int       `json:"price"`
-	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"`
+	ServiceID       *uuid.UUID `json:"service_id,omitempty"`
+	PlanID          *uuid.UUID `json:"plan_id,omitempty"`
+	ServiceName     string     `json:"service_name"`
+	Price           Money      `json:"price"                      swaggertype:"string"`
+	Currency        string     `json:"currency,omitempty"`
+	BillingPeriod   string     `json:"billing_period,omitempty"`
+	BillingInterval int        `json:"billing_interval,omitempty"`
+	UserID          uuid.UUID  `json:"user_id"`
+	StartDate       string     `json:"start_date"`
+	EndDate         *string    `json:"end_date,omitempty"`
+	Category        string     `json:"category,omitempty"`
+	Tags            []string   `json:"tags,omitempty"`
 }
 
+// UpdateSubscriptionRequest keeps the current currency and billing settings
+// when those fields are empty. A changed price applies from PriceEffectiveFrom,
+// or from the current month when it is empty; a PriceEffectiveFrom on or
+// before StartDate corrects the whole price history.
+// Nil Category and Tags keep the current labels; "" and [] remove them.
+// The catalog service is resolved as on create, except that a subscription
+// keeps its service while ServiceName stays the same. A subscription moved to
+// another service loses its plan.
 type UpdateSubscriptionRequest struct {
-	ServiceName string  `json:"service_name"`
-	Price       int     `json:"price"`
-	StartDate   string  `json:"start_date"`
-	EndDate     *string `json:"end_date,omitempty"`
+	ServiceID          *uuid.UUID `json:"service_id,omitempty"`
+	ServiceName        string     `json:"service_name"`
+	Price              Money      `json:"price"                          swaggertype:"string"`
+	Currency           string     `json:"currency,omitempty"`
+	PriceEffectiveFrom string     `json:"price_effective_from,omitempty"`
+	BillingPeriod      string     `json:"billing_period,omitempty"`
+	BillingInterval    int        `json:"billing_interval,omitempty"`
+	StartDate          string     `json:"start_date"`
+	EndDate            *string    `json:"end_date,omitempty"`
+	Category           *string    `json:"category,omitempty"`
+	Tags               []string   `json:"tags,omitempty"`
 }
 
 type SubscriptionResponse struct {
-	ID          uuid.UUID `json:"id"`
-	ServiceName string    `json:"service_name"`
-	Price       int       `json:"price"`
-	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"`
-	EndDate     *string   `json:"end_date,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID              uuid.UUID     `json:"id"`
+	ServiceID       *uuid.UUID    `json:"service_id,omitempty"`
+	PlanID          *uuid.UUID    `json:"plan_id,omitempty"`
+	ServiceName     string        `json:"service_name"`
+	Price           Money         `json:"price"                swaggertype:"string"`
+	Currency        string        `json:"currency"`
+	BillingPeriod   BillingPeriod `json:"billing_period"`
+	BillingInterval int           `json:"billing_interval"`
+	UserID          uuid.UUID     `json:"user_id"`
+	StartDate       string        `json:"start_date"`
+	EndDate         *string       `json:"end_date,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	DeletedAt       *time.Time    `json:"deleted_at,omitempty"`
+	Version         int           `json:"version"`
+	Category        string        `json:"category,omitempty"`
+	Tags            []string      `json:"tags"`
+}
+
+type SubscriptionPriceResponse struct {
+	EffectiveFrom string `json:"effective_from"`
+	EffectiveTo   string `json:"effective_to,omitempty"`
+	Price         Money  `json:"price"                  swaggertype:"string"`
+	Currency      string `json:"currency"`
+}
+
+// SubscriptionPricesResponse lists the price history from the earliest price.
+type SubscriptionPricesResponse struct {
+	SubscriptionID uuid.UUID                    `json:"subscription_id"`
+	Prices         []*SubscriptionPriceResponse `json:"prices"`
 }
@@ -8,31 +8,130 @@ import (
 
 type TotalCostRequest struct {
 	UserID      *uuid.UUID `query:"user_id"`
+	ServiceID   *uuid.UUID `query:"service_id"`
 	ServiceName *string    `query:"service_name"`
+	Category    *string    `query:"category"`
+	Tags        []string   `query:"tag"`
 	StartPeriod string     `query:"start_period"`
 	EndPeriod   string     `query:"end_period"`
+	GroupBy     string     `query:"group_by"`
+	// TargetCurrency defaults to the currency of the user with UserID, else to DefaultCurrency.
+	TargetCurrency string `query:"target_currency"`
+	// Basis is "charges" (default) or "monthly" for the normalized monthly equivalent.
+	Basis string `query:"basis"`
+	// Proration is empty or "daily" to bill partial periods in proportion.
+	Proration string `query:"proration"`
+	// IncludeDeleted bills deleted subscriptions too.
+	IncludeDeleted bool `query:"include_deleted"`
 }
 
 type TotalCostResponse struct {
-	TotalCost int    `json:"total_cost"`
-	Currency  string `json:"currency"`
-	Period    string `json:"period"`
+	TotalCost Money                `json:"total_cost"          swaggertype:"string"`
+	Currency  string               `json:"currency"`
+	Period    string               `json:"period"`
+	Basis     CostBasis            `json:"basis"`
+	Proration Proration            `json:"proration,omitempty"`
+	Groups    []*CostGroupResponse `json:"groups,omitempty"`
+	// ExchangeRates lists the rates used to convert charges in other currencies;
+	// a currency can have several rates in one month.
+	ExchangeRates []*AppliedExchangeRateResponse `json:"exchange_rates,omitempty"`
 }
 
-type SubscriptionFilter struct {
-	UserID      *uuid.UUID
+// CostDimension is a column the total cost can be grouped by.
+type CostDimension string
+
+const (
+	CostDimensionServiceName CostDimension = "service_name"
+	CostDimensionUserID      CostDimension = "user_id"
+	CostDimensionMonth       CostDimension = "month"
+	CostDimensionCategory    CostDimension = "category"
+)
+
+// CostGroup is the subtotal of one bucket in minor units of the target
+// currency. Only the dimensions the cost was grouped by are set.
+// Category is nil for the group of subscriptions without a category.
+type CostGroup struct {
 	ServiceName *string
-	StartDate   time.Time
-	EndDate     time.Time
+	UserID      *uuid.UUID
+	Month       *time.Time
+	Category    *string
+	Amount      int64
+}
+
+type CostGroupResponse struct {
+	ServiceName *string    `json:"service_name,omitempty"`
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	Month       *string    `json:"month,omitempty"`
+	Category    *string    `json:"category,omitempty"`
+	Amount      Money      `json:"amount"                 swaggertype:"string"`
+}
+
+// TotalCostBreakdownResponse splits the total cost of the period by calendar month.
+type TotalCostBreakdownResponse struct {
+	TotalCost Money                `json:"total_cost"          swaggertype:"string"`
+	Currency  string               `json:"currency"`
+	Period    string               `json:"period"`
+	Basis     CostBasis            `json:"basis"`
+	Proration Proration            `json:"proration,omitempty"`
+	Months    []*MonthCostResponse `json:"months"`
+	// ExchangeRates lists the rates used to convert charges in other currencies;
+	// a currency can have several rates in one month.
+	ExchangeRates []*AppliedExchangeRateResponse `json:"exchange_rates,omitempty"`
+}
+
+type MonthCostResponse struct {
+	Month               string                 `json:"month"`
+	Amount              Money                  `json:"amount"               swaggertype:"string"`
+	ActiveSubscriptions int                    `json:"active_subscriptions"`
+	Services            []*ServiceCostResponse `json:"services"`
+}
+
+type ServiceCostResponse struct {
+	ServiceName   string `json:"service_name"`
+	Amount        Money  `json:"amount"        swaggertype:"string"`
+	Subscriptions int    `json:"subscriptions"`
+}
+
+// MonthlyCost is the cost of one service in one month of the period, in
+// minor units of the target currency.
+// ServiceName is empty for months without active subscriptions.
+type MonthlyCost struct {
+	Month         time.Time
+	ServiceName   string
+	Amount        int64
+	Subscriptions int
+}
+
+// SubscriptionFilter selects the subscriptions billed in a cost report.
+// Every charge is converted to TargetCurrency at the rate of its charge date.
+// Basis defaults to CostBasisCharges. StartDate and EndDate are inclusive days.
+// Deleted subscriptions are skipped unless IncludeDeleted is set.
+// ServiceID selects the subscriptions linked to the catalog service and the
+// unlinked ones named after it or one of its aliases. Tags selects the
+// subscriptions labeled with every one of them.
+type SubscriptionFilter struct {
+	UserID         *uuid.UUID
+	ServiceID      *uuid.UUID
+	ServiceName    *string
+	Category       *string
+	Tags           []string
+	StartDate      time.Time
+	EndDate        time.Time
+	TargetCurrency string
+	Basis          CostBasis
+	Proration      Proration
+	IncludeDeleted bool
 }
 
+// ParseDates parses the period as YYYY-MM-DD days or MM-YYYY months; the
+// end is inclusive, so an end month includes all its days.
 func (r *TotalCostRequest) ParseDates() (time.Time, time.Time, error) {
-	startDate, err := time.Parse("01-2006", r.StartPeriod)
+	startDate, err := ParseRangeStart(r.StartPeriod)
 	if err != nil {
 		return time.Time{}, time.Time{}, err
 	}
 
-	endDate, err := time.Parse("01-2006", r.EndPeriod)
+	endDate, err := ParseRangeEnd(r.EndPeriod)
 	if err != nil {
 		return time.Time{}, time.Time{}, err
 	}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLocale   = "ru-RU"
+	DefaultTimezone = "Europe/Moscow"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserEmailTaken means another user already has the email.
+	ErrUserEmailTaken = errors.New("email is already used by another user")
+	// ErrUserInUse means the user still has subscriptions.
+	ErrUserInUse = errors.New("user has subscriptions")
+)
+
+// User owns subscriptions. Email is empty only for the users created from
+// the subscriptions that existed before the users table; it is unique
+// regardless of letter case. Locale is a BCP 47 language tag kept for clients.
+// DefaultCurrency is the currency of the user's cost reports without a target
+// currency, and Timezone, an IANA time zone name, decides which month is the
+// current one for the user's price changes.
+type User struct {
+	ID              uuid.UUID `db:"id"               json:"id"`
+	DisplayName     string    `db:"display_name"     json:"display_name"`
+	Email           string    `db:"email"            json:"email,omitempty"`
+	Locale          string    `db:"locale"           json:"locale"`
+	DefaultCurrency string    `db:"default_currency" json:"default_currency"`
+	Timezone        string    `db:"timezone"         json:"timezone"`
+	CreatedAt       time.Time `db:"created_at"       json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at"       json:"updated_at"`
+}
+
+// UserRequest creates or replaces a user. Locale, DefaultCurrency and
+// Timezone default to DefaultLocale, DefaultCurrency and DefaultTimezone.
+type UserRequest struct {
+	DisplayName     string `json:"display_name"`
+	Email           string `json:"email"`
+	Locale          string `json:"locale,omitempty"`
+	DefaultCurrency string `json:"default_currency,omitempty"`
+	Timezone        string `json:"timezone,omitempty"`
+}
+
+type ListUsersRequest struct {
+	Search string `query:"search"`
+	Page   int    `query:"page"`
+	Limit  int    `query:"limit"`
+}
+
+// UserFilter narrows the user list. Search matches the beginning of the
+// display name or the email case-insensitively. Empty fields are not applied.
+type UserFilter struct {
+	Search string
+}
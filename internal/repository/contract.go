@@ -2,24 +2,56 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/vnchk1/subscription-aggregator/internal/models"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// dbtx is implemented by both the pool and a transaction. Begin on a
+// transaction starts a savepoint.
+type dbtx interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
 type SubscriptionRepository interface {
 	Create(ctx context.Context, subscription *models.Subscription) error
-	GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	CreateMany(ctx context.Context, subscriptions []*models.Subscription) error
+	GetByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*models.Subscription, error)
 	Update(ctx context.Context, subscription *models.Subscription) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, userID *uuid.UUID, limit, offset int) ([]*models.Subscription, int, error)
-	GetTotalCost(ctx context.Context, filter *models.SubscriptionFilter) (int, error)
+	Delete(ctx context.Context, id uuid.UUID, version *int) error
+	Restore(ctx context.Context, id uuid.UUID) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
+	ListPrices(ctx context.Context, id uuid.UUID) ([]*models.SubscriptionPrice, error)
+	ListEvents(ctx context.Context, id uuid.UUID) ([]*models.SubscriptionEvent, error)
+	List(
+		ctx context.Context,
+		filter *models.SubscriptionListFilter,
+		limit, offset int,
+		cursor *models.ListCursor,
+	) ([]*models.Subscription, int, error)
+	Export(ctx context.Context, filter *models.SubscriptionListFilter, fn func(subscription *models.Subscription) error) error
+	GetTotalCost(ctx context.Context, filter *models.SubscriptionFilter) (int64, error)
+	GetMonthlyCost(ctx context.Context, filter *models.SubscriptionFilter) ([]*models.MonthlyCost, int64, error)
+	GetGroupedCost(
+		ctx context.Context,
+		filter *models.SubscriptionFilter,
+		groupBy []models.CostDimension,
+	) ([]*models.CostGroup, int64, error)
+	GetAppliedExchangeRates(ctx context.Context, filter *models.SubscriptionFilter) ([]*models.AppliedExchangeRate, error)
+	ComparePlanPrices(ctx context.Context, filter *models.PlanPriceFilter) ([]*models.PlanPriceComparison, error)
+	WithinTransaction(ctx context.Context, fn func(repo SubscriptionRepository) error) error
 }
 
 type subscriptionRepository struct {
-	db *pgxpool.Pool
+	db dbtx
 }
 
 func NewSubscriptionRepository(db *pgxpool.Pool) SubscriptionRepository {
@@ -27,3 +59,83 @@ func NewSubscriptionRepository(db *pgxpool.Pool) SubscriptionRepository {
 		db: db,
 	}
 }
+
+type ExchangeRateRepository interface {
+	Create(ctx context.Context, rate *models.ExchangeRate) error
+	Upsert(ctx context.Context, rate *models.ExchangeRate) error
+	UpsertMany(ctx context.Context, rates []*models.ExchangeRate) error
+	Get(ctx context.Context, key models.ExchangeRateKey) (*models.ExchangeRate, error)
+	Delete(ctx context.Context, key models.ExchangeRateKey) error
+	List(ctx context.Context, filter *models.ExchangeRateFilter, limit, offset int) ([]*models.ExchangeRate, int, error)
+}
+
+type exchangeRateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewExchangeRateRepository(db *pgxpool.Pool) ExchangeRateRepository {
+	return &exchangeRateRepository{
+		db: db,
+	}
+}
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record *models.IdempotencyRecord) (bool, error)
+	Get(ctx context.Context, key, scope string) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, record *models.IdempotencyRecord) error
+	Release(ctx context.Context, key, scope string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type idempotencyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool) IdempotencyRepository {
+	return &idempotencyRepository{
+		db: db,
+	}
+}
+
+type ServiceRepository interface {
+	Create(ctx context.Context, service *models.Service) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Service, error)
+	FindByName(ctx context.Context, name string) (*models.Service, error)
+	Update(ctx context.Context, service *models.Service) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, filter *models.ServiceFilter, limit, offset int) ([]*models.Service, int, error)
+	CreatePlan(ctx context.Context, plan *models.ServicePlan) error
+	GetPlan(ctx context.Context, id uuid.UUID) (*models.ServicePlan, error)
+	UpdatePlan(ctx context.Context, plan *models.ServicePlan) error
+	DeletePlan(ctx context.Context, serviceID, id uuid.UUID) error
+	ListPlans(ctx context.Context, serviceID uuid.UUID) ([]*models.ServicePlan, error)
+}
+
+type serviceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewServiceRepository(db *pgxpool.Pool) ServiceRepository {
+	return &serviceRepository{
+		db: db,
+	}
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	ExistingIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id uuid.UUID, cascade bool) error
+	List(ctx context.Context, filter *models.UserFilter, limit, offset int) ([]*models.User, int, error)
+}
+
+type userRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUserRepository(db *pgxpool.Pool) UserRepository {
+	return &userRepository{
+		db: db,
+	}
+}
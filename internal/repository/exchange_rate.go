@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const exchangeRateColumns = "rate_date, base_currency, quote_currency, rate, created_at, updated_at"
+
+const upsertExchangeRateQuery = `
+	INSERT INTO exchange_rates (rate_date, base_currency, quote_currency, rate)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (base_currency, quote_currency, rate_date)
+	DO UPDATE SET rate = EXCLUDED.rate, updated_at = NOW()
+	RETURNING created_at, updated_at
+`
+
+// uniqueViolation is the PostgreSQL error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+func scanExchangeRate(row pgx.Row, rate *models.ExchangeRate) error {
+	return row.Scan(
+		&rate.RateDate,
+		&rate.BaseCurrency,
+		&rate.QuoteCurrency,
+		&rate.Rate,
+		&rate.CreatedAt,
+		&rate.UpdatedAt,
+	)
+}
+
+func (r *exchangeRateRepository) Create(ctx context.Context, rate *models.ExchangeRate) error {
+	query := `
+		INSERT INTO exchange_rates (rate_date, base_currency, quote_currency, rate)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		rate.RateDate,
+		rate.BaseCurrency,
+		rate.QuoteCurrency,
+		rate.Rate,
+	).Scan(&rate.CreatedAt, &rate.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return models.ErrExchangeRateExists
+		}
+
+		return fmt.Errorf("failed to create exchange rate: %w", err)
+	}
+
+	return nil
+}
+
+// Upsert stores the rate of the currency pair on its date, replacing a rate
+// already stored for that date.
+func (r *exchangeRateRepository) Upsert(ctx context.Context, rate *models.ExchangeRate) error {
+	err := r.db.QueryRow(ctx, upsertExchangeRateQuery,
+		rate.RateDate,
+		rate.BaseCurrency,
+		rate.QuoteCurrency,
+		rate.Rate,
+	).Scan(&rate.CreatedAt, &rate.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save exchange rate: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertMany stores all rates in one transaction, so an import either loads
+// the whole file or nothing.
+func (r *exchangeRateRepository) UpsertMany(ctx context.Context, rates []*models.ExchangeRate) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, rate := range rates {
+		err = tx.QueryRow(ctx, upsertExchangeRateQuery,
+			rate.RateDate,
+			rate.BaseCurrency,
+			rate.QuoteCurrency,
+			rate.Rate,
+		).Scan(&rate.CreatedAt, &rate.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save exchange rate %s/%s on %s: %w",
+				rate.BaseCurrency, rate.QuoteCurrency, rate.RateDate.Format("2006-01-02"), err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *exchangeRateRepository) Get(ctx context.Context, key models.ExchangeRateKey) (*models.ExchangeRate, error) {
+	query := `
+		SELECT ` + exchangeRateColumns + `
+		FROM exchange_rates
+		WHERE base_currency = $1 AND quote_currency = $2 AND rate_date = $3
+	`
+
+	var rate models.ExchangeRate
+
+	err := scanExchangeRate(r.db.QueryRow(ctx, query, key.BaseCurrency, key.QuoteCurrency, key.RateDate), &rate)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrExchangeRateNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+
+	return &rate, nil
+}
+
+func (r *exchangeRateRepository) Delete(ctx context.Context, key models.ExchangeRateKey) error {
+	query := `DELETE FROM exchange_rates WHERE base_currency = $1 AND quote_currency = $2 AND rate_date = $3`
+
+	result, err := r.db.Exec(ctx, query, key.BaseCurrency, key.QuoteCurrency, key.RateDate)
+	if err != nil {
+		return fmt.Errorf("failed to delete exchange rate: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return models.ErrExchangeRateNotFound
+	}
+
+	return nil
+}
+
+// List returns a page of rates, newest first, and the total number of rates
+// matching the filter.
+func (r *exchangeRateRepository) List(
+	ctx context.Context,
+	filter *models.ExchangeRateFilter,
+	limit, offset int,
+) ([]*models.ExchangeRate, int, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.BaseCurrency != "" {
+		add("base_currency = $%d", filter.BaseCurrency)
+	}
+
+	if filter.QuoteCurrency != "" {
+		add("quote_currency = $%d", filter.QuoteCurrency)
+	}
+
+	if filter.From != nil {
+		add("rate_date >= $%d", *filter.From)
+	}
+
+	if filter.To != nil {
+		add("rate_date <= $%d", *filter.To)
+	}
+
+	var total int
+
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM exchange_rates"+whereClause(conditions), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count exchange rates: %w", err)
+	}
+
+	query := `
+		SELECT ` + exchangeRateColumns + `
+		FROM exchange_rates` + whereClause(conditions) + fmt.Sprintf(`
+		ORDER BY rate_date DESC, base_currency, quote_currency
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := make([]*models.ExchangeRate, 0, limit)
+
+	for rows.Next() {
+		var rate models.ExchangeRate
+
+		if err = scanExchangeRate(rows, &rate); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+
+		rates = append(rates, &rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating exchange rates: %w", err)
+	}
+
+	return rates, total, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Reserve claims the key for a new request. It returns false when the key is
+// already taken by an unexpired record, which the caller then reads with Get.
+// An expired record is replaced.
+func (r *idempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (key, scope, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key, scope) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status_code = NULL, response_headers = NULL,
+			response_body = NULL, created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+	`
+
+	result, err := r.db.Exec(ctx, query, record.Key, record.Scope, record.RequestHash, record.ExpiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, key, scope string) (*models.IdempotencyRecord, error) {
+	query := `
+		SELECT key, scope, request_hash, COALESCE(status_code, 0), response_headers, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE key = $1 AND scope = $2
+	`
+
+	var record models.IdempotencyRecord
+
+	err := r.db.QueryRow(ctx, query, key, scope).Scan(
+		&record.Key,
+		&record.Scope,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ResponseHeaders,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrIdempotencyKeyNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &record, nil
+}
+
+// Complete stores the response of the request that reserved the key.
+func (r *idempotencyRepository) Complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $3, response_headers = $4, response_body = $5
+		WHERE key = $1 AND scope = $2
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		record.Key,
+		record.Scope,
+		record.StatusCode,
+		record.ResponseHeaders,
+		record.ResponseBody,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// Release frees a reserved key whose request failed, so that it can be retried.
+func (r *idempotencyRepository) Release(ctx context.Context, key, scope string) error {
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND scope = $2 AND status_code IS NULL`
+
+	if _, err := r.db.Exec(ctx, query, key, scope); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the records that expired before now and returns how
+// many were removed.
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return int(result.RowsAffected()), nil
+}
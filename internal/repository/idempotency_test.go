@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewIdempotencyRepository(pool)
+	ctx := context.Background()
+
+	record := &models.IdempotencyRecord{
+		Key:         "import-42",
+		Scope:       "POST /subscriptions",
+		RequestHash: "a665a45920422f9d417e4867efdc4fb8a04a1f3fff1fa07e998e86f7f7a27ae3",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	reserved, err := repo.Reserve(ctx, record)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	// Повтор, пока первый запрос выполняется
+	reserved, err = repo.Reserve(ctx, record)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+
+	stored, err := repo.Get(ctx, record.Key, record.Scope)
+	require.NoError(t, err)
+	assert.Zero(t, stored.StatusCode)
+
+	record.StatusCode = http.StatusCreated
+	record.ResponseHeaders = map[string]string{"Content-Type": "application/json", "ETag": `"1"`}
+	record.ResponseBody = []byte(`{"id":"1"}`)
+	require.NoError(t, repo.Complete(ctx, record))
+
+	stored, err = repo.Get(ctx, record.Key, record.Scope)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, stored.StatusCode)
+	assert.Equal(t, record.ResponseHeaders, stored.ResponseHeaders)
+	assert.Equal(t, record.ResponseBody, stored.ResponseBody)
+
+	// Завершенный ключ не освобождается
+	require.NoError(t, repo.Release(ctx, record.Key, record.Scope))
+
+	_, err = repo.Get(ctx, record.Key, record.Scope)
+	require.NoError(t, err)
+
+	// Истекший ключ можно занять снова
+	_, err = pool.Exec(ctx, "UPDATE idempotency_keys SET expires_at = NOW() - INTERVAL '1 second'")
+	require.NoError(t, err)
+
+	reserved, err = repo.Reserve(ctx, record)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	_, err = pool.Exec(ctx, "UPDATE idempotency_keys SET expires_at = NOW() - INTERVAL '1 second'")
+	require.NoError(t, err)
+
+	count, err := repo.DeleteExpired(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	_, err = repo.Get(ctx, record.Key, record.Scope)
+	assert.ErrorIs(t, err, models.ErrIdempotencyKeyNotFound)
+}
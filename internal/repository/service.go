@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const serviceColumns = "id, name, aliases, COALESCE(category, ''), COALESCE(website, ''), default_currency, created_at, updated_at"
+
+// foreignKeyViolation is the PostgreSQL error code of a foreign key violation.
+const foreignKeyViolation = "23503"
+
+func scanService(row pgx.Row, service *models.Service) error {
+	return row.Scan(
+		&service.ID,
+		&service.Name,
+		&service.Aliases,
+		&service.Category,
+		&service.Website,
+		&service.DefaultCurrency,
+		&service.CreatedAt,
+		&service.UpdatedAt,
+	)
+}
+
+func (r *serviceRepository) Create(ctx context.Context, service *models.Service) error {
+	query := `
+		INSERT INTO services (name, aliases, category, website, default_currency)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5)
+		RETURNING id, created_at, updated_at
+	`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query,
+		service.Name,
+		service.Aliases,
+		service.Category,
+		service.Website,
+		service.DefaultCurrency,
+	).Scan(&service.ID, &service.CreatedAt, &service.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+
+	if err = insertServiceNames(ctx, tx, service); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertServiceNames stores the keys of the name and aliases of the service.
+func insertServiceNames(ctx context.Context, tx pgx.Tx, service *models.Service) error {
+	query := `
+		INSERT INTO service_names (name_key, service_id)
+		SELECT DISTINCT service_name_key(name), $1
+		FROM unnest($2::TEXT[]) AS name
+	`
+
+	names := append([]string{service.Name}, service.Aliases...)
+
+	if _, err := tx.Exec(ctx, query, service.ID, names); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return models.ErrServiceNameTaken
+		}
+
+		return fmt.Errorf("failed to save service names: %w", err)
+	}
+
+	return nil
+}
+
+func (r *serviceRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Service, error) {
+	query := `SELECT ` + serviceColumns + ` FROM services WHERE id = $1`
+
+	var service models.Service
+
+	if err := scanService(r.db.QueryRow(ctx, query, id), &service); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrServiceNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+
+	return &service, nil
+}
+
+// FindByName returns the service whose name or alias matches the name
+// regardless of letter case and extra spaces.
+func (r *serviceRepository) FindByName(ctx context.Context, name string) (*models.Service, error) {
+	query := `
+		SELECT ` + serviceColumns + `
+		FROM services
+		WHERE id = (SELECT service_id FROM service_names WHERE name_key = service_name_key($1))
+	`
+
+	var service models.Service
+
+	if err := scanService(r.db.QueryRow(ctx, query, name), &service); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrServiceNotFound
+		}
+
+		return nil, fmt.Errorf("failed to find service: %w", err)
+	}
+
+	return &service, nil
+}
+
+// Update replaces the service and the names it is found by.
+func (r *serviceRepository) Update(ctx context.Context, service *models.Service) error {
+	query := `
+		UPDATE services
+		SET name = $2, aliases = $3, category = NULLIF($4, ''), website = NULLIF($5, ''), default_currency = $6
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query,
+		service.ID,
+		service.Name,
+		service.Aliases,
+		service.Category,
+		service.Website,
+		service.DefaultCurrency,
+	).Scan(&service.CreatedAt, &service.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ErrServiceNotFound
+		}
+
+		return fmt.Errorf("failed to update service: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM service_names WHERE service_id = $1`, service.ID); err != nil {
+		return fmt.Errorf("failed to delete service names: %w", err)
+	}
+
+	if err = insertServiceNames(ctx, tx, service); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the service. It fails with models.ErrServiceInUse while
+// subscriptions, deleted ones included, are linked to it.
+func (r *serviceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM services WHERE id = $1`, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return models.ErrServiceInUse
+		}
+
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return models.ErrServiceNotFound
+	}
+
+	return nil
+}
+
+// List returns a page of services ordered by name and the total number of
+// services matching the filter.
+func (r *serviceRepository) List(
+	ctx context.Context,
+	filter *models.ServiceFilter,
+	limit, offset int,
+) ([]*models.Service, int, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Category != "" {
+		add("category = $%d", filter.Category)
+	}
+
+	if filter.Search != "" {
+		add(`id IN (SELECT service_id FROM service_names WHERE name_key LIKE service_name_key($%d) ESCAPE '\')`,
+			escapeLike(filter.Search)+"%")
+	}
+
+	var total int
+
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM services"+whereClause(conditions), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count services: %w", err)
+	}
+
+	query := `
+		SELECT ` + serviceColumns + `
+		FROM services` + whereClause(conditions) + fmt.Sprintf(`
+		ORDER BY lower(name), id
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list services: %w", err)
+	}
+	defer rows.Close()
+
+	services := make([]*models.Service, 0, limit)
+
+	for rows.Next() {
+		var service models.Service
+
+		if err = scanService(rows, &service); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan service: %w", err)
+		}
+
+		services = append(services, &service)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating services: %w", err)
+	}
+
+	return services, total, nil
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const planColumns = "id, service_id, name, created_at, updated_at"
+
+func scanPlan(row pgx.Row, plan *models.ServicePlan) error {
+	return row.Scan(&plan.ID, &plan.ServiceID, &plan.Name, &plan.CreatedAt, &plan.UpdatedAt)
+}
+
+// planError maps the constraint violations of a plan write to model errors.
+func planError(action string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation:
+			return models.ErrPlanNameTaken
+		case foreignKeyViolation:
+			return models.ErrServiceNotFound
+		}
+	}
+
+	return fmt.Errorf("failed to %s plan: %w", action, err)
+}
+
+func (r *serviceRepository) CreatePlan(ctx context.Context, plan *models.ServicePlan) error {
+	query := `
+		INSERT INTO service_plans (service_id, name)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query, plan.ServiceID, plan.Name).Scan(&plan.ID, &plan.CreatedAt, &plan.UpdatedAt)
+	if err != nil {
+		return planError("create", err)
+	}
+
+	if err = insertPlanPrices(ctx, tx, plan); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func insertPlanPrices(ctx context.Context, tx pgx.Tx, plan *models.ServicePlan) error {
+	query := `
+		INSERT INTO plan_prices (plan_id, currency, billing_period, price)
+		SELECT $1, unnest($2::TEXT[]), unnest($3::TEXT[]), unnest($4::BIGINT[])
+	`
+
+	var (
+		currencies = make([]string, len(plan.Prices))
+		periods    = make([]string, len(plan.Prices))
+		amounts    = make([]int64, len(plan.Prices))
+	)
+
+	for i, price := range plan.Prices {
+		currencies[i] = price.Price.Currency
+		periods[i] = string(price.BillingPeriod)
+		amounts[i] = price.Price.Amount
+	}
+
+	if _, err := tx.Exec(ctx, query, plan.ID, currencies, periods, amounts); err != nil {
+		return fmt.Errorf("failed to save plan prices: %w", err)
+	}
+
+	return nil
+}
+
+// GetPlan returns the plan with its list prices.
+func (r *serviceRepository) GetPlan(ctx context.Context, id uuid.UUID) (*models.ServicePlan, error) {
+	query := `SELECT ` + planColumns + ` FROM service_plans WHERE id = $1`
+
+	var plan models.ServicePlan
+
+	if err := scanPlan(r.db.QueryRow(ctx, query, id), &plan); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrPlanNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	if err := r.loadPlanPrices(ctx, []*models.ServicePlan{&plan}); err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// UpdatePlan replaces the name and the prices of the plan of plan.ServiceID.
+func (r *serviceRepository) UpdatePlan(ctx context.Context, plan *models.ServicePlan) error {
+	query := `
+		UPDATE service_plans
+		SET name = $3
+		WHERE id = $1 AND service_id = $2
+		RETURNING created_at, updated_at
+	`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query, plan.ID, plan.ServiceID, plan.Name).Scan(&plan.CreatedAt, &plan.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ErrPlanNotFound
+		}
+
+		return planError("update", err)
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM plan_prices WHERE plan_id = $1`, plan.ID); err != nil {
+		return fmt.Errorf("failed to delete plan prices: %w", err)
+	}
+
+	if err = insertPlanPrices(ctx, tx, plan); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePlan removes the plan of the service. It fails with
+// models.ErrPlanInUse while subscriptions are linked to the plan.
+func (r *serviceRepository) DeletePlan(ctx context.Context, serviceID, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM service_plans WHERE id = $1 AND service_id = $2`, id, serviceID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return models.ErrPlanInUse
+		}
+
+		return fmt.Errorf("failed to delete plan: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return models.ErrPlanNotFound
+	}
+
+	return nil
+}
+
+// ListPlans returns the plans of the service ordered by name.
+func (r *serviceRepository) ListPlans(ctx context.Context, serviceID uuid.UUID) ([]*models.ServicePlan, error) {
+	query := `SELECT ` + planColumns + ` FROM service_plans WHERE service_id = $1 ORDER BY lower(name), id`
+
+	rows, err := r.db.Query(ctx, query, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plans: %w", err)
+	}
+	defer rows.Close()
+
+	var plans []*models.ServicePlan
+
+	for rows.Next() {
+		var plan models.ServicePlan
+
+		if err = scanPlan(rows, &plan); err != nil {
+			return nil, fmt.Errorf("failed to scan plan: %w", err)
+		}
+
+		plans = append(plans, &plan)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating plans: %w", err)
+	}
+
+	if err = r.loadPlanPrices(ctx, plans); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+// loadPlanPrices sets the list prices of the plans with one query.
+func (r *serviceRepository) loadPlanPrices(ctx context.Context, plans []*models.ServicePlan) error {
+	ids := make([]uuid.UUID, len(plans))
+	byID := make(map[uuid.UUID]*models.ServicePlan, len(plans))
+
+	for i, plan := range plans {
+		ids[i] = plan.ID
+		byID[plan.ID] = plan
+		plan.Prices = []*models.PlanPrice{}
+	}
+
+	query := `
+		SELECT plan_id, currency, billing_period, price
+		FROM plan_prices
+		WHERE plan_id = ANY($1)
+		ORDER BY currency, CASE billing_period
+			WHEN 'weekly' THEN 1 WHEN 'monthly' THEN 2 WHEN 'quarterly' THEN 3 ELSE 4
+		END
+	`
+
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("failed to list plan prices: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			planID uuid.UUID
+			price  models.PlanPrice
+		)
+
+		if err = rows.Scan(&planID, &price.Price.Currency, &price.BillingPeriod, &price.Price.Amount); err != nil {
+			return fmt.Errorf("failed to scan plan price: %w", err)
+		}
+
+		byID[planID].Prices = append(byID[planID].Prices, &price)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating plan prices: %w", err)
+	}
+
+	return nil
+}
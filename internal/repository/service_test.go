@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+)
+
+func TestServiceRepository(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewServiceRepository(pool)
+	ctx := context.Background()
+
+	service := &models.Service{
+		Name:            "Yandex Plus",
+		Aliases:         []string{"Яндекс Плюс", "yandex  plus"},
+		Category:        "music",
+		DefaultCurrency: "RUB",
+	}
+	require.NoError(t, repo.Create(ctx, service))
+
+	// Название ищется без учета регистра и лишних пробелов
+	found, err := repo.FindByName(ctx, "  ЯНДЕКС   плюс ")
+	require.NoError(t, err)
+	assert.Equal(t, service.ID, found.ID)
+	assert.Equal(t, "", found.Website)
+
+	_, err = repo.FindByName(ctx, "Netflix")
+	require.ErrorIs(t, err, models.ErrServiceNotFound)
+
+	// Псевдоним уже занят первым сервисом
+	err = repo.Create(ctx, &models.Service{Name: "Kinopoisk", Aliases: []string{"yandex plus"}, DefaultCurrency: "RUB"})
+	require.ErrorIs(t, err, models.ErrServiceNameTaken)
+
+	service.Aliases = []string{"Plus"}
+	require.NoError(t, repo.Update(ctx, service))
+
+	_, err = repo.FindByName(ctx, "Яндекс Плюс")
+	require.ErrorIs(t, err, models.ErrServiceNotFound)
+
+	found, err = repo.FindByName(ctx, "plus")
+	require.NoError(t, err)
+	assert.Equal(t, service.ID, found.ID)
+
+	services, total, err := repo.List(ctx, &models.ServiceFilter{Search: "pl"}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, services, 1)
+	assert.Equal(t, []string{"Plus"}, services[0].Aliases)
+
+	subscriptions := NewSubscriptionRepository(pool)
+	require.NoError(t, subscriptions.Create(ctx, &models.Subscription{
+		ServiceID:       &service.ID,
+		ServiceName:     service.Name,
+		Price:           models.NewMoney(399, models.DefaultCurrency),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          createTestUser(t, pool),
+		StartDate:       month(2024, time.January),
+	}))
+
+	require.ErrorIs(t, repo.Delete(ctx, service.ID), models.ErrServiceInUse)
+	require.ErrorIs(t, repo.Delete(ctx, uuid.New()), models.ErrServiceNotFound)
+}
+
+func TestSubscriptionRepository_GetTotalCost_ServiceID(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	services := NewServiceRepository(pool)
+	ctx := context.Background()
+
+	service := &models.Service{Name: "Netflix", Aliases: []string{"Нетфликс"}, DefaultCurrency: "RUB"}
+	require.NoError(t, services.Create(ctx, service))
+
+	userID := createTestUser(t, pool)
+
+	require.NoError(t, repo.Create(ctx, &models.Subscription{
+		ServiceID:       &service.ID,
+		ServiceName:     "Netflix",
+		Price:           models.NewMoney(1000, models.DefaultCurrency),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          userID,
+		StartDate:       month(2024, time.January),
+	}))
+	// Подписка без ссылки на сервис, созданная под псевдонимом
+	createTestSubscription(t, repo, userID, "нетфликс", 500, month(2024, time.January), nil)
+	createTestSubscription(t, repo, userID, "Spotify", 300, month(2024, time.January), nil)
+
+	total, err := repo.GetTotalCost(ctx, &models.SubscriptionFilter{
+		ServiceID: &service.ID,
+		StartDate: month(2024, time.January),
+		EndDate:   monthEnd(2024, time.January),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), total)
+
+	subscriptions, _, err := repo.List(ctx, &models.SubscriptionListFilter{ServiceID: &service.ID}, 10, 0, nil)
+	require.NoError(t, err)
+	assert.Len(t, subscriptions, 2)
+}
+
+func TestServiceRepository_Plans(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewServiceRepository(pool)
+	subscriptions := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	service := &models.Service{Name: "Spotify", Aliases: []string{}, DefaultCurrency: "RUB"}
+	require.NoError(t, repo.Create(ctx, service))
+
+	plan := &models.ServicePlan{
+		ServiceID: service.ID,
+		Name:      "Family",
+		Prices: []*models.PlanPrice{
+			{BillingPeriod: models.BillingPeriodYearly, Price: models.NewMoney(269000, "RUB")},
+			{BillingPeriod: models.BillingPeriodMonthly, Price: models.NewMoney(26900, "RUB")},
+		},
+	}
+	require.NoError(t, repo.CreatePlan(ctx, plan))
+
+	err := repo.CreatePlan(ctx, &models.ServicePlan{ServiceID: service.ID, Name: "Family"})
+	require.ErrorIs(t, err, models.ErrPlanNameTaken)
+
+	err = repo.CreatePlan(ctx, &models.ServicePlan{ServiceID: uuid.New(), Name: "Family"})
+	require.ErrorIs(t, err, models.ErrServiceNotFound)
+
+	stored, err := repo.GetPlan(ctx, plan.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Prices, 2)
+	assert.Equal(t, models.BillingPeriodMonthly, stored.Prices[0].BillingPeriod)
+
+	require.NoError(t, subscriptions.Create(ctx, &models.Subscription{
+		ServiceID:       &service.ID,
+		PlanID:          &plan.ID,
+		ServiceName:     service.Name,
+		Price:           models.NewMoney(2*24900, "RUB"),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 2,
+		UserID:          createTestUser(t, pool),
+		StartDate:       month(2024, time.January),
+	}))
+
+	// Прейскурант поднялся после оформления подписки
+	plan.Prices = []*models.PlanPrice{{BillingPeriod: models.BillingPeriodMonthly, Price: models.NewMoney(29900, "RUB")}}
+	require.NoError(t, repo.UpdatePlan(ctx, plan))
+
+	comparisons, err := subscriptions.ComparePlanPrices(ctx, &models.PlanPriceFilter{ServiceID: &service.ID})
+	require.NoError(t, err)
+	require.Len(t, comparisons, 1)
+	assert.Equal(t, "Family", comparisons[0].PlanName)
+	assert.Equal(t, models.NewMoney(2*24900, "RUB"), comparisons[0].Price)
+	assert.Equal(t, models.NewMoney(2*29900, "RUB"), *comparisons[0].ListPrice)
+
+	require.ErrorIs(t, repo.DeletePlan(ctx, service.ID, plan.ID), models.ErrPlanInUse)
+	require.ErrorIs(t, repo.DeletePlan(ctx, uuid.New(), plan.ID), models.ErrPlanNotFound)
+}
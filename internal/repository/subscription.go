@@ -4,18 +4,101 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/vnchk1/subscription-aggregator/internal/models"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// subscriptionColumns is the column list scanned by scanSubscription.
+const subscriptionColumns = "id, service_id, plan_id, service_name, price, currency, billing_period, billing_interval, " +
+	"user_id, start_date, end_date, created_at, updated_at, deleted_at, version, COALESCE(category, ''), tags"
+
+func scanSubscription(row pgx.Row, subscription *models.Subscription) error {
+	return row.Scan(
+		&subscription.ID,
+		&subscription.ServiceID,
+		&subscription.PlanID,
+		&subscription.ServiceName,
+		&subscription.Price.Amount,
+		&subscription.Price.Currency,
+		&subscription.BillingPeriod,
+		&subscription.BillingInterval,
+		&subscription.UserID,
+		&subscription.StartDate,
+		&subscription.EndDate,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+		&subscription.DeletedAt,
+		&subscription.Version,
+		&subscription.Category,
+		&subscription.Tags,
+	)
+}
+
+// subscriptionUserError reports a subscription of an unknown user as
+// models.ErrUserNotFound.
+func subscriptionUserError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation && pgErr.ConstraintName == userForeignKey {
+		return models.ErrUserNotFound
+	}
+
+	return err
+}
+
+// categoryValue stores an empty category as NULL.
+func categoryValue(category string) *string {
+	if category == "" {
+		return nil
+	}
+
+	return &category
+}
+
+// tagsValue stores nil tags as an empty array, since the column is NOT NULL.
+func tagsValue(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+
+	return tags
+}
+
+// WithinTransaction runs fn with a repository whose calls share one
+// transaction, committed when fn returns nil and rolled back otherwise.
+// Writes of the repository run in savepoints, so a failed write leaves the
+// transaction usable for the next call.
+func (r *subscriptionRepository) WithinTransaction(ctx context.Context, fn func(repo SubscriptionRepository) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = fn(&subscriptionRepository{db: tx}); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 func (r *subscriptionRepository) Create(ctx context.Context, subscription *models.Subscription) error {
 	query := `
-		INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at, updated_at
+		INSERT INTO subscriptions (
+			service_id, plan_id, service_name, price, currency, billing_period, billing_interval, user_id, start_date, end_date,
+			category, tags
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at, updated_at, version
 	`
 
 	tx, err := r.db.Begin(ctx)
@@ -25,15 +108,30 @@ func (r *subscriptionRepository) Create(ctx context.Context, subscription *model
 	defer tx.Rollback(ctx)
 
 	err = tx.QueryRow(ctx, query,
+		subscription.ServiceID,
+		subscription.PlanID,
 		subscription.ServiceName,
-		subscription.Price,
+		subscription.Price.Amount,
+		subscription.Price.Currency,
+		subscription.BillingPeriod,
+		subscription.BillingInterval,
 		subscription.UserID,
 		subscription.StartDate,
 		subscription.EndDate,
-	).Scan(&subscription.ID, &subscription.CreatedAt, &subscription.UpdatedAt)
+		categoryValue(subscription.Category),
+		tagsValue(subscription.Tags),
+	).Scan(&subscription.ID, &subscription.CreatedAt, &subscription.UpdatedAt, &subscription.Version)
 
 	if err != nil {
-		return fmt.Errorf("failed to create subscription: %w", err)
+		return fmt.Errorf("failed to create subscription: %w", subscriptionUserError(err))
+	}
+
+	if err = insertPrice(ctx, tx, subscription.ID, subscription.StartDate, subscription.Price); err != nil {
+		return err
+	}
+
+	if err = insertEvent(ctx, tx, subscription.ID, models.SubscriptionEventCreated, nil); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(ctx); err != nil {
@@ -43,25 +141,98 @@ func (r *subscriptionRepository) Create(ctx context.Context, subscription *model
 	return nil
 }
 
-func (r *subscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+// CreateMany inserts the subscriptions with COPY in one transaction, so that
+// either all of them are created or none. IDs are assigned before the copy;
+// the other generated columns keep their defaults and are not read back.
+func (r *subscriptionRepository) CreateMany(ctx context.Context, subscriptions []*models.Subscription) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ids := make([]uuid.UUID, len(subscriptions))
+
+	for i, subscription := range subscriptions {
+		subscription.ID = uuid.New()
+		subscription.Version = 1
+		ids[i] = subscription.ID
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"subscriptions"},
+		[]string{
+			"id", "service_id", "plan_id", "service_name", "price", "currency", "billing_period", "billing_interval",
+			"user_id", "start_date", "end_date", "category", "tags",
+		},
+		pgx.CopyFromSlice(len(subscriptions), func(i int) ([]interface{}, error) {
+			sub := subscriptions[i]
+
+			return []interface{}{
+				sub.ID,
+				sub.ServiceID,
+				sub.PlanID,
+				sub.ServiceName,
+				sub.Price.Amount,
+				sub.Price.Currency,
+				sub.BillingPeriod,
+				sub.BillingInterval,
+				sub.UserID,
+				sub.StartDate,
+				sub.EndDate,
+				categoryValue(sub.Category),
+				tagsValue(sub.Tags),
+			}, nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy subscriptions: %w", subscriptionUserError(err))
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"subscription_prices"},
+		[]string{"subscription_id", "effective_from", "price", "currency"},
+		pgx.CopyFromSlice(len(subscriptions), func(i int) ([]interface{}, error) {
+			sub := subscriptions[i]
+
+			return []interface{}{sub.ID, sub.StartDate, sub.Price.Amount, sub.Price.Currency}, nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy subscription prices: %w", err)
+	}
+
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+		INSERT INTO subscription_events (subscription_id, action, after, actor, request_id)
+		SELECT s.id, $2, to_jsonb(s), NULLIF($3, ''), NULLIF($4, '')
+		FROM subscriptions s
+		WHERE s.id = ANY($1)
+	`
+
+	info := models.RequestInfoFromContext(ctx)
+
+	if _, err = tx.Exec(ctx, query, ids, models.SubscriptionEventCreated, info.Actor, info.RequestID); err != nil {
+		return fmt.Errorf("failed to record subscription events: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns the subscription; a deleted one only with includeDeleted.
+func (r *subscriptionRepository) GetByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions
-		WHERE id = $1
+		WHERE id = $1 AND ($2 OR deleted_at IS NULL)
 	`
 
 	var subscription models.Subscription
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&subscription.ID,
-		&subscription.ServiceName,
-		&subscription.Price,
-		&subscription.UserID,
-		&subscription.StartDate,
-		&subscription.EndDate,
-		&subscription.CreatedAt,
-		&subscription.UpdatedAt,
-	)
 
+	err := scanSubscription(r.db.QueryRow(ctx, query, id, includeDeleted), &subscription)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, models.ErrNotFound
@@ -73,12 +244,24 @@ func (r *subscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 	return &subscription, nil
 }
 
+// Update saves the subscription and keeps its price history in sync: with
+// PriceEffectiveFrom set the price replaces the prices from that date on,
+// otherwise a changed price or currency replaces the whole history. The
+// service leaves PriceEffectiveFrom nil only for such corrections. The change
+// is recorded in the subscription events. It fails with
+// models.ErrVersionConflict when the stored version is not subscription.Version.
 func (r *subscriptionRepository) Update(ctx context.Context, subscription *models.Subscription) error {
 	query := `
-		UPDATE subscriptions
-		SET service_name = $1, price = $2, start_date = $3, end_date = $4, updated_at = NOW()
-		WHERE id = $5
-		RETURNING updated_at
+		WITH previous AS (
+			SELECT * FROM subscriptions WHERE id = $8 AND deleted_at IS NULL FOR UPDATE
+		)
+		UPDATE subscriptions s
+		SET service_name = $1, price = $2, currency = $3, billing_period = $4, billing_interval = $5,
+			start_date = $6, end_date = $7, service_id = $10, plan_id = $11, category = $12, tags = $13,
+			updated_at = NOW(), version = s.version + 1
+		FROM previous
+		WHERE s.id = previous.id AND previous.version = $9
+		RETURNING s.updated_at, s.version, previous.price, previous.currency, to_jsonb(previous)
 	`
 
 	tx, err := r.db.Begin(ctx)
@@ -87,22 +270,55 @@ func (r *subscriptionRepository) Update(ctx context.Context, subscription *model
 	}
 	defer tx.Rollback(ctx)
 
+	var (
+		previous models.Money
+		before   []byte
+	)
+
 	err = tx.QueryRow(ctx, query,
 		subscription.ServiceName,
-		subscription.Price,
+		subscription.Price.Amount,
+		subscription.Price.Currency,
+		subscription.BillingPeriod,
+		subscription.BillingInterval,
 		subscription.StartDate,
 		subscription.EndDate,
 		subscription.ID,
-	).Scan(&subscription.UpdatedAt)
+		subscription.Version,
+		subscription.ServiceID,
+		subscription.PlanID,
+		categoryValue(subscription.Category),
+		tagsValue(subscription.Tags),
+	).Scan(&subscription.UpdatedAt, &subscription.Version, &previous.Amount, &previous.Currency, &before)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return models.ErrNotFound
+			return r.versionError(ctx, subscription.ID)
 		}
 
 		return fmt.Errorf("failed to update subscription: %w", err)
 	}
 
+	if subscription.PriceEffectiveFrom != nil || previous != subscription.Price {
+		// Цена без даты начала действия исправляет всю историю по явному запросу
+		from := subscription.StartDate
+		if subscription.PriceEffectiveFrom != nil {
+			from = *subscription.PriceEffectiveFrom
+		}
+
+		if err = deletePrices(ctx, tx, subscription.ID, subscription.PriceEffectiveFrom); err != nil {
+			return err
+		}
+
+		if err = insertPrice(ctx, tx, subscription.ID, from, subscription.Price); err != nil {
+			return err
+		}
+	}
+
+	if err = insertEvent(ctx, tx, subscription.ID, models.SubscriptionEventUpdated, before); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -110,8 +326,175 @@ func (r *subscriptionRepository) Update(ctx context.Context, subscription *model
 	return nil
 }
 
-func (r *subscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM subscriptions WHERE id = $1`
+// versionError explains why a write guarded by the version matched no row:
+// the subscription is gone or its version changed.
+func (r *subscriptionRepository) versionError(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.GetByID(ctx, id, false); err != nil {
+		return err
+	}
+
+	return models.ErrVersionConflict
+}
+
+func insertPrice(ctx context.Context, tx pgx.Tx, id uuid.UUID, from time.Time, price models.Money) error {
+	query := `
+		INSERT INTO subscription_prices (subscription_id, effective_from, price, currency)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	if _, err := tx.Exec(ctx, query, id, from, price.Amount, price.Currency); err != nil {
+		return fmt.Errorf("failed to save subscription price: %w", err)
+	}
+
+	return nil
+}
+
+// deletePrices deletes the prices effective on or after from, the whole
+// history when from is nil.
+func deletePrices(ctx context.Context, tx pgx.Tx, id uuid.UUID, from *time.Time) error {
+	query := `
+		DELETE FROM subscription_prices
+		WHERE subscription_id = $1 AND ($2::DATE IS NULL OR effective_from >= $2)
+	`
+
+	if _, err := tx.Exec(ctx, query, id, from); err != nil {
+		return fmt.Errorf("failed to delete subscription prices: %w", err)
+	}
+
+	return nil
+}
+
+// insertEvent appends a change of the subscription to its events. before is
+// the row prior to the change; the row after it is read back in the same
+// transaction. The actor and request ID are taken from ctx.
+func insertEvent(
+	ctx context.Context,
+	tx pgx.Tx,
+	id uuid.UUID,
+	action models.SubscriptionEventAction,
+	before []byte,
+) error {
+	query := `
+		INSERT INTO subscription_events (subscription_id, action, before, after, actor, request_id)
+		SELECT $1, $2, $3::JSONB, (SELECT to_jsonb(s) FROM subscriptions s WHERE s.id = $1),
+			NULLIF($4, ''), NULLIF($5, '')
+	`
+
+	info := models.RequestInfoFromContext(ctx)
+
+	if _, err := tx.Exec(ctx, query, id, action, before, info.Actor, info.RequestID); err != nil {
+		return fmt.Errorf("failed to record subscription event: %w", err)
+	}
+
+	return nil
+}
+
+// ListEvents returns the change log of the subscription, oldest first. The
+// log outlives the subscription, so events of a deleted one are returned too.
+func (r *subscriptionRepository) ListEvents(ctx context.Context, id uuid.UUID) ([]*models.SubscriptionEvent, error) {
+	query := `
+		SELECT id, subscription_id, action, before, after, COALESCE(actor, ''), COALESCE(request_id, ''), created_at
+		FROM subscription_events
+		WHERE subscription_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscription events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.SubscriptionEvent
+
+	for rows.Next() {
+		var event models.SubscriptionEvent
+
+		err = rows.Scan(
+			&event.ID,
+			&event.SubscriptionID,
+			&event.Action,
+			&event.Before,
+			&event.After,
+			&event.Actor,
+			&event.RequestID,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription event: %w", err)
+		}
+
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating subscription events: %w", err)
+	}
+
+	// Подписки, созданные до появления журнала, не имеют событий
+	if len(events) == 0 {
+		if _, err = r.GetByID(ctx, id, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
+
+// ListPrices returns the price history of the subscription, earliest first.
+func (r *subscriptionRepository) ListPrices(ctx context.Context, id uuid.UUID) ([]*models.SubscriptionPrice, error) {
+	query := `
+		SELECT subscription_id, effective_from, price, currency, created_at
+		FROM subscription_prices
+		WHERE subscription_id = $1
+		ORDER BY effective_from
+	`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscription prices: %w", err)
+	}
+	defer rows.Close()
+
+	var prices []*models.SubscriptionPrice
+
+	for rows.Next() {
+		var price models.SubscriptionPrice
+
+		err = rows.Scan(&price.SubscriptionID, &price.EffectiveFrom, &price.Price.Amount, &price.Price.Currency, &price.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription price: %w", err)
+		}
+
+		prices = append(prices, &price)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating subscription prices: %w", err)
+	}
+
+	// У каждой подписки есть хотя бы одна цена, пустая история - это чужой ID
+	if len(prices) == 0 {
+		return nil, models.ErrNotFound
+	}
+
+	return prices, nil
+}
+
+// Delete marks the subscription as deleted. Its row stays until Purge. With
+// version set it fails with models.ErrVersionConflict when the stored version
+// differs.
+func (r *subscriptionRepository) Delete(ctx context.Context, id uuid.UUID, version *int) error {
+	query := `
+		WITH previous AS (
+			SELECT * FROM subscriptions WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+		)
+		UPDATE subscriptions s
+		SET deleted_at = NOW(), version = s.version + 1
+		FROM previous
+		WHERE s.id = previous.id AND ($2::INTEGER IS NULL OR previous.version = $2)
+		RETURNING to_jsonb(previous)
+	`
 
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
@@ -119,13 +502,18 @@ func (r *subscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error
 	}
 	defer tx.Rollback(ctx)
 
-	result, err := tx.Exec(ctx, query, id)
-	if err != nil {
+	var before []byte
+
+	if err = tx.QueryRow(ctx, query, id, version).Scan(&before); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return r.versionError(ctx, id)
+		}
+
 		return fmt.Errorf("failed to delete subscription: %w", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return models.ErrNotFound
+	if err = insertEvent(ctx, tx, id, models.SubscriptionEventDeleted, before); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -135,21 +523,112 @@ func (r *subscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error
 	return nil
 }
 
-func (r *subscriptionRepository) List(ctx context.Context, userID *uuid.UUID, limit, offset int) ([]*models.Subscription, int, error) {
+// Restore clears the deletion mark of the subscription. Restoring a
+// subscription that is not deleted changes nothing.
+func (r *subscriptionRepository) Restore(ctx context.Context, id uuid.UUID) error {
 	query := `
-        SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
-        FROM subscriptions
-    `
+		WITH previous AS (
+			SELECT * FROM subscriptions WHERE id = $1 FOR UPDATE
+		)
+		UPDATE subscriptions s
+		SET deleted_at = NULL, version = s.version + 1
+		FROM previous
+		WHERE s.id = previous.id AND previous.deleted_at IS NOT NULL
+		RETURNING to_jsonb(previous)
+	`
 
-	var args []interface{}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	if userID != nil {
-		query += " WHERE user_id = $1"
+	var before []byte
 
-		args = append(args, *userID)
+	err = tx.QueryRow(ctx, query, id).Scan(&before)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Подписка либо не удалена, либо не существует
+		_, err = r.GetByID(ctx, id, false)
+
+		return err
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to restore subscription: %w", err)
+	}
+
+	if err = insertEvent(ctx, tx, id, models.SubscriptionEventRestored, before); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	query += " ORDER BY created_at DESC"
+	return nil
+}
+
+// Purge permanently removes the subscriptions deleted before the given time
+// together with their price history and returns how many were removed. The
+// change log keeps a purged event for each of them.
+func (r *subscriptionRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	query := `
+		WITH purged AS (
+			DELETE FROM subscriptions s
+			WHERE deleted_at < $1
+			RETURNING s.id, to_jsonb(s) AS snapshot
+		)
+		INSERT INTO subscription_events (subscription_id, action, before, actor, request_id)
+		SELECT id, $2, snapshot, NULLIF($3, ''), NULLIF($4, '')
+		FROM purged
+	`
+
+	info := models.RequestInfoFromContext(ctx)
+
+	result, err := r.db.Exec(ctx, query, deletedBefore, models.SubscriptionEventPurged, info.Actor, info.RequestID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge subscriptions: %w", err)
+	}
+
+	return int(result.RowsAffected()), nil
+}
+
+// List returns a page of subscriptions ordered by (created_at, id) descending
+// and the total number of subscriptions matching the filter. When cursor is
+// set the page starts right after it and offset is ignored.
+func (r *subscriptionRepository) List(
+	ctx context.Context,
+	filter *models.SubscriptionListFilter,
+	limit, offset int,
+	cursor *models.ListCursor,
+) ([]*models.Subscription, int, error) {
+	conditions, args := listConditions(filter)
+
+	var total int
+
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM subscriptions"+whereClause(conditions), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count subscriptions: %w", err)
+	}
+
+	if cursor != nil {
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+		offset = 0
+	}
+
+	orderBy, err := listOrderBy(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+        SELECT ` + subscriptionColumns + `
+        FROM subscriptions` + whereClause(conditions) + `
+        ORDER BY ` + orderBy + fmt.Sprintf(`
+        LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	args = append(args, limit, offset)
 
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
@@ -157,22 +636,12 @@ func (r *subscriptionRepository) List(ctx context.Context, userID *uuid.UUID, li
 	}
 	defer rows.Close()
 
-	var subscriptions []*models.Subscription
+	subscriptions := make([]*models.Subscription, 0, limit)
 
 	for rows.Next() {
 		var subscription models.Subscription
 
-		err = rows.Scan(
-			&subscription.ID,
-			&subscription.ServiceName,
-			&subscription.Price,
-			&subscription.UserID,
-			&subscription.StartDate,
-			&subscription.EndDate,
-			&subscription.CreatedAt,
-			&subscription.UpdatedAt,
-		)
-		if err != nil {
+		if err = scanSubscription(rows, &subscription); err != nil {
 			return nil, 0, fmt.Errorf("failed to scan subscription: %w", err)
 		}
 
@@ -183,46 +652,676 @@ func (r *subscriptionRepository) List(ctx context.Context, userID *uuid.UUID, li
 		return nil, 0, fmt.Errorf("error iterating subscriptions: %w", err)
 	}
 
-	total := len(subscriptions)
-
 	return subscriptions, total, nil
 }
 
-func (r *subscriptionRepository) GetTotalCost(ctx context.Context, filter *models.SubscriptionFilter) (int, error) {
-	query := `SELECT COALESCE(SUM(price), 0) FROM subscriptions WHERE 1=1`
-	args := []interface{}{}
-	paramCount := 1
+// Export calls fn with every subscription matching the filter, in list order.
+// pgx reads the rows from the connection as they are iterated, so the memory
+// used does not grow with the number of subscriptions. An error of fn stops
+// the export and is returned as is.
+func (r *subscriptionRepository) Export(
+	ctx context.Context,
+	filter *models.SubscriptionListFilter,
+	fn func(subscription *models.Subscription) error,
+) error {
+	conditions, args := listConditions(filter)
+
+	orderBy, err := listOrderBy(filter)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        SELECT ` + subscriptionColumns + `
+        FROM subscriptions` + whereClause(conditions) + `
+        ORDER BY ` + orderBy
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscription models.Subscription
+
+	for rows.Next() {
+		if err = scanSubscription(rows, &subscription); err != nil {
+			return fmt.Errorf("failed to scan subscription: %w", err)
+		}
+
+		if err = fn(&subscription); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating subscriptions: %w", err)
+	}
+
+	return nil
+}
+
+// serviceCondition matches the subscriptions linked to the service param and
+// the unlinked ones named after the service or one of its aliases, so that
+// subscriptions created before the service was added to the catalog count too.
+// Columns are prefixed with alias.
+func serviceCondition(alias, param string) string {
+	return fmt.Sprintf(`(%[1]sservice_id = %[2]s OR (%[1]sservice_id IS NULL AND EXISTS (
+		SELECT 1 FROM service_names sn WHERE sn.service_id = %[2]s AND sn.name_key = service_name_key(%[1]sservice_name))))`,
+		alias, param)
+}
+
+// listConditions translates the filter into SQL conditions. Values are always
+// passed as arguments, never interpolated into the query.
+func listConditions(filter *models.SubscriptionListFilter) ([]string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter == nil || !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	if filter == nil {
+		return conditions, args
+	}
+
+	if filter.UserID != nil {
+		add("user_id = $%d", *filter.UserID)
+	}
+
+	if filter.ServiceID != nil {
+		add(serviceCondition("", "$%[1]d"), *filter.ServiceID)
+	}
+
+	if filter.ServiceName != nil {
+		add("service_name = $%d", *filter.ServiceName)
+	}
+
+	if filter.ServiceNamePrefix != nil {
+		add(`service_name ILIKE $%d ESCAPE '\'`, escapeLike(*filter.ServiceNamePrefix)+"%")
+	}
+
+	if filter.Category != nil {
+		add("category = $%d", *filter.Category)
+	}
+
+	if len(filter.Tags) > 0 {
+		add("tags @> $%d", filter.Tags)
+	}
+
+	if filter.MinPrice != nil {
+		add("price >= $%d", *filter.MinPrice)
+	}
+
+	if filter.MaxPrice != nil {
+		add("price <= $%d", *filter.MaxPrice)
+	}
+
+	if filter.ActiveOn != nil {
+		add("start_date < $%[1]d::DATE + INTERVAL '1 month' AND (end_date IS NULL OR end_date >= $%[1]d)", *filter.ActiveOn)
+	}
+
+	if filter.StartFrom != nil {
+		add("start_date >= $%d", *filter.StartFrom)
+	}
+
+	if filter.StartTo != nil {
+		add("start_date < $%d::DATE + INTERVAL '1 month'", *filter.StartTo)
+	}
+
+	if filter.Status != nil {
+		switch *filter.Status {
+		case models.SubscriptionStatusActive:
+			conditions = append(conditions,
+				"start_date <= CURRENT_DATE AND (end_date IS NULL OR end_date >= CURRENT_DATE)")
+		case models.SubscriptionStatusEnded:
+			conditions = append(conditions, "end_date < CURRENT_DATE")
+		case models.SubscriptionStatusFuture:
+			conditions = append(conditions, "start_date > CURRENT_DATE")
+		}
+	}
+
+	return conditions, args
+}
+
+// sortColumns maps models.SubscriptionSortFields to columns. The service rejects
+// unknown keys before querying; the lookup here keeps raw input out of SQL.
+var sortColumns = map[string]string{
+	"service_name": "service_name",
+	"price":        "price",
+	"currency":     "currency",
+	"user_id":      "user_id",
+	"start_date":   "start_date",
+	"end_date":     "end_date",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+}
+
+// listOrderBy builds the ORDER BY clause from the requested sort keys. Only
+// whitelisted columns are accepted; (created_at, id) always breaks ties.
+func listOrderBy(filter *models.SubscriptionListFilter) (string, error) {
+	var keys []string
+
+	if filter != nil {
+		for _, field := range filter.Sort {
+			column, ok := sortColumns[field.Field]
+			if !ok {
+				return "", fmt.Errorf("invalid sort field: %s", field.Field)
+			}
+
+			if field.Desc {
+				column += " DESC"
+			}
+
+			keys = append(keys, column)
+		}
+	}
+
+	keys = append(keys, "created_at DESC", "id DESC")
+
+	return strings.Join(keys, ", "), nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// Billing sources of the charges CTE. Each one is a lateral subquery over
+// the subscription s yielding (charged_on, month, share): the date the rate is
+// taken on, the month the amount is reported in and the part of the price billed.
+const (
+	// chargeDatesSQL bills every charge of the period in full.
+	chargeDatesSQL = `(
+				SELECT d, date_trunc('month', d)::DATE, 1.0
+				FROM subscription_charge_dates(s.start_date, s.end_date, s.billing_period, s.billing_interval, $1::DATE, $2::DATE) AS d
+			)`
+
+	// chargeSegmentsSQL bills the days of every billing period that fall
+	// inside the period, split by calendar month.
+	chargeSegmentsSQL = `(
+				SELECT g.charged_on, g.month, g.days::NUMERIC / g.period_days
+				FROM subscription_charge_segments(s.start_date, s.end_date, s.billing_period, s.billing_interval, $1::DATE, $2::DATE) AS g
+			)`
+
+	// activeMonthsSQL bills the monthly equivalent for every month the
+	// subscription is active in.
+	activeMonthsSQL = `(
+				SELECT mm::DATE, mm::DATE, ` + monthlyShareSQL + `
+				FROM generate_series(
+					date_trunc('month', GREATEST(s.start_date, $1::DATE))::DATE,
+					LEAST(COALESCE(s.end_date, $2::DATE), $2::DATE),
+					INTERVAL '1 month'
+				) AS mm
+			)`
+
+	// activeDaysSQL bills the monthly equivalent in proportion to the active
+	// days of every month.
+	activeDaysSQL = `(
+				SELECT mm::DATE, mm::DATE, ` + monthlyShareSQL + ` *
+					(LEAST(COALESCE(s.end_date, $2::DATE), $2::DATE, (mm + INTERVAL '1 month' - INTERVAL '1 day')::DATE)
+						- GREATEST(s.start_date, $1::DATE, mm::DATE) + 1)
+					/ ((mm + INTERVAL '1 month')::DATE - mm::DATE)
+				FROM generate_series(
+					date_trunc('month', GREATEST(s.start_date, $1::DATE))::DATE,
+					LEAST(COALESCE(s.end_date, $2::DATE), $2::DATE),
+					INTERVAL '1 month'
+				) AS mm
+			)`
+)
+
+// monthlyShareSQL is the part of the price one month of the billing period costs.
+const monthlyShareSQL = `CASE s.billing_period
+					WHEN 'weekly' THEN 52.0 / 12
+					WHEN 'monthly' THEN 1.0
+					WHEN 'quarterly' THEN 1.0 / 3
+					WHEN 'yearly' THEN 1.0 / 12
+				END / s.billing_interval`
+
+// priceInEffectSQL picks the price of the subscription s in effect on
+// m.charged_on: the latest one effective on or before it, the earliest one
+// for charges before the history starts. The current price of the
+// subscription is the last resort, so a charge is never dropped.
+const priceInEffectSQL = `(
+				SELECT h.price, h.currency FROM (
+					SELECT sp.price, sp.currency, sp.effective_from
+					FROM subscription_prices sp
+					WHERE sp.subscription_id = s.id
+					UNION ALL
+					SELECT s.price, s.currency, 'infinity'::DATE
+				) h
+				ORDER BY h.effective_from <= m.charged_on DESC,
+					CASE WHEN h.effective_from <= m.charged_on THEN h.effective_from END DESC,
+					h.effective_from
+				LIMIT 1
+			)`
+
+// targetCurrency is the currency the charges of filter are converted to.
+func targetCurrency(filter *models.SubscriptionFilter) string {
+	if filter.TargetCurrency == "" {
+		return models.DefaultCurrency
+	}
+
+	return filter.TargetCurrency
+}
+
+// chargesQuery builds the "charges" CTE. With models.CostBasisCharges it has
+// one row per charge inside [filter.StartDate, filter.EndDate]; with
+// models.CostBasisMonthly one row per month the subscription is active in,
+// billed at its monthly equivalent. models.ProrationDaily bills only the days
+// that fall inside the period and the subscription dates. Every charge is
+// billed at the price in effect on its date (see priceInEffectSQL). The amount is
+// converted to filter.TargetCurrency (models.DefaultCurrency when not set) at
+// the latest rate on or before the charge date, using the inverse pair when
+// only that one is stored; rate, rate_date and amount are NULL when no rate is
+// known, so the reports skip such charges and callers check
+// GetAppliedExchangeRates first. Deleted subscriptions are skipped unless
+// filter.IncludeDeleted is set. Aggregations select from it so that every
+// report bills the same charges.
+func chargesQuery(filter *models.SubscriptionFilter) (string, []interface{}) {
+	source := chargeDatesSQL
+
+	switch {
+	case filter.Basis == models.CostBasisMonthly && filter.Proration == models.ProrationDaily:
+		source = activeDaysSQL
+	case filter.Basis == models.CostBasisMonthly:
+		source = activeMonthsSQL
+	case filter.Proration == models.ProrationDaily:
+		source = chargeSegmentsSQL
+	}
+
+	query := `
+		WITH charges AS (
+			SELECT s.id AS subscription_id, s.service_name, s.user_id, s.category, p.currency, m.month,
+				CASE WHEN p.currency = $3 THEN NULL ELSE fx.rate_date END AS rate_date,
+				CASE WHEN p.currency = $3 THEN 1 ELSE fx.rate END AS rate,
+				p.price * m.share * CASE WHEN p.currency = $3 THEN 1 ELSE fx.rate END AS amount
+			FROM subscriptions s
+			CROSS JOIN LATERAL ` + source + ` AS m(charged_on, month, share)
+			CROSS JOIN LATERAL ` + priceInEffectSQL + ` AS p(price, currency)
+			LEFT JOIN LATERAL (
+				SELECT pair.rate_date, pair.rate FROM (
+					SELECT r.rate_date, r.rate
+					FROM exchange_rates r
+					WHERE r.base_currency = p.currency AND r.quote_currency = $3 AND r.rate_date <= m.charged_on
+					UNION ALL
+					SELECT r.rate_date, 1 / r.rate
+					FROM exchange_rates r
+					WHERE r.base_currency = $3 AND r.quote_currency = p.currency AND r.rate_date <= m.charged_on
+				) pair
+				ORDER BY pair.rate_date DESC
+				LIMIT 1
+			) fx ON p.currency <> $3
+			WHERE s.start_date <= $2 AND (s.end_date IS NULL OR s.end_date >= $1)`
+	args := []interface{}{filter.StartDate, filter.EndDate, targetCurrency(filter)}
+	paramCount := 4
+
+	if !filter.IncludeDeleted {
+		query += " AND s.deleted_at IS NULL"
+	}
 
 	// Добавляем условия фильтрации
 	if filter.UserID != nil {
-		query += fmt.Sprintf(" AND user_id = $%d", paramCount)
+		query += fmt.Sprintf(" AND s.user_id = $%d", paramCount)
 
 		args = append(args, *filter.UserID)
 		paramCount++
 	}
 
+	if filter.ServiceID != nil {
+		query += " AND " + serviceCondition("s.", fmt.Sprintf("$%d", paramCount))
+
+		args = append(args, *filter.ServiceID)
+		paramCount++
+	}
+
 	if filter.ServiceName != nil {
-		query += fmt.Sprintf(" AND service_name = $%d", paramCount)
+		query += fmt.Sprintf(" AND s.service_name = $%d", paramCount)
 
 		args = append(args, *filter.ServiceName)
 		paramCount++
 	}
 
-	query += fmt.Sprintf(" AND start_date <= $%d", paramCount)
+	if filter.Category != nil {
+		query += fmt.Sprintf(" AND s.category = $%d", paramCount)
+
+		args = append(args, *filter.Category)
+		paramCount++
+	}
+
+	if len(filter.Tags) > 0 {
+		query += fmt.Sprintf(" AND s.tags @> $%d", paramCount)
+
+		args = append(args, filter.Tags)
+		paramCount++
+	}
+
+	query += ")"
+
+	return query, args
+}
+
+// GetAppliedExchangeRates returns the rates used to convert the charges of the
+// period to filter.TargetCurrency, ordered by currency, month and rate date.
+// Every charge is converted at the latest rate on or before its own date, so a
+// month with several charges of a currency (weekly billing, daily proration)
+// can list several rates for it. It fails with models.ErrMissingExchangeRate
+// listing the currencies and months without a rate.
+func (r *subscriptionRepository) GetAppliedExchangeRates(
+	ctx context.Context,
+	filter *models.SubscriptionFilter,
+) ([]*models.AppliedExchangeRate, error) {
+	query, args := chargesQuery(filter)
+	query += `
+		SELECT DISTINCT currency, month, rate_date, rate::DOUBLE PRECISION
+		FROM charges
+		WHERE currency <> $3
+		ORDER BY currency, month, rate_date
+	`
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		rates   []*models.AppliedExchangeRate
+		missing []string
+	)
 
-	args = append(args, filter.EndDate)
-	paramCount++
+	for rows.Next() {
+		var (
+			rate     models.AppliedExchangeRate
+			rateDate *time.Time
+			value    *float64
+		)
 
-	query += fmt.Sprintf(" AND (end_date IS NULL OR end_date >= $%d)", paramCount)
+		if err = rows.Scan(&rate.Currency, &rate.Month, &rateDate, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
 
-	args = append(args, filter.StartDate)
+		if rateDate == nil || value == nil {
+			missing = append(missing, rate.Currency+" "+rate.Month.Format("01-2006"))
 
-	var totalCost int
+			continue
+		}
+
+		rate.RateDate = *rateDate
+		rate.Rate = *value
+		rates = append(rates, &rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating exchange rates: %w", err)
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s to %s", models.ErrMissingExchangeRate, strings.Join(missing, ", "), targetCurrency(filter))
+	}
+
+	return rates, nil
+}
+
+// numericValueOutOfRange is the PostgreSQL error code raised when a sum does
+// not fit into BIGINT.
+const numericValueOutOfRange = "22003"
+
+// costError reports a cost that does not fit into int64 minor units as
+// models.ErrAmountOverflow. Sums are accumulated as NUMERIC, so only the
+// final cast to BIGINT can overflow.
+func costError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == numericValueOutOfRange {
+		return models.ErrAmountOverflow
+	}
+
+	return err
+}
+
+// GetTotalCost sums the charges of [filter.StartDate, filter.EndDate] on the
+// basis of filter.Basis, clipped to start_date/end_date. The cost is in minor
+// units of filter.TargetCurrency.
+func (r *subscriptionRepository) GetTotalCost(ctx context.Context, filter *models.SubscriptionFilter) (int64, error) {
+	query, args := chargesQuery(filter)
+	query += ` SELECT COALESCE(ROUND(SUM(amount)), 0)::BIGINT FROM charges`
+
+	var totalCost int64
 
 	err := r.db.QueryRow(ctx, query, args...).Scan(&totalCost)
 	if err != nil {
-		return 0, fmt.Errorf("failed to calculate total cost: %w", err)
+		return 0, fmt.Errorf("failed to calculate total cost: %w", costError(err))
 	}
 
 	return totalCost, nil
 }
+
+// GetMonthlyCost returns the cost of every month in the period split by
+// service. Months without charges are returned as a single row
+// with an empty service name so callers can render gaps. The total of the
+// period is rounded once, after summing, so it equals GetTotalCost for the
+// filter even when the rounded rows add up to a slightly different amount.
+func (r *subscriptionRepository) GetMonthlyCost(
+	ctx context.Context,
+	filter *models.SubscriptionFilter,
+) ([]*models.MonthlyCost, int64, error) {
+	query, args := chargesQuery(filter)
+	query += `
+		SELECT m.month::DATE, c.service_name, COALESCE(ROUND(SUM(c.amount)), 0)::BIGINT,
+			COUNT(DISTINCT c.subscription_id), COALESCE(ROUND(SUM(SUM(c.amount)) OVER ()), 0)::BIGINT
+		FROM generate_series(date_trunc('month', $1::DATE)::DATE, $2::DATE, INTERVAL '1 month') AS m(month)
+		LEFT JOIN charges c ON c.month = m.month::DATE
+		GROUP BY m.month, c.service_name
+		ORDER BY m.month, c.service_name
+	`
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to calculate monthly cost: %w", costError(err))
+	}
+	defer rows.Close()
+
+	var (
+		costs []*models.MonthlyCost
+		total int64
+	)
+
+	for rows.Next() {
+		var (
+			cost        models.MonthlyCost
+			serviceName *string
+		)
+
+		if err = rows.Scan(&cost.Month, &serviceName, &cost.Amount, &cost.Subscriptions, &total); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan monthly cost: %w", err)
+		}
+
+		if serviceName != nil {
+			cost.ServiceName = *serviceName
+		}
+
+		costs = append(costs, &cost)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating monthly cost: %w", costError(err))
+	}
+
+	return costs, total, nil
+}
+
+// costDimensionColumns whitelists the charges columns the cost can be grouped by.
+var costDimensionColumns = map[models.CostDimension]string{
+	models.CostDimensionServiceName: "service_name",
+	models.CostDimensionUserID:      "user_id",
+	models.CostDimensionMonth:       "month",
+	models.CostDimensionCategory:    "category",
+}
+
+// GetGroupedCost returns subtotals of the period grouped by the given
+// dimensions, most expensive buckets first, and the total of the period
+// rounded once as in GetMonthlyCost.
+func (r *subscriptionRepository) GetGroupedCost(
+	ctx context.Context,
+	filter *models.SubscriptionFilter,
+	groupBy []models.CostDimension,
+) ([]*models.CostGroup, int64, error) {
+	columns := make([]string, 0, len(groupBy))
+
+	for _, dimension := range groupBy {
+		column, ok := costDimensionColumns[dimension]
+		if !ok {
+			return nil, 0, fmt.Errorf("invalid group by dimension: %s", dimension)
+		}
+
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		return nil, 0, errors.New("group by dimension is required")
+	}
+
+	groupColumns := strings.Join(columns, ", ")
+
+	query, args := chargesQuery(filter)
+	query += fmt.Sprintf(`
+		SELECT %[1]s, ROUND(SUM(amount))::BIGINT AS amount, ROUND(SUM(SUM(amount)) OVER ())::BIGINT
+		FROM charges
+		GROUP BY %[1]s
+		ORDER BY amount DESC, %[1]s
+	`, groupColumns)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to calculate grouped cost: %w", costError(err))
+	}
+	defer rows.Close()
+
+	var (
+		groups []*models.CostGroup
+		total  int64
+	)
+
+	for rows.Next() {
+		group := &models.CostGroup{}
+		dest := make([]interface{}, 0, len(groupBy)+2)
+
+		for _, dimension := range groupBy {
+			switch dimension {
+			case models.CostDimensionServiceName:
+				dest = append(dest, &group.ServiceName)
+			case models.CostDimensionUserID:
+				dest = append(dest, &group.UserID)
+			case models.CostDimensionMonth:
+				dest = append(dest, &group.Month)
+			case models.CostDimensionCategory:
+				dest = append(dest, &group.Category)
+			}
+		}
+
+		dest = append(dest, &group.Amount, &total)
+
+		if err = rows.Scan(dest...); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan grouped cost: %w", err)
+		}
+
+		groups = append(groups, group)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating grouped cost: %w", costError(err))
+	}
+
+	return groups, total, nil
+}
+
+// ComparePlanPrices returns the latest price of every active or future
+// subscription with a plan next to the current list price of the plan for the
+// currency and billing period of the subscription, ordered by service and plan.
+func (r *subscriptionRepository) ComparePlanPrices(
+	ctx context.Context,
+	filter *models.PlanPriceFilter,
+) ([]*models.PlanPriceComparison, error) {
+	query := `
+		SELECT s.id, s.user_id, s.service_name, p.id, p.name, s.billing_period, s.billing_interval,
+			s.price, s.currency, lp.price * s.billing_interval
+		FROM subscriptions s
+		JOIN service_plans p ON p.id = s.plan_id
+		LEFT JOIN plan_prices lp
+			ON lp.plan_id = p.id AND lp.currency = s.currency AND lp.billing_period = s.billing_period
+		WHERE s.deleted_at IS NULL AND (s.end_date IS NULL OR s.end_date >= CURRENT_DATE)`
+
+	var args []interface{}
+
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		query += fmt.Sprintf(" AND s.user_id = $%d", len(args))
+	}
+
+	if filter.ServiceID != nil {
+		args = append(args, *filter.ServiceID)
+		query += fmt.Sprintf(" AND p.service_id = $%d", len(args))
+	}
+
+	query += " ORDER BY lower(s.service_name), lower(p.name), s.id"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare plan prices: %w", err)
+	}
+	defer rows.Close()
+
+	var comparisons []*models.PlanPriceComparison
+
+	for rows.Next() {
+		var (
+			comparison models.PlanPriceComparison
+			listPrice  *int64
+		)
+
+		err = rows.Scan(
+			&comparison.SubscriptionID,
+			&comparison.UserID,
+			&comparison.ServiceName,
+			&comparison.PlanID,
+			&comparison.PlanName,
+			&comparison.BillingPeriod,
+			&comparison.BillingInterval,
+			&comparison.Price.Amount,
+			&comparison.Price.Currency,
+			&listPrice,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan plan price: %w", err)
+		}
+
+		if listPrice != nil {
+			price := models.NewMoney(*listPrice, comparison.Price.Currency)
+			comparison.ListPrice = &price
+		}
+
+		comparisons = append(comparisons, &comparison)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating plan prices: %w", err)
+	}
+
+	return comparisons, nil
+}
@@ -0,0 +1,1107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vnchk1/subscription-aggregator/internal/config"
+	"github.com/vnchk1/subscription-aggregator/internal/migration"
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+)
+
+// newTestPool connects to the database from TEST_DATABASE_URL, applies the
+// migrations and truncates the tables. Tests are skipped when it is not set.
+func newTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+
+	migrator, err := migration.NewMigrator(config.DatabaseConfig{URL: url, MigrationPath: "../../migrations"})
+	require.NoError(t, err)
+
+	defer migrator.Close()
+
+	require.NoError(t, migrator.Up(ctx))
+
+	pool, err := pgxpool.New(ctx, url)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	_, err = pool.Exec(ctx, "TRUNCATE subscriptions, subscription_prices, subscription_events, exchange_rates, "+
+		"idempotency_keys, services, service_names, service_plans, plan_prices, users")
+	require.NoError(t, err)
+
+	return pool
+}
+
+// createTestUser creates a user that subscriptions can refer to.
+func createTestUser(t *testing.T, pool *pgxpool.Pool) uuid.UUID {
+	t.Helper()
+
+	user := &models.User{
+		DisplayName:     "Test User",
+		Locale:          models.DefaultLocale,
+		DefaultCurrency: models.DefaultCurrency,
+		Timezone:        models.DefaultTimezone,
+	}
+	require.NoError(t, NewUserRepository(pool).Create(context.Background(), user))
+
+	return user.ID
+}
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// monthEnd returns the last day of the month, the inclusive end of a range.
+func monthEnd(year int, m time.Month) time.Time {
+	return month(year, m).AddDate(0, 1, -1)
+}
+
+func createTestSubscription(t *testing.T, repo SubscriptionRepository, userID uuid.UUID, name string, price int64, start time.Time, end *time.Time) {
+	t.Helper()
+
+	err := repo.Create(context.Background(), &models.Subscription{
+		ServiceName:     name,
+		Price:           models.NewMoney(price, models.DefaultCurrency),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          userID,
+		StartDate:       start,
+		EndDate:         end,
+	})
+	require.NoError(t, err)
+}
+
+func TestSubscriptionRepository_GetTotalCost(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	otherUserID := createTestUser(t, pool)
+
+	beforeEnd := monthEnd(2023, time.December)
+	partialEnd := monthEnd(2024, time.March)
+	containedEnd := monthEnd(2024, time.August)
+	afterEnd := monthEnd(2025, time.June)
+
+	// Открытая подписка: активна все 12 месяцев окна.
+	createTestSubscription(t, repo, userID, "Yandex Plus", 400, month(2023, time.June), nil)
+	// Начинается до окна и заканчивается внутри: январь-март.
+	createTestSubscription(t, repo, userID, "Netflix", 800, month(2023, time.October), &partialEnd)
+	// Начинается внутри окна и заканчивается после: ноябрь-декабрь.
+	createTestSubscription(t, repo, userID, "Spotify", 300, month(2024, time.November), &afterEnd)
+	// Полностью внутри окна: май-август.
+	createTestSubscription(t, repo, userID, "Kinopoisk", 250, month(2024, time.May), &containedEnd)
+	// Закончилась до окна и не учитывается.
+	createTestSubscription(t, repo, userID, "Okko", 500, month(2023, time.January), &beforeEnd)
+	// Начинается после окна и не учитывается.
+	createTestSubscription(t, repo, userID, "Ivi", 350, month(2025, time.January), nil)
+	createTestSubscription(t, repo, otherUserID, "Yandex Plus", 400, month(2024, time.July), nil)
+
+	netflix := "Netflix"
+
+	testCases := []struct {
+		name   string
+		filter *models.SubscriptionFilter
+		want   int64
+	}{
+		{
+			name:   "all users for the year",
+			filter: &models.SubscriptionFilter{StartDate: month(2024, time.January), EndDate: month(2024, time.December)},
+			want:   12*400 + 3*800 + 2*300 + 4*250 + 6*400,
+		},
+		{
+			name: "single user for the year",
+			filter: &models.SubscriptionFilter{
+				UserID:    &userID,
+				StartDate: month(2024, time.January),
+				EndDate:   monthEnd(2024, time.December),
+			},
+			want: 12*400 + 3*800 + 2*300 + 4*250,
+		},
+		{
+			name: "service filter clips to end date",
+			filter: &models.SubscriptionFilter{
+				ServiceName: &netflix,
+				StartDate:   month(2024, time.February),
+				EndDate:     monthEnd(2024, time.December),
+			},
+			want: 2 * 800,
+		},
+		{
+			name: "single month window",
+			filter: &models.SubscriptionFilter{
+				UserID:    &userID,
+				StartDate: month(2024, time.March),
+				EndDate:   monthEnd(2024, time.March),
+			},
+			want: 400 + 800,
+		},
+		{
+			name:   "no overlap",
+			filter: &models.SubscriptionFilter{StartDate: month(2022, time.January), EndDate: month(2022, time.December)},
+			want:   0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			total, err := repo.GetTotalCost(ctx, tc.filter)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, total)
+		})
+	}
+}
+
+func TestSubscriptionRepository_GetMonthlyCost(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	netflixEnd := monthEnd(2024, time.February)
+
+	createTestSubscription(t, repo, userID, "Netflix", 800, month(2023, time.October), &netflixEnd)
+	createTestSubscription(t, repo, userID, "Spotify", 300, month(2024, time.January), nil)
+	createTestSubscription(t, repo, createTestUser(t, pool), "Spotify", 300, month(2024, time.February), nil)
+
+	costs, total, err := repo.GetMonthlyCost(ctx, &models.SubscriptionFilter{
+		StartDate: month(2023, time.December),
+		EndDate:   monthEnd(2024, time.March),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3*800+300+2*600), total)
+
+	want := []*models.MonthlyCost{
+		{Month: month(2023, time.December), ServiceName: "Netflix", Amount: 800, Subscriptions: 1},
+		{Month: month(2024, time.January), ServiceName: "Netflix", Amount: 800, Subscriptions: 1},
+		{Month: month(2024, time.January), ServiceName: "Spotify", Amount: 300, Subscriptions: 1},
+		{Month: month(2024, time.February), ServiceName: "Netflix", Amount: 800, Subscriptions: 1},
+		{Month: month(2024, time.February), ServiceName: "Spotify", Amount: 600, Subscriptions: 2},
+		{Month: month(2024, time.March), ServiceName: "Spotify", Amount: 600, Subscriptions: 2},
+	}
+	assert.Equal(t, want, costs)
+
+	empty, total, err := repo.GetMonthlyCost(ctx, &models.SubscriptionFilter{
+		UserID:    &userID,
+		StartDate: month(2022, time.January),
+		EndDate:   monthEnd(2022, time.February),
+	})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	assert.Equal(t, []*models.MonthlyCost{
+		{Month: month(2022, time.January)},
+		{Month: month(2022, time.February)},
+	}, empty)
+}
+
+func TestSubscriptionRepository_CostTotalsAgree(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	// Месячный эквивалент годовой подписки - 83,33, каждый месяц округляется до 83
+	require.NoError(t, repo.Create(ctx, &models.Subscription{
+		ServiceName:     "Yandex Plus",
+		Price:           models.NewMoney(1000, models.DefaultCurrency),
+		BillingPeriod:   models.BillingPeriodYearly,
+		BillingInterval: 1,
+		UserID:          createTestUser(t, pool),
+		StartDate:       month(2024, time.January),
+	}))
+
+	filter := &models.SubscriptionFilter{
+		StartDate: month(2024, time.January),
+		EndDate:   monthEnd(2024, time.December),
+		Basis:     models.CostBasisMonthly,
+	}
+
+	total, err := repo.GetTotalCost(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), total)
+
+	costs, monthlyTotal, err := repo.GetMonthlyCost(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, costs, 12)
+	assert.Equal(t, int64(83), costs[0].Amount)
+	assert.Equal(t, total, monthlyTotal)
+
+	_, groupedTotal, err := repo.GetGroupedCost(ctx, filter, []models.CostDimension{models.CostDimensionMonth})
+	require.NoError(t, err)
+	assert.Equal(t, total, groupedTotal)
+}
+
+func TestSubscriptionRepository_GetGroupedCost(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	otherUserID := createTestUser(t, pool)
+
+	createTestSubscription(t, repo, userID, "Netflix", 800, month(2024, time.January), nil)
+	createTestSubscription(t, repo, userID, "Spotify", 300, month(2024, time.February), nil)
+	createTestSubscription(t, repo, otherUserID, "Spotify", 300, month(2024, time.January), nil)
+
+	filter := &models.SubscriptionFilter{StartDate: month(2024, time.January), EndDate: month(2024, time.March)}
+
+	byService, total, err := repo.GetGroupedCost(ctx, filter, []models.CostDimension{models.CostDimensionServiceName})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3*800+5*300), total)
+	require.Len(t, byService, 2)
+	assert.Equal(t, "Netflix", *byService[0].ServiceName)
+	assert.Equal(t, int64(3*800), byService[0].Amount)
+	assert.Equal(t, "Spotify", *byService[1].ServiceName)
+	assert.Equal(t, int64(2*300+3*300), byService[1].Amount)
+	assert.Nil(t, byService[0].UserID)
+	assert.Nil(t, byService[0].Month)
+
+	byUserMonth, _, err := repo.GetGroupedCost(ctx, filter, []models.CostDimension{models.CostDimensionUserID, models.CostDimensionMonth})
+	require.NoError(t, err)
+	require.Len(t, byUserMonth, 6)
+	assert.Equal(t, userID, *byUserMonth[0].UserID)
+	assert.Equal(t, int64(1100), byUserMonth[0].Amount)
+
+	_, _, err = repo.GetGroupedCost(ctx, filter, []models.CostDimension{"price; DROP TABLE subscriptions"})
+	require.Error(t, err)
+}
+
+func TestSubscriptionRepository_List(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+
+	for i := 0; i < 5; i++ {
+		createTestSubscription(t, repo, userID, "Netflix", int64(100+i), month(2024, time.January), nil)
+	}
+
+	createTestSubscription(t, repo, createTestUser(t, pool), "Spotify", 300, month(2024, time.January), nil)
+
+	firstPage, total, err := repo.List(ctx, &models.SubscriptionListFilter{UserID: &userID}, 2, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	require.Len(t, firstPage, 2)
+	assert.Equal(t, int64(104), firstPage[0].Price.Amount)
+	assert.Equal(t, int64(103), firstPage[1].Price.Amount)
+
+	lastPage, total, err := repo.List(ctx, &models.SubscriptionListFilter{UserID: &userID}, 2, 4, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	require.Len(t, lastPage, 1)
+	assert.Equal(t, int64(100), lastPage[0].Price.Amount)
+
+	beyond, total, err := repo.List(ctx, nil, 10, 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 6, total)
+	assert.Empty(t, beyond)
+}
+
+func TestSubscriptionRepository_List_Cursor(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+
+	for i := 0; i < 5; i++ {
+		createTestSubscription(t, repo, userID, "Netflix", int64(100+i), month(2024, time.January), nil)
+	}
+
+	firstPage, _, err := repo.List(ctx, &models.SubscriptionListFilter{UserID: &userID}, 2, 0, nil)
+	require.NoError(t, err)
+	require.Len(t, firstPage, 2)
+
+	// Новая подписка не должна сдвигать следующие страницы.
+	createTestSubscription(t, repo, userID, "Netflix", 999, month(2024, time.January), nil)
+
+	secondPage, total, err := repo.List(ctx, &models.SubscriptionListFilter{UserID: &userID}, 2, 0, models.NewListCursor(firstPage[1]))
+	require.NoError(t, err)
+	assert.Equal(t, 6, total)
+	require.Len(t, secondPage, 2)
+	assert.Equal(t, int64(102), secondPage[0].Price.Amount)
+	assert.Equal(t, int64(101), secondPage[1].Price.Amount)
+
+	lastPage, _, err := repo.List(ctx, &models.SubscriptionListFilter{UserID: &userID}, 2, 10, models.NewListCursor(secondPage[1]))
+	require.NoError(t, err)
+	require.Len(t, lastPage, 1)
+	assert.Equal(t, int64(100), lastPage[0].Price.Amount)
+}
+
+func TestSubscriptionRepository_List_Filters(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	ended := monthEnd(2020, time.June)
+
+	createTestSubscription(t, repo, userID, "Netflix", 800, month(2019, time.January), &ended)
+	createTestSubscription(t, repo, userID, "netflix Premium", 1200, month(2021, time.March), nil)
+	createTestSubscription(t, repo, userID, "Spotify", 300, month(2021, time.May), nil)
+	createTestSubscription(t, repo, userID, "100%_Sport", 500, month(2099, time.January), nil)
+
+	names := func(filter *models.SubscriptionListFilter) []string {
+		subs, total, err := repo.List(ctx, filter, 100, 0, nil)
+		require.NoError(t, err)
+		assert.Len(t, subs, total)
+
+		result := make([]string, 0, len(subs))
+		for _, sub := range subs {
+			result = append(result, sub.ServiceName)
+		}
+
+		return result
+	}
+
+	ptr := func(v int64) *int64 { return &v }
+	str := func(v string) *string { return &v }
+	date := func(v time.Time) *time.Time { return &v }
+	status := func(v models.SubscriptionStatus) *models.SubscriptionStatus { return &v }
+
+	assert.ElementsMatch(t, []string{"Netflix"}, names(&models.SubscriptionListFilter{ServiceName: str("Netflix")}))
+	assert.ElementsMatch(t, []string{"Netflix", "netflix Premium"},
+		names(&models.SubscriptionListFilter{ServiceNamePrefix: str("NETF")}))
+	assert.ElementsMatch(t, []string{"100%_Sport"}, names(&models.SubscriptionListFilter{ServiceNamePrefix: str("100%_")}))
+	assert.Empty(t, names(&models.SubscriptionListFilter{ServiceNamePrefix: str("%")}))
+	assert.ElementsMatch(t, []string{"Netflix", "100%_Sport"},
+		names(&models.SubscriptionListFilter{MinPrice: ptr(500), MaxPrice: ptr(800)}))
+	assert.ElementsMatch(t, []string{"Netflix"}, names(&models.SubscriptionListFilter{ActiveOn: date(month(2020, time.June))}))
+	assert.ElementsMatch(t, []string{"netflix Premium", "Spotify"},
+		names(&models.SubscriptionListFilter{StartFrom: date(month(2021, time.January)), StartTo: date(month(2021, time.December))}))
+	assert.ElementsMatch(t, []string{"netflix Premium", "Spotify"},
+		names(&models.SubscriptionListFilter{Status: status(models.SubscriptionStatusActive)}))
+	assert.ElementsMatch(t, []string{"Netflix"}, names(&models.SubscriptionListFilter{Status: status(models.SubscriptionStatusEnded)}))
+	assert.ElementsMatch(t, []string{"100%_Sport"}, names(&models.SubscriptionListFilter{Status: status(models.SubscriptionStatusFuture)}))
+}
+
+func TestSubscriptionRepository_List_Sort(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	soon := monthEnd(2025, time.February)
+	later := monthEnd(2026, time.March)
+
+	createTestSubscription(t, repo, userID, "Spotify", 300, month(2024, time.May), &later)
+	createTestSubscription(t, repo, userID, "Netflix", 800, month(2024, time.January), &soon)
+	createTestSubscription(t, repo, userID, "Kinopoisk", 300, month(2024, time.March), nil)
+
+	names := func(sort ...models.SortField) []string {
+		subs, _, err := repo.List(ctx, &models.SubscriptionListFilter{Sort: sort}, 100, 0, nil)
+		require.NoError(t, err)
+
+		result := make([]string, 0, len(subs))
+		for _, sub := range subs {
+			result = append(result, sub.ServiceName)
+		}
+
+		return result
+	}
+
+	assert.Equal(t, []string{"Netflix", "Kinopoisk", "Spotify"},
+		names(models.SortField{Field: "price", Desc: true}, models.SortField{Field: "start_date"}))
+	assert.Equal(t, []string{"Netflix", "Spotify", "Kinopoisk"}, names(models.SortField{Field: "end_date"}))
+	assert.Equal(t, []string{"Kinopoisk", "Netflix", "Spotify"}, names(models.SortField{Field: "service_name"}))
+
+	_, _, err := repo.List(ctx, &models.SubscriptionListFilter{
+		Sort: []models.SortField{{Field: "price; DROP TABLE subscriptions"}},
+	}, 10, 0, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid sort field")
+}
+
+func TestSubscriptionRepository_GetTotalCost_Currency(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	rates := NewExchangeRateRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	end := monthEnd(2024, time.March)
+
+	require.NoError(t, repo.Create(ctx, &models.Subscription{
+		ServiceName: "ChatGPT", Price: models.NewMoney(2000, "USD"), BillingPeriod: models.BillingPeriodMonthly, BillingInterval: 1, UserID: userID, StartDate: month(2024, time.January), EndDate: &end,
+	}))
+	require.NoError(t, repo.Create(ctx, &models.Subscription{
+		ServiceName: "Spotify", Price: models.NewMoney(1000, "EUR"), BillingPeriod: models.BillingPeriodMonthly, BillingInterval: 1, UserID: userID, StartDate: month(2024, time.March), EndDate: &end,
+	}))
+	createTestSubscription(t, repo, userID, "Yandex Plus", 40000, month(2024, time.January), &end)
+
+	for _, rate := range []*models.ExchangeRate{
+		{RateDate: month(2023, time.December), BaseCurrency: "USD", QuoteCurrency: "RUB", Rate: 90},
+		{RateDate: month(2024, time.March), BaseCurrency: "USD", QuoteCurrency: "RUB", Rate: 100},
+		// Хранится только обратная пара RUB/EUR.
+		{RateDate: month(2024, time.February), BaseCurrency: "RUB", QuoteCurrency: "EUR", Rate: 0.01},
+	} {
+		require.NoError(t, rates.Upsert(ctx, rate))
+	}
+
+	filter := &models.SubscriptionFilter{
+		StartDate:      month(2024, time.January),
+		EndDate:        monthEnd(2024, time.March),
+		TargetCurrency: "RUB",
+	}
+
+	total, err := repo.GetTotalCost(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2000*90+2000*90+2000*100+1000*100+3*40000), total)
+
+	filter.TargetCurrency = "EUR"
+
+	_, err = repo.GetAppliedExchangeRates(ctx, filter)
+	require.ErrorIs(t, err, models.ErrMissingExchangeRate)
+	assert.Contains(t, err.Error(), "USD 01-2024 to EUR")
+
+	// Без валюты отчета сообщение называет валюту по умолчанию
+	require.NoError(t, pool.QueryRow(ctx, "DELETE FROM exchange_rates WHERE rate_date = $1 RETURNING 1", month(2023, time.December)).Scan(new(int)))
+
+	filter.TargetCurrency = ""
+
+	_, err = repo.GetAppliedExchangeRates(ctx, filter)
+	require.ErrorIs(t, err, models.ErrMissingExchangeRate)
+	assert.Contains(t, err.Error(), "USD 01-2024 to "+models.DefaultCurrency)
+}
+
+func TestSubscriptionRepository_GetAppliedExchangeRates_SeveralRatesInMonth(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	rates := NewExchangeRateRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+
+	require.NoError(t, repo.Create(ctx, &models.Subscription{
+		ServiceName: "Figma", Price: models.NewMoney(500, "USD"), BillingPeriod: models.BillingPeriodWeekly, BillingInterval: 1, UserID: userID, StartDate: month(2024, time.January),
+	}))
+
+	for _, rate := range []*models.ExchangeRate{
+		{RateDate: month(2023, time.December), BaseCurrency: "USD", QuoteCurrency: "RUB", Rate: 90},
+		{RateDate: time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC), BaseCurrency: "USD", QuoteCurrency: "RUB", Rate: 100},
+	} {
+		require.NoError(t, rates.Upsert(ctx, rate))
+	}
+
+	// Списания 1 и 8 января идут по старому курсу, 15, 22 и 29 - по новому
+	applied, err := repo.GetAppliedExchangeRates(ctx, &models.SubscriptionFilter{
+		StartDate:      month(2024, time.January),
+		EndDate:        monthEnd(2024, time.January),
+		TargetCurrency: "RUB",
+	})
+	require.NoError(t, err)
+	require.Len(t, applied, 2)
+
+	for i, want := range []struct {
+		rateDate time.Time
+		rate     float64
+	}{
+		{month(2023, time.December), 90},
+		{time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC), 100},
+	} {
+		assert.Equal(t, "USD", applied[i].Currency)
+		assert.True(t, month(2024, time.January).Equal(applied[i].Month))
+		assert.True(t, want.rateDate.Equal(applied[i].RateDate))
+		assert.InDelta(t, want.rate, applied[i].Rate, 1e-9)
+	}
+}
+
+func TestSubscriptionRepository_GetTotalCost_Overflow(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	createTestSubscription(t, repo, userID, "Enterprise", math.MaxInt64/2+1, month(2024, time.January), nil)
+
+	filter := &models.SubscriptionFilter{StartDate: month(2024, time.January), EndDate: monthEnd(2024, time.January)}
+
+	total, err := repo.GetTotalCost(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(math.MaxInt64/2+1), total)
+
+	// Сумма за два месяца не помещается в BIGINT
+	filter.EndDate = monthEnd(2024, time.February)
+
+	_, err = repo.GetTotalCost(ctx, filter)
+	require.ErrorIs(t, err, models.ErrAmountOverflow)
+}
+
+func TestSubscriptionRepository_GetTotalCost_BillingPeriods(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+
+	create := func(name string, price int64, period models.BillingPeriod, interval int, start time.Time) {
+		require.NoError(t, repo.Create(ctx, &models.Subscription{
+			ServiceName:     name,
+			Price:           models.NewMoney(price, models.DefaultCurrency),
+			BillingPeriod:   period,
+			BillingInterval: interval,
+			UserID:          userID,
+			StartDate:       start,
+		}))
+	}
+
+	// Годовая подписка списывается в марте, квартальная - в феврале, мае, августе и ноябре
+	create("Yearly", 120000, models.BillingPeriodYearly, 1, month(2023, time.March))
+	create("Quarterly", 30000, models.BillingPeriodQuarterly, 1, month(2023, time.November))
+	// 1, 15 и 29 января
+	create("Biweekly", 1000, models.BillingPeriodWeekly, 2, month(2024, time.January))
+
+	filter := &models.SubscriptionFilter{StartDate: month(2024, time.January), EndDate: monthEnd(2024, time.January)}
+
+	total, err := repo.GetTotalCost(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3*1000), total)
+
+	filter.EndDate = monthEnd(2024, time.December)
+
+	total, err = repo.GetTotalCost(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(120000+4*30000+27*1000), total)
+
+	filter.EndDate = monthEnd(2024, time.January)
+	filter.Basis = models.CostBasisMonthly
+
+	total, err = repo.GetTotalCost(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10000+10000+2167), total)
+}
+
+func TestSubscriptionRepository_GetTotalCost_Proration(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	end := time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC)
+	// Период оплаты с 20 января по 19 февраля - 31 день
+	createTestSubscription(t, repo, userID, "Netflix", 3100, time.Date(2024, time.January, 20, 0, 0, 0, 0, time.UTC), &end)
+
+	testCases := []struct {
+		name   string
+		filter *models.SubscriptionFilter
+		want   int64
+	}{
+		{
+			name:   "whole charge",
+			filter: &models.SubscriptionFilter{StartDate: month(2024, time.January), EndDate: monthEnd(2024, time.January)},
+			want:   3100,
+		},
+		{
+			name: "12 of 31 days of the first period",
+			filter: &models.SubscriptionFilter{
+				StartDate: month(2024, time.January),
+				EndDate:   monthEnd(2024, time.January),
+				Proration: models.ProrationDaily,
+			},
+			want: 1200,
+		},
+		{
+			name: "10 of 31 days of the last month",
+			filter: &models.SubscriptionFilter{
+				StartDate: month(2024, time.March),
+				EndDate:   monthEnd(2024, time.March),
+				Basis:     models.CostBasisMonthly,
+				Proration: models.ProrationDaily,
+			},
+			want: 1000,
+		},
+		{
+			name: "day-precision period",
+			filter: &models.SubscriptionFilter{
+				StartDate: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2024, time.February, 19, 0, 0, 0, 0, time.UTC),
+				Proration: models.ProrationDaily,
+			},
+			want: 1900,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			total, err := repo.GetTotalCost(ctx, tc.filter)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, total)
+		})
+	}
+}
+
+func TestSubscriptionRepository_PriceHistory(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	subscription := &models.Subscription{
+		ServiceName:     "Netflix",
+		Price:           models.NewMoney(1000, models.DefaultCurrency),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          createTestUser(t, pool),
+		StartDate:       month(2024, time.January),
+	}
+	require.NoError(t, repo.Create(ctx, subscription))
+
+	// С апреля цена выросла, прошлые месяцы остаются по старой цене
+	april := month(2024, time.April)
+	subscription.Price = models.NewMoney(1500, models.DefaultCurrency)
+	subscription.PriceEffectiveFrom = &april
+	require.NoError(t, repo.Update(ctx, subscription))
+
+	prices, err := repo.ListPrices(ctx, subscription.ID)
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	assert.Equal(t, month(2024, time.January), prices[0].EffectiveFrom)
+	assert.Equal(t, int64(1000), prices[0].Price.Amount)
+	assert.Equal(t, month(2024, time.April), prices[1].EffectiveFrom)
+	assert.Equal(t, int64(1500), prices[1].Price.Amount)
+
+	filter := &models.SubscriptionFilter{StartDate: month(2024, time.January), EndDate: monthEnd(2024, time.June)}
+
+	total, err := repo.GetTotalCost(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3*1000+3*1500), total)
+
+	// Обновление без даты при неизменной цене не трогает историю
+	subscription.PriceEffectiveFrom = nil
+	subscription.ServiceName = "Netflix Premium"
+	require.NoError(t, repo.Update(ctx, subscription))
+
+	prices, err = repo.ListPrices(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Len(t, prices, 2)
+
+	// Исправленная цена без даты заменяет всю историю
+	subscription.Price = models.NewMoney(2000, models.DefaultCurrency)
+	require.NoError(t, repo.Update(ctx, subscription))
+
+	total, err = repo.GetTotalCost(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6*2000), total)
+
+	_, err = repo.ListPrices(ctx, uuid.New())
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestSubscriptionRepository_Events(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := models.WithRequestInfo(context.Background(), models.RequestInfo{Actor: "support", RequestID: "req-1"})
+
+	subscription := &models.Subscription{
+		ServiceName:     "Netflix",
+		Price:           models.NewMoney(1000, models.DefaultCurrency),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          createTestUser(t, pool),
+		StartDate:       month(2024, time.January),
+	}
+	require.NoError(t, repo.Create(ctx, subscription))
+
+	subscription.Price = models.NewMoney(1500, models.DefaultCurrency)
+	require.NoError(t, repo.Update(ctx, subscription))
+	require.NoError(t, repo.Delete(ctx, subscription.ID, nil))
+
+	// Журнал переживает удаление подписки
+	events, err := repo.ListEvents(ctx, subscription.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	assert.Equal(t, models.SubscriptionEventCreated, events[0].Action)
+	assert.Nil(t, events[0].Before)
+	assert.Contains(t, string(events[0].After), `"price": 1000`)
+
+	assert.Equal(t, models.SubscriptionEventUpdated, events[1].Action)
+	assert.Contains(t, string(events[1].Before), `"price": 1000`)
+	assert.Contains(t, string(events[1].After), `"price": 1500`)
+
+	assert.Equal(t, models.SubscriptionEventDeleted, events[2].Action)
+	assert.Contains(t, string(events[2].Before), `"deleted_at": null`)
+	assert.NotContains(t, string(events[2].After), `"deleted_at": null`)
+
+	for _, event := range events {
+		assert.Equal(t, "support", event.Actor)
+		assert.Equal(t, "req-1", event.RequestID)
+	}
+
+	_, err = pool.Exec(ctx, "DELETE FROM subscription_events")
+	assert.Error(t, err)
+
+	_, err = repo.ListEvents(ctx, uuid.New())
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestSubscriptionRepository_SoftDelete(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	createTestSubscription(t, repo, userID, "Netflix", 1000, month(2024, time.January), nil)
+	createTestSubscription(t, repo, userID, "Spotify", 500, month(2024, time.January), nil)
+
+	netflix := "Netflix"
+
+	subscriptions, _, err := repo.List(ctx, &models.SubscriptionListFilter{ServiceName: &netflix}, 10, 0, nil)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+
+	id := subscriptions[0].ID
+	require.NoError(t, repo.Delete(ctx, id, nil))
+	assert.ErrorIs(t, repo.Delete(ctx, id, nil), models.ErrNotFound)
+
+	_, err = repo.GetByID(ctx, id, false)
+	assert.ErrorIs(t, err, models.ErrNotFound)
+
+	deleted, err := repo.GetByID(ctx, id, true)
+	require.NoError(t, err)
+	assert.NotNil(t, deleted.DeletedAt)
+
+	_, total, err := repo.List(ctx, nil, 10, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	_, total, err = repo.List(ctx, &models.SubscriptionListFilter{IncludeDeleted: true}, 10, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	filter := &models.SubscriptionFilter{StartDate: month(2024, time.January), EndDate: monthEnd(2024, time.January)}
+
+	cost, err := repo.GetTotalCost(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), cost)
+
+	filter.IncludeDeleted = true
+
+	cost, err = repo.GetTotalCost(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), cost)
+
+	require.NoError(t, repo.Restore(ctx, id))
+	require.NoError(t, repo.Restore(ctx, id))
+
+	restored, err := repo.GetByID(ctx, id, false)
+	require.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+
+	// Удаленная до порога подписка удаляется навсегда
+	require.NoError(t, repo.Delete(ctx, id, nil))
+
+	purged, err := repo.Purge(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = repo.Purge(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = repo.GetByID(ctx, id, true)
+	assert.ErrorIs(t, err, models.ErrNotFound)
+
+	events, err := repo.ListEvents(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubscriptionEventPurged, events[len(events)-1].Action)
+
+	assert.ErrorIs(t, repo.Restore(ctx, id), models.ErrNotFound)
+}
+
+func TestSubscriptionRepository_Version(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	subscription := &models.Subscription{
+		ServiceName:     "Netflix",
+		Price:           models.NewMoney(1000, models.DefaultCurrency),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          createTestUser(t, pool),
+		StartDate:       month(2024, time.January),
+	}
+	require.NoError(t, repo.Create(ctx, subscription))
+	assert.Equal(t, 1, subscription.Version)
+
+	stale := *subscription
+
+	subscription.ServiceName = "Netflix Premium"
+	require.NoError(t, repo.Update(ctx, subscription))
+	assert.Equal(t, 2, subscription.Version)
+
+	// Запись по устаревшей версии не затирает чужое изменение
+	stale.ServiceName = "Netflix Basic"
+	assert.ErrorIs(t, repo.Update(ctx, &stale), models.ErrVersionConflict)
+
+	staleVersion := 1
+	assert.ErrorIs(t, repo.Delete(ctx, subscription.ID, &staleVersion), models.ErrVersionConflict)
+	require.NoError(t, repo.Delete(ctx, subscription.ID, &subscription.Version))
+
+	deleted, err := repo.GetByID(ctx, subscription.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, "Netflix Premium", deleted.ServiceName)
+	assert.Equal(t, 3, deleted.Version)
+}
+
+func TestSubscriptionRepository_WithinTransaction(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	errRollback := errors.New("rollback")
+
+	err := repo.WithinTransaction(ctx, func(tx SubscriptionRepository) error {
+		createTestSubscription(t, tx, userID, "Netflix", 79900, month(2024, time.January), nil)
+
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+
+	subscriptions, total, err := repo.List(ctx, &models.SubscriptionListFilter{UserID: &userID}, 10, 0, nil)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, subscriptions)
+
+	err = repo.WithinTransaction(ctx, func(tx SubscriptionRepository) error {
+		createTestSubscription(t, tx, userID, "Netflix", 79900, month(2024, time.January), nil)
+
+		// Ошибка записи откатывает только свою точку сохранения
+		assert.ErrorIs(t, tx.Delete(ctx, uuid.New(), nil), models.ErrNotFound)
+
+		createTestSubscription(t, tx, userID, "Spotify", 16900, month(2024, time.January), nil)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, total, err = repo.List(ctx, &models.SubscriptionListFilter{UserID: &userID}, 10, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
+
+func TestSubscriptionRepository_CreateMany(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	end := monthEnd(2024, time.June)
+	subscriptions := []*models.Subscription{
+		{
+			ServiceName:     "Netflix",
+			Price:           models.NewMoney(79900, models.DefaultCurrency),
+			BillingPeriod:   models.BillingPeriodMonthly,
+			BillingInterval: 1,
+			UserID:          userID,
+			StartDate:       month(2024, time.January),
+		},
+		{
+			ServiceName:     "Spotify",
+			Price:           models.NewMoney(999, "USD"),
+			BillingPeriod:   models.BillingPeriodYearly,
+			BillingInterval: 1,
+			UserID:          userID,
+			StartDate:       month(2024, time.February),
+			EndDate:         &end,
+		},
+	}
+
+	require.NoError(t, repo.CreateMany(ctx, subscriptions))
+
+	for _, sub := range subscriptions {
+		stored, err := repo.GetByID(ctx, sub.ID, false)
+		require.NoError(t, err)
+		assert.Equal(t, sub.ServiceName, stored.ServiceName)
+		assert.Equal(t, sub.Price, stored.Price)
+		assert.Equal(t, sub.EndDate, stored.EndDate)
+		assert.Equal(t, 1, stored.Version)
+
+		prices, err := repo.ListPrices(ctx, sub.ID)
+		require.NoError(t, err)
+		require.Len(t, prices, 1)
+		assert.Equal(t, sub.StartDate, prices[0].EffectiveFrom)
+
+		events, err := repo.ListEvents(ctx, sub.ID)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, models.SubscriptionEventCreated, events[0].Action)
+	}
+}
+
+func TestSubscriptionRepository_Export(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+
+	createTestSubscription(t, repo, userID, "Netflix", 79900, month(2024, time.January), nil)
+	createTestSubscription(t, repo, userID, "Spotify", 16900, month(2024, time.February), nil)
+	createTestSubscription(t, repo, createTestUser(t, pool), "Okko", 39900, month(2024, time.March), nil)
+
+	filter := &models.SubscriptionListFilter{
+		UserID: &userID,
+		Sort:   []models.SortField{{Field: "price"}},
+	}
+
+	var names []string
+
+	err := repo.Export(ctx, filter, func(subscription *models.Subscription) error {
+		names = append(names, subscription.ServiceName)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Spotify", "Netflix"}, names)
+
+	errStop := errors.New("stop")
+
+	err = repo.Export(ctx, nil, func(*models.Subscription) error {
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+}
+
+func TestSubscriptionRepository_Tags(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+
+	create := func(name, category string, tags []string) *models.Subscription {
+		sub := &models.Subscription{
+			ServiceName:     name,
+			Price:           models.NewMoney(500, models.DefaultCurrency),
+			BillingPeriod:   models.BillingPeriodMonthly,
+			BillingInterval: 1,
+			UserID:          userID,
+			StartDate:       month(2024, time.January),
+			Category:        category,
+			Tags:            tags,
+		}
+		require.NoError(t, repo.Create(ctx, sub))
+
+		return sub
+	}
+
+	netflix := create("Netflix", "entertainment", []string{"family", "shared"})
+	create("Spotify", "entertainment", []string{"family"})
+	create("Jira", "work", nil)
+	create("Notion", "", []string{"shared"})
+
+	stored, err := repo.GetByID(ctx, netflix.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, "entertainment", stored.Category)
+	assert.Equal(t, []string{"family", "shared"}, stored.Tags)
+
+	names := func(filter *models.SubscriptionListFilter) []string {
+		subs, _, err := repo.List(ctx, filter, 100, 0, nil)
+		require.NoError(t, err)
+
+		result := make([]string, 0, len(subs))
+		for _, sub := range subs {
+			result = append(result, sub.ServiceName)
+		}
+
+		return result
+	}
+
+	work := "work"
+
+	assert.ElementsMatch(t, []string{"Netflix", "Spotify"}, names(&models.SubscriptionListFilter{Tags: []string{"family"}}))
+	assert.ElementsMatch(t, []string{"Netflix"}, names(&models.SubscriptionListFilter{Tags: []string{"family", "shared"}}))
+	assert.ElementsMatch(t, []string{"Jira"}, names(&models.SubscriptionListFilter{Category: &work}))
+
+	filter := &models.SubscriptionFilter{StartDate: month(2024, time.January), EndDate: month(2024, time.January)}
+
+	byCategory, _, err := repo.GetGroupedCost(ctx, filter, []models.CostDimension{models.CostDimensionCategory})
+	require.NoError(t, err)
+	require.Len(t, byCategory, 3)
+	assert.Equal(t, "entertainment", *byCategory[0].Category)
+	assert.Equal(t, int64(1000), byCategory[0].Amount)
+
+	filter.Tags = []string{"shared"}
+
+	total, err := repo.GetTotalCost(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), total)
+}
+
+func TestSubscriptionRepository_Tags_WithOtherFilters(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	userID := createTestUser(t, pool)
+	otherUserID := createTestUser(t, pool)
+
+	create := func(userID uuid.UUID, name string, price int64, start time.Time, tags []string) {
+		require.NoError(t, repo.Create(ctx, &models.Subscription{
+			ServiceName:     name,
+			Price:           models.NewMoney(price, models.DefaultCurrency),
+			BillingPeriod:   models.BillingPeriodMonthly,
+			BillingInterval: 1,
+			UserID:          userID,
+			StartDate:       start,
+			Category:        "entertainment",
+			Tags:            tags,
+		}))
+	}
+
+	create(userID, "Netflix", 900, month(2024, time.January), []string{"family"})
+	create(userID, "Spotify", 300, month(2024, time.January), []string{"family"})
+	create(userID, "Kinopoisk", 500, month(2024, time.March), []string{"family"})
+	create(userID, "Jira", 700, month(2024, time.January), nil)
+	create(otherUserID, "Netflix", 900, month(2024, time.January), []string{"family"})
+
+	// Условия после меток должны получать свои номера параметров
+	minPrice := int64(400)
+	startTo := month(2024, time.February)
+
+	subs, total, err := repo.List(ctx, &models.SubscriptionListFilter{
+		UserID:   &userID,
+		Tags:     []string{"family"},
+		MinPrice: &minPrice,
+		StartTo:  &startTo,
+	}, 100, 0, nil)
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "Netflix", subs[0].ServiceName)
+
+	category := "entertainment"
+	serviceName := "Netflix"
+
+	cost, err := repo.GetTotalCost(ctx, &models.SubscriptionFilter{
+		UserID:      &userID,
+		ServiceName: &serviceName,
+		Category:    &category,
+		Tags:        []string{"family"},
+		StartDate:   month(2024, time.January),
+		EndDate:     monthEnd(2024, time.March),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2700), cost)
+}
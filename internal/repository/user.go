@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const userColumns = "id, display_name, COALESCE(email, ''), locale, default_currency, timezone, created_at, updated_at"
+
+// userForeignKey is the constraint that links subscriptions to their users.
+const userForeignKey = "subscriptions_user_id_fkey"
+
+func scanUser(row pgx.Row, user *models.User) error {
+	return row.Scan(
+		&user.ID,
+		&user.DisplayName,
+		&user.Email,
+		&user.Locale,
+		&user.DefaultCurrency,
+		&user.Timezone,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+}
+
+// userError maps the constraint violations of a user write to model errors.
+func userError(action string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return models.ErrUserEmailTaken
+	}
+
+	return fmt.Errorf("failed to %s user: %w", action, err)
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	query := `
+		INSERT INTO users (display_name, email, locale, default_currency, timezone)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		user.DisplayName,
+		user.Email,
+		user.Locale,
+		user.DefaultCurrency,
+		user.Timezone,
+	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return userError("create", err)
+	}
+
+	return nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	var user models.User
+
+	if err := scanUser(r.db.QueryRow(ctx, query, id), &user); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// ExistingIDs reports which of ids belong to a user, with a single query
+// however many ids are checked.
+func (r *userRepository) ExistingIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	existing := make(map[uuid.UUID]bool, len(ids))
+
+	rows, err := r.db.Query(ctx, `SELECT id FROM users WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %w", err)
+		}
+
+		existing[id] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return existing, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	query := `
+		UPDATE users
+		SET display_name = $2, email = NULLIF($3, ''), locale = $4, default_currency = $5, timezone = $6
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		user.ID,
+		user.DisplayName,
+		user.Email,
+		user.Locale,
+		user.DefaultCurrency,
+		user.Timezone,
+	).Scan(&user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ErrUserNotFound
+		}
+
+		return userError("update", err)
+	}
+
+	return nil
+}
+
+// Delete removes the user. Without cascade it fails with models.ErrUserInUse
+// while the user has subscriptions, deleted ones included; with cascade the
+// subscriptions are removed permanently first, as Purge does, in the same
+// transaction.
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID, cascade bool) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if cascade {
+		query := `
+			WITH purged AS (
+				DELETE FROM subscriptions s
+				WHERE user_id = $1
+				RETURNING s.id, to_jsonb(s) AS snapshot
+			)
+			INSERT INTO subscription_events (subscription_id, action, before, actor, request_id)
+			SELECT id, $2, snapshot, NULLIF($3, ''), NULLIF($4, '')
+			FROM purged
+		`
+
+		info := models.RequestInfoFromContext(ctx)
+
+		if _, err = tx.Exec(ctx, query, id, models.SubscriptionEventPurged, info.Actor, info.RequestID); err != nil {
+			return fmt.Errorf("failed to delete user subscriptions: %w", err)
+		}
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return models.ErrUserInUse
+		}
+
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return models.ErrUserNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// List returns a page of users ordered by display name and the total number
+// of users matching the filter.
+func (r *userRepository) List(ctx context.Context, filter *models.UserFilter, limit, offset int) ([]*models.User, int, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Search != "" {
+		add(`(display_name ILIKE $%[1]d ESCAPE '\' OR email ILIKE $%[1]d ESCAPE '\')`, escapeLike(filter.Search)+"%")
+	}
+
+	var total int
+
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM users"+whereClause(conditions), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users` + whereClause(conditions) + fmt.Sprintf(`
+		ORDER BY lower(display_name), id
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0, limit)
+
+	for rows.Next() {
+		var user models.User
+
+		if err = scanUser(rows, &user); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+
+		users = append(users, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, total, nil
+}
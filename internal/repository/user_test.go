@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+)
+
+func TestUserRepository(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewUserRepository(pool)
+	ctx := context.Background()
+
+	user := &models.User{
+		DisplayName:     "Ivan Petrov",
+		Email:           "Ivan@Example.com",
+		Locale:          "ru-RU",
+		DefaultCurrency: "RUB",
+		Timezone:        "Europe/Moscow",
+	}
+	require.NoError(t, repo.Create(ctx, user))
+
+	found, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Ivan@Example.com", found.Email)
+
+	_, err = repo.GetByID(ctx, uuid.New())
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+
+	// Email сравнивается без учета регистра
+	err = repo.Create(ctx, &models.User{
+		DisplayName:     "Other",
+		Email:           "ivan@example.com",
+		Locale:          models.DefaultLocale,
+		DefaultCurrency: models.DefaultCurrency,
+		Timezone:        models.DefaultTimezone,
+	})
+	require.ErrorIs(t, err, models.ErrUserEmailTaken)
+
+	user.Timezone = "Asia/Novosibirsk"
+	require.NoError(t, repo.Update(ctx, user))
+
+	require.ErrorIs(t, repo.Update(ctx, &models.User{ID: uuid.New(), DisplayName: "Nobody"}), models.ErrUserNotFound)
+
+	users, total, err := repo.List(ctx, &models.UserFilter{Search: "ivan@"}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, users, 1)
+	assert.Equal(t, "Asia/Novosibirsk", users[0].Timezone)
+
+	require.ErrorIs(t, repo.Delete(ctx, uuid.New(), false), models.ErrUserNotFound)
+
+	missing := uuid.New()
+
+	existing, err := repo.ExistingIDs(ctx, []uuid.UUID{user.ID, missing})
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]bool{user.ID: true}, existing)
+}
+
+func TestUserRepository_Subscriptions(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewUserRepository(pool)
+	subscriptions := NewSubscriptionRepository(pool)
+	ctx := context.Background()
+
+	subscription := func(userID uuid.UUID) *models.Subscription {
+		return &models.Subscription{
+			ServiceName:     "Netflix",
+			Price:           models.NewMoney(799, models.DefaultCurrency),
+			BillingPeriod:   models.BillingPeriodMonthly,
+			BillingInterval: 1,
+			UserID:          userID,
+			StartDate:       month(2024, time.January),
+		}
+	}
+
+	require.ErrorIs(t, subscriptions.Create(ctx, subscription(uuid.New())), models.ErrUserNotFound)
+	require.ErrorIs(t, subscriptions.CreateMany(ctx, []*models.Subscription{subscription(uuid.New())}), models.ErrUserNotFound)
+
+	userID := createTestUser(t, pool)
+	sub := subscription(userID)
+	require.NoError(t, subscriptions.Create(ctx, sub))
+	require.NoError(t, subscriptions.Delete(ctx, sub.ID, nil))
+
+	// Удаленная подписка тоже не дает удалить пользователя без каскада
+	require.ErrorIs(t, repo.Delete(ctx, userID, false), models.ErrUserInUse)
+
+	require.NoError(t, repo.Delete(ctx, userID, true))
+
+	_, err := repo.GetByID(ctx, userID)
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+
+	_, err = subscriptions.GetByID(ctx, sub.ID, true)
+	require.ErrorIs(t, err, models.ErrNotFound)
+
+	events, err := subscriptions.ListEvents(ctx, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubscriptionEventPurged, events[len(events)-1].Action)
+}
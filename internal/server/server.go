@@ -55,19 +55,72 @@ func (s *Server) GetEchoInstance() *echo.Echo {
 	return s.echo
 }
 
-func SetupRouter(e *echo.Echo, subscriptionHandler *handler.SubscriptionHandler, logger *slog.Logger) {
+func SetupRouter(
+	e *echo.Echo,
+	subscriptionHandler *handler.SubscriptionHandler,
+	exchangeRateHandler *handler.ExchangeRateHandler,
+	serviceCatalogHandler *handler.ServiceCatalogHandler,
+	userHandler *handler.UserHandler,
+	idempotency echo.MiddlewareFunc,
+	logger *slog.Logger,
+) {
 	e.Use(middleware.LoggingMiddleware(logger))
+	e.Use(middleware.RequestInfoMiddleware())
 
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 	// Subscription routes
-	subscriptions := e.Group("/subscriptions")
+	subscriptions := e.Group("/subscriptions", middleware.DateFormatMiddleware())
 	{
-		subscriptions.POST("", subscriptionHandler.CreateSubscription)
+		subscriptions.POST("", subscriptionHandler.CreateSubscription, idempotency)
+		subscriptions.POST("/batch", subscriptionHandler.ProcessBatch, idempotency)
+		subscriptions.POST("/import", subscriptionHandler.ImportSubscriptions)
 		subscriptions.GET("", subscriptionHandler.ListSubscriptions)
+		subscriptions.GET("/export", subscriptionHandler.ExportSubscriptions)
 		subscriptions.GET("/total-cost", subscriptionHandler.CalculateTotalCost)
+		subscriptions.GET("/total-cost/breakdown", subscriptionHandler.CalculateTotalCostBreakdown)
+		subscriptions.GET("/plan-prices", subscriptionHandler.ComparePlanPrices)
 		subscriptions.GET("/:id", subscriptionHandler.GetSubscription)
 		subscriptions.PUT("/:id", subscriptionHandler.UpdateSubscription)
+		subscriptions.PATCH("/:id", subscriptionHandler.PatchSubscription)
+		subscriptions.GET("/:id/prices", subscriptionHandler.GetSubscriptionPrices)
+		subscriptions.GET("/:id/history", subscriptionHandler.GetSubscriptionHistory)
 		subscriptions.DELETE("/:id", subscriptionHandler.DeleteSubscription)
+		subscriptions.POST("/:id/restore", subscriptionHandler.RestoreSubscription)
+	}
+
+	// Exchange rate routes
+	exchangeRates := e.Group("/exchange-rates")
+	{
+		exchangeRates.POST("", exchangeRateHandler.CreateExchangeRate)
+		exchangeRates.GET("", exchangeRateHandler.ListExchangeRates)
+		exchangeRates.GET("/:base/:quote/:date", exchangeRateHandler.GetExchangeRate)
+		exchangeRates.PUT("/:base/:quote/:date", exchangeRateHandler.PutExchangeRate)
+		exchangeRates.DELETE("/:base/:quote/:date", exchangeRateHandler.DeleteExchangeRate)
+	}
+
+	// Service catalog routes
+	services := e.Group("/services")
+	{
+		services.POST("", serviceCatalogHandler.CreateService)
+		services.GET("", serviceCatalogHandler.ListServices)
+		services.GET("/:id", serviceCatalogHandler.GetService)
+		services.PUT("/:id", serviceCatalogHandler.UpdateService)
+		services.DELETE("/:id", serviceCatalogHandler.DeleteService)
+		services.POST("/:id/plans", serviceCatalogHandler.CreatePlan)
+		services.GET("/:id/plans", serviceCatalogHandler.ListPlans)
+		services.GET("/:id/plans/:plan_id", serviceCatalogHandler.GetPlan)
+		services.PUT("/:id/plans/:plan_id", serviceCatalogHandler.UpdatePlan)
+		services.DELETE("/:id/plans/:plan_id", serviceCatalogHandler.DeletePlan)
+	}
+
+	// User routes
+	users := e.Group("/users")
+	{
+		users.POST("", userHandler.CreateUser)
+		users.GET("", userHandler.ListUsers)
+		users.GET("/:id", userHandler.GetUser)
+		users.PUT("/:id", userHandler.UpdateUser)
+		users.DELETE("/:id", userHandler.DeleteUser)
 	}
 
 	e.GET("/health", func(c echo.Context) error {
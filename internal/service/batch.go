@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// errBatchFailed rolls back an atomic batch with a failed operation.
+var errBatchFailed = errors.New("batch operation failed")
+
+// ProcessBatch applies the operations of the batch in order. Failed
+// operations are reported in their results rather than as an error; in an
+// atomic batch they also roll back the operations that succeeded.
+func (s *subscriptionService) ProcessBatch(ctx context.Context, req *models.BatchRequest) (*models.BatchResponse, error) {
+	if err := validateBatchRequest(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	response := &models.BatchResponse{Mode: req.Mode}
+
+	if response.Mode == "" {
+		response.Mode = models.BatchModeAtomic
+	}
+
+	if response.Mode == models.BatchModeBestEffort {
+		response.Results = s.runBatch(ctx, req.Operations)
+	} else {
+		err := s.repo.WithinTransaction(ctx, func(repo repository.SubscriptionRepository) error {
+			txService := *s
+			txService.repo = repo
+
+			response.Results = txService.runBatch(ctx, req.Operations)
+
+			for _, result := range response.Results {
+				if result.Err != nil {
+					return errBatchFailed
+				}
+			}
+
+			return nil
+		})
+
+		switch {
+		case errors.Is(err, errBatchFailed):
+			for _, result := range response.Results {
+				if result.Err == nil {
+					result.Err = models.ErrBatchRolledBack
+					result.Subscription = nil
+				}
+			}
+		case err != nil:
+			return nil, fmt.Errorf("failed to process batch: %w", err)
+		}
+	}
+
+	for _, result := range response.Results {
+		if result.Err == nil {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	return response, nil
+}
+
+func validateBatchRequest(req *models.BatchRequest) error {
+	switch req.Mode {
+	case "", models.BatchModeAtomic, models.BatchModeBestEffort:
+	default:
+		return fmt.Errorf("invalid batch mode %q, expected atomic or best_effort", req.Mode)
+	}
+
+	if len(req.Operations) == 0 {
+		return errors.New("operations are required")
+	}
+
+	if len(req.Operations) > models.MaxBatchOperations {
+		return fmt.Errorf("batch cannot contain more than %d operations", models.MaxBatchOperations)
+	}
+
+	return nil
+}
+
+func (s *subscriptionService) runBatch(ctx context.Context, operations []*models.BatchOperation) []*models.BatchItemResult {
+	results := make([]*models.BatchItemResult, len(operations))
+
+	for i, op := range operations {
+		results[i] = s.runBatchOperation(ctx, op)
+		results[i].Index = i
+	}
+
+	return results
+}
+
+func (s *subscriptionService) runBatchOperation(ctx context.Context, op *models.BatchOperation) *models.BatchItemResult {
+	result := &models.BatchItemResult{Op: op.Op}
+
+	if op.ID != uuid.Nil {
+		id := op.ID
+		result.ID = &id
+	}
+
+	switch op.Op {
+	case models.BatchOperationCreate:
+		var req models.CreateSubscriptionRequest
+
+		if result.Err = decodeBatchData(op.Data, &req); result.Err == nil {
+			result.Subscription, result.Err = s.CreateSubscription(ctx, &req)
+		}
+	case models.BatchOperationUpdate:
+		var req models.UpdateSubscriptionRequest
+
+		if result.Err = decodeBatchData(op.Data, &req); result.Err == nil {
+			result.Subscription, result.Err = s.UpdateSubscription(ctx, op.ID, &req, op.Version)
+		}
+	case models.BatchOperationDelete:
+		result.Err = s.DeleteSubscription(ctx, op.ID, op.Version)
+	default:
+		result.Err = fmt.Errorf("validation failed: invalid operation %q, expected create, update or delete", op.Op)
+	}
+
+	if result.Subscription != nil {
+		result.ID = &result.Subscription.ID
+	}
+
+	return result
+}
+
+func decodeBatchData(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return errors.New("validation failed: data is required")
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("validation failed: invalid data: %w", err)
+	}
+
+	return nil
+}
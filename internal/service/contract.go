@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"io"
 
 	"github.com/vnchk1/subscription-aggregator/internal/models"
 	"github.com/vnchk1/subscription-aggregator/internal/repository"
@@ -11,19 +12,107 @@ import (
 
 type SubscriptionService interface {
 	CreateSubscription(ctx context.Context, req *models.CreateSubscriptionRequest) (*models.SubscriptionResponse, error)
-	GetSubscription(ctx context.Context, id uuid.UUID) (*models.SubscriptionResponse, error)
-	UpdateSubscription(ctx context.Context, id uuid.UUID, req *models.UpdateSubscriptionRequest) (*models.SubscriptionResponse, error)
-	DeleteSubscription(ctx context.Context, id uuid.UUID) error
-	ListSubscriptions(ctx context.Context, userID *uuid.UUID, page, limit int) (*models.ListResponse, error)
+	GetSubscription(ctx context.Context, id uuid.UUID, includeDeleted bool) (*models.SubscriptionResponse, error)
+	UpdateSubscription(
+		ctx context.Context,
+		id uuid.UUID,
+		req *models.UpdateSubscriptionRequest,
+		expectedVersion *int,
+	) (*models.SubscriptionResponse, error)
+	PatchSubscription(ctx context.Context, id uuid.UUID, patch []byte, expectedVersion *int) (*models.SubscriptionResponse, error)
+	GetSubscriptionPrices(ctx context.Context, id uuid.UUID) (*models.SubscriptionPricesResponse, error)
+	GetSubscriptionHistory(ctx context.Context, id uuid.UUID) (*models.SubscriptionHistoryResponse, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID, expectedVersion *int) error
+	RestoreSubscription(ctx context.Context, id uuid.UUID) (*models.SubscriptionResponse, error)
+	PurgeDeletedSubscriptions(ctx context.Context, olderThanDays int) (int, error)
+	ProcessBatch(ctx context.Context, req *models.BatchRequest) (*models.BatchResponse, error)
+	ImportSubscriptions(ctx context.Context, r io.Reader, dryRun bool) (*models.ImportResponse, error)
+	ExportSubscriptions(ctx context.Context, req *models.ListSubscriptionsRequest, format models.ExportFormat, w io.Writer) error
+	ListSubscriptions(ctx context.Context, req *models.ListSubscriptionsRequest) (*models.ListResponse, error)
+	ValidateListRequest(req *models.ListSubscriptionsRequest) error
 	CalculateTotalCost(ctx context.Context, req *models.TotalCostRequest) (*models.TotalCostResponse, error)
+	CalculateTotalCostBreakdown(ctx context.Context, req *models.TotalCostRequest) (*models.TotalCostBreakdownResponse, error)
+	ComparePlanPrices(ctx context.Context, req *models.PlanPriceReportRequest) (*models.PlanPriceReportResponse, error)
 }
 
 type subscriptionService struct {
-	repo repository.SubscriptionRepository
+	repo     repository.SubscriptionRepository
+	services repository.ServiceRepository
+	users    repository.UserRepository
 }
 
-func NewSubscriptionService(repo repository.SubscriptionRepository) SubscriptionService {
+func NewSubscriptionService(
+	repo repository.SubscriptionRepository,
+	services repository.ServiceRepository,
+	users repository.UserRepository,
+) SubscriptionService {
 	return &subscriptionService{
+		repo:     repo,
+		services: services,
+		users:    users,
+	}
+}
+
+type ServiceCatalogService interface {
+	CreateService(ctx context.Context, req *models.ServiceRequest) (*models.Service, error)
+	GetService(ctx context.Context, id uuid.UUID) (*models.Service, error)
+	UpdateService(ctx context.Context, id uuid.UUID, req *models.ServiceRequest) (*models.Service, error)
+	DeleteService(ctx context.Context, id uuid.UUID) error
+	ListServices(ctx context.Context, req *models.ListServicesRequest) (*models.ListResponse, error)
+	CreatePlan(ctx context.Context, serviceID uuid.UUID, req *models.ServicePlanRequest) (*models.ServicePlanResponse, error)
+	GetPlan(ctx context.Context, serviceID, planID uuid.UUID) (*models.ServicePlanResponse, error)
+	UpdatePlan(
+		ctx context.Context,
+		serviceID, planID uuid.UUID,
+		req *models.ServicePlanRequest,
+	) (*models.ServicePlanResponse, error)
+	DeletePlan(ctx context.Context, serviceID, planID uuid.UUID) error
+	ListPlans(ctx context.Context, serviceID uuid.UUID) ([]*models.ServicePlanResponse, error)
+}
+
+type serviceCatalogService struct {
+	repo repository.ServiceRepository
+}
+
+func NewServiceCatalogService(repo repository.ServiceRepository) ServiceCatalogService {
+	return &serviceCatalogService{
+		repo: repo,
+	}
+}
+
+type ExchangeRateService interface {
+	CreateExchangeRate(ctx context.Context, req *models.CreateExchangeRateRequest) (*models.ExchangeRateResponse, error)
+	GetExchangeRate(ctx context.Context, key models.ExchangeRateKey) (*models.ExchangeRateResponse, error)
+	PutExchangeRate(ctx context.Context, key models.ExchangeRateKey, req *models.PutExchangeRateRequest) (*models.ExchangeRateResponse, error)
+	DeleteExchangeRate(ctx context.Context, key models.ExchangeRateKey) error
+	ListExchangeRates(ctx context.Context, req *models.ListExchangeRatesRequest) (*models.ListResponse, error)
+	ImportExchangeRates(ctx context.Context, format models.RateFileFormat, r io.Reader) (int, error)
+}
+
+type exchangeRateService struct {
+	repo repository.ExchangeRateRepository
+}
+
+func NewExchangeRateService(repo repository.ExchangeRateRepository) ExchangeRateService {
+	return &exchangeRateService{
+		repo: repo,
+	}
+}
+
+type UserService interface {
+	CreateUser(ctx context.Context, req *models.UserRequest) (*models.User, error)
+	GetUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+	UpdateUser(ctx context.Context, id uuid.UUID, req *models.UserRequest) (*models.User, error)
+	DeleteUser(ctx context.Context, id uuid.UUID, cascade bool) error
+	ListUsers(ctx context.Context, req *models.ListUsersRequest) (*models.ListResponse, error)
+}
+
+type userService struct {
+	repo repository.UserRepository
+}
+
+func NewUserService(repo repository.UserRepository) UserService {
+	return &userService{
 		repo: repo,
 	}
 }
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+)
+
+func (s *exchangeRateService) CreateExchangeRate(
+	ctx context.Context,
+	req *models.CreateExchangeRateRequest,
+) (*models.ExchangeRateResponse, error) {
+	rateDate, err := time.Parse(time.DateOnly, req.RateDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate date format: %w", err)
+	}
+
+	rate := &models.ExchangeRate{
+		RateDate:      rateDate,
+		BaseCurrency:  req.BaseCurrency,
+		QuoteCurrency: req.QuoteCurrency,
+		Rate:          req.Rate,
+	}
+
+	if err = s.normalizeExchangeRate(rate); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err = s.repo.Create(ctx, rate); err != nil {
+		return nil, fmt.Errorf("failed to create exchange rate: %w", err)
+	}
+
+	return s.toResponse(rate), nil
+}
+
+func (s *exchangeRateService) GetExchangeRate(ctx context.Context, key models.ExchangeRateKey) (*models.ExchangeRateResponse, error) {
+	key, err := s.normalizeKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	rate, err := s.repo.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+
+	return s.toResponse(rate), nil
+}
+
+// PutExchangeRate creates the rate of the key or replaces the stored one.
+func (s *exchangeRateService) PutExchangeRate(
+	ctx context.Context,
+	key models.ExchangeRateKey,
+	req *models.PutExchangeRateRequest,
+) (*models.ExchangeRateResponse, error) {
+	rate := &models.ExchangeRate{
+		RateDate:      key.RateDate,
+		BaseCurrency:  key.BaseCurrency,
+		QuoteCurrency: key.QuoteCurrency,
+		Rate:          req.Rate,
+	}
+
+	if err := s.normalizeExchangeRate(rate); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err := s.repo.Upsert(ctx, rate); err != nil {
+		return nil, fmt.Errorf("failed to save exchange rate: %w", err)
+	}
+
+	return s.toResponse(rate), nil
+}
+
+func (s *exchangeRateService) DeleteExchangeRate(ctx context.Context, key models.ExchangeRateKey) error {
+	key, err := s.normalizeKey(key)
+	if err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err = s.repo.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete exchange rate: %w", err)
+	}
+
+	return nil
+}
+
+func (s *exchangeRateService) ListExchangeRates(ctx context.Context, req *models.ListExchangeRatesRequest) (*models.ListResponse, error) {
+	filter := &models.ExchangeRateFilter{}
+
+	var err error
+
+	if req.BaseCurrency != "" {
+		if filter.BaseCurrency, err = models.NormalizeCurrencyCode(req.BaseCurrency); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	if req.QuoteCurrency != "" {
+		if filter.QuoteCurrency, err = models.NormalizeCurrencyCode(req.QuoteCurrency); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	if req.From != "" {
+		from, err := time.Parse(time.DateOnly, req.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date format: %w", err)
+		}
+
+		filter.From = &from
+	}
+
+	if req.To != "" {
+		to, err := time.Parse(time.DateOnly, req.To)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date format: %w", err)
+		}
+
+		filter.To = &to
+	}
+
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+
+	limit := req.Limit
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	rates, total, err := s.repo.List(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+
+	responseData := make([]*models.ExchangeRateResponse, len(rates))
+	for i, rate := range rates {
+		responseData[i] = s.toResponse(rate)
+	}
+
+	return &models.ListResponse{
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+		HasMore: offset+len(rates) < total,
+		Data:    responseData,
+	}, nil
+}
+
+// ImportExchangeRates parses a rate file and stores all its rates, replacing
+// rates already stored for the same date and pair. It returns the number of
+// imported rates.
+func (s *exchangeRateService) ImportExchangeRates(ctx context.Context, format models.RateFileFormat, r io.Reader) (int, error) {
+	var (
+		rates []*models.ExchangeRate
+		err   error
+	)
+
+	switch format {
+	case models.RateFileFormatCSV:
+		rates, err = parseRatesCSV(r)
+	case models.RateFileFormatCBR:
+		rates, err = parseRatesCBR(r)
+	default:
+		return 0, fmt.Errorf("invalid rate file format: %s", format)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse exchange rates: %w", err)
+	}
+
+	if len(rates) == 0 {
+		return 0, errors.New("no exchange rates found in file")
+	}
+
+	for _, rate := range rates {
+		if err = s.normalizeExchangeRate(rate); err != nil {
+			return 0, fmt.Errorf("validation failed: %s/%s on %s: %w",
+				rate.BaseCurrency, rate.QuoteCurrency, rate.RateDate.Format(time.DateOnly), err)
+		}
+	}
+
+	if err = s.repo.UpsertMany(ctx, rates); err != nil {
+		return 0, fmt.Errorf("failed to import exchange rates: %w", err)
+	}
+
+	return len(rates), nil
+}
+
+// normalizeKey upper-cases and checks the currency codes of a rate key.
+func (s *exchangeRateService) normalizeKey(key models.ExchangeRateKey) (models.ExchangeRateKey, error) {
+	base, err := models.NormalizeCurrencyCode(key.BaseCurrency)
+	if err != nil {
+		return key, fmt.Errorf("base currency: %w", err)
+	}
+
+	quote, err := models.NormalizeCurrencyCode(key.QuoteCurrency)
+	if err != nil {
+		return key, fmt.Errorf("quote currency: %w", err)
+	}
+
+	key.BaseCurrency = base
+	key.QuoteCurrency = quote
+
+	return key, nil
+}
+
+// normalizeExchangeRate normalizes the currency codes of a rate and validates it.
+func (s *exchangeRateService) normalizeExchangeRate(rate *models.ExchangeRate) error {
+	if rate.RateDate.IsZero() {
+		return errors.New("rate date is required")
+	}
+
+	key, err := s.normalizeKey(models.ExchangeRateKey{
+		RateDate:      rate.RateDate,
+		BaseCurrency:  rate.BaseCurrency,
+		QuoteCurrency: rate.QuoteCurrency,
+	})
+	if err != nil {
+		return err
+	}
+
+	if key.BaseCurrency == key.QuoteCurrency {
+		return errors.New("base and quote currencies must differ")
+	}
+
+	if rate.Rate <= 0 {
+		return errors.New("rate must be positive")
+	}
+
+	rate.BaseCurrency = key.BaseCurrency
+	rate.QuoteCurrency = key.QuoteCurrency
+
+	return nil
+}
+
+func (s *exchangeRateService) toResponse(rate *models.ExchangeRate) *models.ExchangeRateResponse {
+	return &models.ExchangeRateResponse{
+		RateDate:      rate.RateDate.Format(time.DateOnly),
+		BaseCurrency:  rate.BaseCurrency,
+		QuoteCurrency: rate.QuoteCurrency,
+		Rate:          rate.Rate,
+		CreatedAt:     rate.CreatedAt,
+		UpdatedAt:     rate.UpdatedAt,
+	}
+}
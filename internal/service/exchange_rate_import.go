@@ -0,0 +1,165 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+)
+
+var rateCSVHeader = []string{"rate_date", "base_currency", "quote_currency", "rate"}
+
+// parseRatesCSV reads rates from a CSV file with the rateCSVHeader columns.
+func parseRatesCSV(r io.Reader) ([]*models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(rateCSVHeader)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	// Excel сохраняет CSV с BOM в начале файла
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	for i, column := range rateCSVHeader {
+		if strings.TrimSpace(strings.ToLower(header[i])) != column {
+			return nil, fmt.Errorf("invalid header: expected %s", strings.Join(rateCSVHeader, ","))
+		}
+	}
+
+	var rates []*models.ExchangeRate
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		rateDate, err := time.Parse(time.DateOnly, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rate date: %w", line, err)
+		}
+
+		value, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rate: %w", line, err)
+		}
+
+		rates = append(rates, &models.ExchangeRate{
+			RateDate:      rateDate,
+			BaseCurrency:  record[1],
+			QuoteCurrency: record[2],
+			Rate:          value,
+		})
+	}
+
+	return rates, nil
+}
+
+type cbrValCurs struct {
+	Date    string      `xml:"Date,attr"`
+	Valutes []cbrValute `xml:"Valute"`
+}
+
+type cbrValute struct {
+	CharCode string `xml:"CharCode"`
+	Nominal  string `xml:"Nominal"`
+	Value    string `xml:"Value"`
+}
+
+// parseRatesCBR reads the daily rates of the Central Bank of Russia. Every
+// currency is quoted in RUB per Nominal units, so the rate is Value / Nominal.
+func parseRatesCBR(r io.Reader) ([]*models.ExchangeRate, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = cbrCharsetReader
+
+	var valCurs cbrValCurs
+
+	if err := decoder.Decode(&valCurs); err != nil {
+		return nil, fmt.Errorf("failed to decode XML: %w", err)
+	}
+
+	rateDate, err := time.Parse("02.01.2006", valCurs.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ValCurs date: %w", err)
+	}
+
+	rates := make([]*models.ExchangeRate, 0, len(valCurs.Valutes))
+
+	for _, valute := range valCurs.Valutes {
+		nominal, err := strconv.Atoi(strings.TrimSpace(valute.Nominal))
+		if err != nil || nominal <= 0 {
+			return nil, fmt.Errorf("%s: invalid nominal %q", valute.CharCode, valute.Nominal)
+		}
+
+		// ЦБ использует запятую как десятичный разделитель
+		value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(valute.Value), ",", "."), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid value %q", valute.CharCode, valute.Value)
+		}
+
+		rates = append(rates, &models.ExchangeRate{
+			RateDate:      rateDate,
+			BaseCurrency:  strings.TrimSpace(valute.CharCode),
+			QuoteCurrency: "RUB",
+			Rate:          value / float64(nominal),
+		})
+	}
+
+	return rates, nil
+}
+
+// cbrCharsetReader decodes windows-1251, the encoding the CBR publishes in.
+func cbrCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	if !strings.EqualFold(charset, "windows-1251") {
+		return nil, fmt.Errorf("unsupported charset: %s", charset)
+	}
+
+	// Дневной файл ЦБ небольшой, поэтому перекодируем его целиком
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded strings.Builder
+
+	decoded.Grow(len(data) * 2)
+
+	for _, b := range data {
+		decoded.WriteRune(decodeWindows1251(b))
+	}
+
+	return strings.NewReader(decoded.String()), nil
+}
+
+// decodeWindows1251 maps ASCII and the Cyrillic letters of windows-1251.
+// Other symbols are not used by the fields we read and become U+FFFD.
+func decodeWindows1251(b byte) rune {
+	switch {
+	case b < 0x80:
+		return rune(b)
+	case b >= 0xC0:
+		return 'А' + rune(b-0xC0)
+	case b == 0xA8:
+		return 'Ё'
+	case b == 0xB8:
+		return 'ё'
+	default:
+		return utf8.RuneError
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/service/mocks"
+)
+
+func TestExchangeRateService_CreateExchangeRate_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExchangeRateRepository(ctrl)
+	service := NewExchangeRateService(mockRepo)
+
+	ctx := context.Background()
+	req := &models.CreateExchangeRateRequest{
+		RateDate:      "2024-01-10",
+		BaseCurrency:  "usd",
+		QuoteCurrency: "RUB",
+		Rate:          89.6883,
+	}
+
+	mockRepo.EXPECT().
+		Create(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, rate *models.ExchangeRate) error {
+			assert.Equal(t, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), rate.RateDate)
+			assert.Equal(t, "USD", rate.BaseCurrency)
+			assert.Equal(t, "RUB", rate.QuoteCurrency)
+			return nil
+		})
+
+	result, err := service.CreateExchangeRate(ctx, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-10", result.RateDate)
+	assert.Equal(t, "USD", result.BaseCurrency)
+	assert.InDelta(t, 89.6883, result.Rate, 1e-9)
+}
+
+func TestExchangeRateService_CreateExchangeRate_InvalidData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExchangeRateRepository(ctrl)
+	service := NewExchangeRateService(mockRepo)
+
+	testCases := []struct {
+		name    string
+		request *models.CreateExchangeRateRequest
+		wantErr string
+	}{
+		{
+			name:    "invalid date",
+			request: &models.CreateExchangeRateRequest{RateDate: "01-2024", BaseCurrency: "USD", QuoteCurrency: "RUB", Rate: 1},
+			wantErr: "invalid rate date format",
+		},
+		{
+			name:    "invalid currency",
+			request: &models.CreateExchangeRateRequest{RateDate: "2024-01-01", BaseCurrency: "US", QuoteCurrency: "RUB", Rate: 1},
+			wantErr: "base currency",
+		},
+		{
+			name:    "same currencies",
+			request: &models.CreateExchangeRateRequest{RateDate: "2024-01-01", BaseCurrency: "RUB", QuoteCurrency: "rub", Rate: 1},
+			wantErr: "base and quote currencies must differ",
+		},
+		{
+			name:    "non-positive rate",
+			request: &models.CreateExchangeRateRequest{RateDate: "2024-01-01", BaseCurrency: "USD", QuoteCurrency: "RUB", Rate: 0},
+			wantErr: "rate must be positive",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := service.CreateExchangeRate(context.Background(), tc.request)
+
+			assert.Nil(t, result)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestExchangeRateService_GetExchangeRate_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExchangeRateRepository(ctrl)
+	service := NewExchangeRateService(mockRepo)
+
+	ctx := context.Background()
+	key := models.ExchangeRateKey{RateDate: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), BaseCurrency: "eur", QuoteCurrency: "rub"}
+
+	mockRepo.EXPECT().
+		Get(ctx, models.ExchangeRateKey{RateDate: key.RateDate, BaseCurrency: "EUR", QuoteCurrency: "RUB"}).
+		Return(nil, models.ErrExchangeRateNotFound)
+
+	result, err := service.GetExchangeRate(ctx, key)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, models.ErrExchangeRateNotFound)
+}
+
+func TestExchangeRateService_ImportExchangeRates_CSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExchangeRateRepository(ctrl)
+	service := NewExchangeRateService(mockRepo)
+
+	ctx := context.Background()
+	file := "\ufeffrate_date,base_currency,quote_currency,rate\n" +
+		"2024-01-10,USD,RUB,89.6883\n" +
+		"2024-01-10, eur, rub, 98.2215\n"
+
+	mockRepo.EXPECT().
+		UpsertMany(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, rates []*models.ExchangeRate) error {
+			require.Len(t, rates, 2)
+			assert.Equal(t, "EUR", rates[1].BaseCurrency)
+			assert.Equal(t, "RUB", rates[1].QuoteCurrency)
+			assert.InDelta(t, 98.2215, rates[1].Rate, 1e-9)
+			return nil
+		})
+
+	count, err := service.ImportExchangeRates(ctx, models.RateFileFormatCSV, strings.NewReader(file))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestExchangeRateService_ImportExchangeRates_CBR(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExchangeRateRepository(ctrl)
+	service := NewExchangeRateService(mockRepo)
+
+	ctx := context.Background()
+	// "Доллар США" и "Иена" в windows-1251
+	file := `<?xml version="1.0" encoding="windows-1251"?>
+<ValCurs Date="10.01.2024" name="Foreign Currency Market">
+<Valute ID="R01235"><NumCode>840</NumCode><CharCode>USD</CharCode><Nominal>1</Nominal>` +
+		"<Name>\xc4\xee\xeb\xeb\xe0\xf0 \xd1\xd8\xc0</Name>" + `<Value>89,6883</Value></Valute>
+<Valute ID="R01820"><NumCode>392</NumCode><CharCode>JPY</CharCode><Nominal>100</Nominal>` +
+		"<Name>\xc8\xe5\xed\xe0</Name>" + `<Value>61,8700</Value></Valute>
+</ValCurs>`
+
+	mockRepo.EXPECT().
+		UpsertMany(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, rates []*models.ExchangeRate) error {
+			require.Len(t, rates, 2)
+			assert.Equal(t, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), rates[0].RateDate)
+			assert.Equal(t, "USD", rates[0].BaseCurrency)
+			assert.Equal(t, "RUB", rates[0].QuoteCurrency)
+			assert.InDelta(t, 89.6883, rates[0].Rate, 1e-9)
+			assert.Equal(t, "JPY", rates[1].BaseCurrency)
+			assert.InDelta(t, 0.6187, rates[1].Rate, 1e-9)
+			return nil
+		})
+
+	count, err := service.ImportExchangeRates(ctx, models.RateFileFormatCBR, strings.NewReader(file))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestExchangeRateService_ImportExchangeRates_InvalidFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockExchangeRateRepository(ctrl)
+	service := NewExchangeRateService(mockRepo)
+
+	testCases := []struct {
+		name    string
+		format  models.RateFileFormat
+		file    string
+		wantErr string
+	}{
+		{
+			name:    "unknown format",
+			format:  "json",
+			file:    "{}",
+			wantErr: "invalid rate file format",
+		},
+		{
+			name:    "wrong header",
+			format:  models.RateFileFormatCSV,
+			file:    "date,from,to,value\n",
+			wantErr: "invalid header",
+		},
+		{
+			name:    "bad rate",
+			format:  models.RateFileFormatCSV,
+			file:    "rate_date,base_currency,quote_currency,rate\n2024-01-10,USD,RUB,abc\n",
+			wantErr: "line 2: invalid rate",
+		},
+		{
+			name:    "same currencies",
+			format:  models.RateFileFormatCSV,
+			file:    "rate_date,base_currency,quote_currency,rate\n2024-01-10,RUB,RUB,1\n",
+			wantErr: "base and quote currencies must differ",
+		},
+		{
+			name:    "empty file",
+			format:  models.RateFileFormatCSV,
+			file:    "rate_date,base_currency,quote_currency,rate\n",
+			wantErr: "no exchange rates found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			count, err := service.ImportExchangeRates(context.Background(), tc.format, strings.NewReader(tc.file))
+
+			assert.Zero(t, count)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+)
+
+var errMergePatchNotObject = errors.New("merge patch must be a JSON object")
+
+// mergePatchRequest renders the subscription as an update request, applies
+// the merge patch to it and decodes the result. Dates are rendered as
+// YYYY-MM-DD so that patching other fields keeps them as they are. A null
+// category or tags removes them.
+func mergePatchRequest(sub *models.Subscription, patch []byte) (*models.UpdateSubscriptionRequest, error) {
+	current := &models.UpdateSubscriptionRequest{
+		ServiceName:     sub.ServiceName,
+		Price:           sub.Price,
+		Currency:        sub.Price.Currency,
+		BillingPeriod:   string(sub.BillingPeriod),
+		BillingInterval: sub.BillingInterval,
+		StartDate:       sub.StartDate.Format(models.DateLayout),
+		Category:        &sub.Category,
+		Tags:            sub.Tags,
+	}
+
+	if sub.EndDate != nil {
+		endDate := sub.EndDate.Format(models.DateLayout)
+		current.EndDate = &endDate
+	}
+
+	document, err := json.Marshal(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode subscription: %w", err)
+	}
+
+	var target, changes interface{}
+
+	if err = json.Unmarshal(document, &target); err != nil {
+		return nil, fmt.Errorf("failed to encode subscription: %w", err)
+	}
+
+	if err = json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+
+	if _, ok := changes.(map[string]interface{}); !ok {
+		return nil, errMergePatchNotObject
+	}
+
+	if document, err = json.Marshal(mergePatch(target, changes)); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+
+	var req models.UpdateSubscriptionRequest
+
+	if err = decoder.Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+
+	// Удаленные патчем метки очищаются, а не сохраняются
+	if req.Category == nil {
+		req.Category = new(string)
+	}
+
+	if req.Tags == nil {
+		req.Tags = []string{}
+	}
+
+	return &req, nil
+}
+
+// mergePatch implements the MergePatch algorithm of RFC 7396.
+func mergePatch(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+
+	for name, value := range changes {
+		if value == nil {
+			delete(object, name)
+
+			continue
+		}
+
+		object[name] = mergePatch(object[name], value)
+	}
+
+	return object
+}
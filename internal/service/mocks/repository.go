@@ -7,10 +7,12 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
 	models "github.com/vnchk1/subscription-aggregator/internal/models"
+	repository "github.com/vnchk1/subscription-aggregator/internal/repository"
 )
 
 // MockSubscriptionRepository is a mock of SubscriptionRepository interface.
@@ -36,6 +38,21 @@ func (m *MockSubscriptionRepository) EXPECT() *MockSubscriptionRepositoryMockRec
 	return m.recorder
 }
 
+// ComparePlanPrices mocks base method.
+func (m *MockSubscriptionRepository) ComparePlanPrices(ctx context.Context, filter *models.PlanPriceFilter) ([]*models.PlanPriceComparison, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ComparePlanPrices", ctx, filter)
+	ret0, _ := ret[0].([]*models.PlanPriceComparison)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ComparePlanPrices indicates an expected call of ComparePlanPrices.
+func (mr *MockSubscriptionRepositoryMockRecorder) ComparePlanPrices(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComparePlanPrices", reflect.TypeOf((*MockSubscriptionRepository)(nil).ComparePlanPrices), ctx, filter)
+}
+
 // Create mocks base method.
 func (m *MockSubscriptionRepository) Create(ctx context.Context, subscription *models.Subscription) error {
 	m.ctrl.T.Helper()
@@ -50,40 +67,115 @@ func (mr *MockSubscriptionRepositoryMockRecorder) Create(ctx, subscription inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSubscriptionRepository)(nil).Create), ctx, subscription)
 }
 
+// CreateMany mocks base method.
+func (m *MockSubscriptionRepository) CreateMany(ctx context.Context, subscriptions []*models.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMany", ctx, subscriptions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMany indicates an expected call of CreateMany.
+func (mr *MockSubscriptionRepositoryMockRecorder) CreateMany(ctx, subscriptions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMany", reflect.TypeOf((*MockSubscriptionRepository)(nil).CreateMany), ctx, subscriptions)
+}
+
 // Delete mocks base method.
-func (m *MockSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID, version *int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockSubscriptionRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+func (mr *MockSubscriptionRepositoryMockRecorder) Delete(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSubscriptionRepository)(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSubscriptionRepository)(nil).Delete), ctx, id, version)
+}
+
+// Export mocks base method.
+func (m *MockSubscriptionRepository) Export(ctx context.Context, filter *models.SubscriptionListFilter, fn func(*models.Subscription) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockSubscriptionRepositoryMockRecorder) Export(ctx, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockSubscriptionRepository)(nil).Export), ctx, filter, fn)
+}
+
+// GetAppliedExchangeRates mocks base method.
+func (m *MockSubscriptionRepository) GetAppliedExchangeRates(ctx context.Context, filter *models.SubscriptionFilter) ([]*models.AppliedExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppliedExchangeRates", ctx, filter)
+	ret0, _ := ret[0].([]*models.AppliedExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppliedExchangeRates indicates an expected call of GetAppliedExchangeRates.
+func (mr *MockSubscriptionRepositoryMockRecorder) GetAppliedExchangeRates(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppliedExchangeRates", reflect.TypeOf((*MockSubscriptionRepository)(nil).GetAppliedExchangeRates), ctx, filter)
 }
 
 // GetByID mocks base method.
-func (m *MockSubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+func (m *MockSubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*models.Subscription, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret := m.ctrl.Call(m, "GetByID", ctx, id, includeDeleted)
 	ret0, _ := ret[0].(*models.Subscription)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByID indicates an expected call of GetByID.
-func (mr *MockSubscriptionRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+func (mr *MockSubscriptionRepositoryMockRecorder) GetByID(ctx, id, includeDeleted interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockSubscriptionRepository)(nil).GetByID), ctx, id, includeDeleted)
+}
+
+// GetGroupedCost mocks base method.
+func (m *MockSubscriptionRepository) GetGroupedCost(ctx context.Context, filter *models.SubscriptionFilter, groupBy []models.CostDimension) ([]*models.CostGroup, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupedCost", ctx, filter, groupBy)
+	ret0, _ := ret[0].([]*models.CostGroup)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetGroupedCost indicates an expected call of GetGroupedCost.
+func (mr *MockSubscriptionRepositoryMockRecorder) GetGroupedCost(ctx, filter, groupBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupedCost", reflect.TypeOf((*MockSubscriptionRepository)(nil).GetGroupedCost), ctx, filter, groupBy)
+}
+
+// GetMonthlyCost mocks base method.
+func (m *MockSubscriptionRepository) GetMonthlyCost(ctx context.Context, filter *models.SubscriptionFilter) ([]*models.MonthlyCost, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMonthlyCost", ctx, filter)
+	ret0, _ := ret[0].([]*models.MonthlyCost)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetMonthlyCost indicates an expected call of GetMonthlyCost.
+func (mr *MockSubscriptionRepositoryMockRecorder) GetMonthlyCost(ctx, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockSubscriptionRepository)(nil).GetByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMonthlyCost", reflect.TypeOf((*MockSubscriptionRepository)(nil).GetMonthlyCost), ctx, filter)
 }
 
 // GetTotalCost mocks base method.
-func (m *MockSubscriptionRepository) GetTotalCost(ctx context.Context, filter *models.SubscriptionFilter) (int, error) {
+func (m *MockSubscriptionRepository) GetTotalCost(ctx context.Context, filter *models.SubscriptionFilter) (int64, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetTotalCost", ctx, filter)
-	ret0, _ := ret[0].(int)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -95,9 +187,9 @@ func (mr *MockSubscriptionRepositoryMockRecorder) GetTotalCost(ctx, filter inter
 }
 
 // List mocks base method.
-func (m *MockSubscriptionRepository) List(ctx context.Context, userID *uuid.UUID, limit, offset int) ([]*models.Subscription, int, error) {
+func (m *MockSubscriptionRepository) List(ctx context.Context, filter *models.SubscriptionListFilter, limit, offset int, cursor *models.ListCursor) ([]*models.Subscription, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List", ctx, userID, limit, offset)
+	ret := m.ctrl.Call(m, "List", ctx, filter, limit, offset, cursor)
 	ret0, _ := ret[0].([]*models.Subscription)
 	ret1, _ := ret[1].(int)
 	ret2, _ := ret[2].(error)
@@ -105,9 +197,68 @@ func (m *MockSubscriptionRepository) List(ctx context.Context, userID *uuid.UUID
 }
 
 // List indicates an expected call of List.
-func (mr *MockSubscriptionRepositoryMockRecorder) List(ctx, userID, limit, offset interface{}) *gomock.Call {
+func (mr *MockSubscriptionRepositoryMockRecorder) List(ctx, filter, limit, offset, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSubscriptionRepository)(nil).List), ctx, filter, limit, offset, cursor)
+}
+
+// ListEvents mocks base method.
+func (m *MockSubscriptionRepository) ListEvents(ctx context.Context, id uuid.UUID) ([]*models.SubscriptionEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEvents", ctx, id)
+	ret0, _ := ret[0].([]*models.SubscriptionEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEvents indicates an expected call of ListEvents.
+func (mr *MockSubscriptionRepositoryMockRecorder) ListEvents(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSubscriptionRepository)(nil).List), ctx, userID, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEvents", reflect.TypeOf((*MockSubscriptionRepository)(nil).ListEvents), ctx, id)
+}
+
+// ListPrices mocks base method.
+func (m *MockSubscriptionRepository) ListPrices(ctx context.Context, id uuid.UUID) ([]*models.SubscriptionPrice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPrices", ctx, id)
+	ret0, _ := ret[0].([]*models.SubscriptionPrice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPrices indicates an expected call of ListPrices.
+func (mr *MockSubscriptionRepositoryMockRecorder) ListPrices(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPrices", reflect.TypeOf((*MockSubscriptionRepository)(nil).ListPrices), ctx, id)
+}
+
+// Purge mocks base method.
+func (m *MockSubscriptionRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, deletedBefore)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockSubscriptionRepositoryMockRecorder) Purge(ctx, deletedBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockSubscriptionRepository)(nil).Purge), ctx, deletedBefore)
+}
+
+// Restore mocks base method.
+func (m *MockSubscriptionRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockSubscriptionRepositoryMockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockSubscriptionRepository)(nil).Restore), ctx, id)
 }
 
 // Update mocks base method.
@@ -123,3 +274,421 @@ func (mr *MockSubscriptionRepositoryMockRecorder) Update(ctx, subscription inter
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSubscriptionRepository)(nil).Update), ctx, subscription)
 }
+
+// WithinTransaction mocks base method.
+func (m *MockSubscriptionRepository) WithinTransaction(ctx context.Context, fn func(repository.SubscriptionRepository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTransaction indicates an expected call of WithinTransaction.
+func (mr *MockSubscriptionRepositoryMockRecorder) WithinTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTransaction", reflect.TypeOf((*MockSubscriptionRepository)(nil).WithinTransaction), ctx, fn)
+}
+
+// MockExchangeRateRepository is a mock of ExchangeRateRepository interface.
+type MockExchangeRateRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockExchangeRateRepositoryMockRecorder
+}
+
+// MockExchangeRateRepositoryMockRecorder is the mock recorder for MockExchangeRateRepository.
+type MockExchangeRateRepositoryMockRecorder struct {
+	mock *MockExchangeRateRepository
+}
+
+// NewMockExchangeRateRepository creates a new mock instance.
+func NewMockExchangeRateRepository(ctrl *gomock.Controller) *MockExchangeRateRepository {
+	mock := &MockExchangeRateRepository{ctrl: ctrl}
+	mock.recorder = &MockExchangeRateRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExchangeRateRepository) EXPECT() *MockExchangeRateRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockExchangeRateRepository) Create(ctx context.Context, rate *models.ExchangeRate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, rate)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockExchangeRateRepositoryMockRecorder) Create(ctx, rate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockExchangeRateRepository)(nil).Create), ctx, rate)
+}
+
+// Delete mocks base method.
+func (m *MockExchangeRateRepository) Delete(ctx context.Context, key models.ExchangeRateKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockExchangeRateRepositoryMockRecorder) Delete(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockExchangeRateRepository)(nil).Delete), ctx, key)
+}
+
+// Get mocks base method.
+func (m *MockExchangeRateRepository) Get(ctx context.Context, key models.ExchangeRateKey) (*models.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(*models.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockExchangeRateRepositoryMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockExchangeRateRepository)(nil).Get), ctx, key)
+}
+
+// List mocks base method.
+func (m *MockExchangeRateRepository) List(ctx context.Context, filter *models.ExchangeRateFilter, limit, offset int) ([]*models.ExchangeRate, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, limit, offset)
+	ret0, _ := ret[0].([]*models.ExchangeRate)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockExchangeRateRepositoryMockRecorder) List(ctx, filter, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockExchangeRateRepository)(nil).List), ctx, filter, limit, offset)
+}
+
+// Upsert mocks base method.
+func (m *MockExchangeRateRepository) Upsert(ctx context.Context, rate *models.ExchangeRate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, rate)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockExchangeRateRepositoryMockRecorder) Upsert(ctx, rate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockExchangeRateRepository)(nil).Upsert), ctx, rate)
+}
+
+// UpsertMany mocks base method.
+func (m *MockExchangeRateRepository) UpsertMany(ctx context.Context, rates []*models.ExchangeRate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertMany", ctx, rates)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertMany indicates an expected call of UpsertMany.
+func (mr *MockExchangeRateRepositoryMockRecorder) UpsertMany(ctx, rates interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertMany", reflect.TypeOf((*MockExchangeRateRepository)(nil).UpsertMany), ctx, rates)
+}
+
+// MockServiceRepository is a mock of ServiceRepository interface.
+type MockServiceRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceRepositoryMockRecorder
+}
+
+// MockServiceRepositoryMockRecorder is the mock recorder for MockServiceRepository.
+type MockServiceRepositoryMockRecorder struct {
+	mock *MockServiceRepository
+}
+
+// NewMockServiceRepository creates a new mock instance.
+func NewMockServiceRepository(ctrl *gomock.Controller) *MockServiceRepository {
+	mock := &MockServiceRepository{ctrl: ctrl}
+	mock.recorder = &MockServiceRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServiceRepository) EXPECT() *MockServiceRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockServiceRepository) Create(ctx context.Context, service *models.Service) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, service)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockServiceRepositoryMockRecorder) Create(ctx, service interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockServiceRepository)(nil).Create), ctx, service)
+}
+
+// CreatePlan mocks base method.
+func (m *MockServiceRepository) CreatePlan(ctx context.Context, plan *models.ServicePlan) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePlan", ctx, plan)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePlan indicates an expected call of CreatePlan.
+func (mr *MockServiceRepositoryMockRecorder) CreatePlan(ctx, plan interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePlan", reflect.TypeOf((*MockServiceRepository)(nil).CreatePlan), ctx, plan)
+}
+
+// Delete mocks base method.
+func (m *MockServiceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockServiceRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockServiceRepository)(nil).Delete), ctx, id)
+}
+
+// DeletePlan mocks base method.
+func (m *MockServiceRepository) DeletePlan(ctx context.Context, serviceID, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePlan", ctx, serviceID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePlan indicates an expected call of DeletePlan.
+func (mr *MockServiceRepositoryMockRecorder) DeletePlan(ctx, serviceID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePlan", reflect.TypeOf((*MockServiceRepository)(nil).DeletePlan), ctx, serviceID, id)
+}
+
+// FindByName mocks base method.
+func (m *MockServiceRepository) FindByName(ctx context.Context, name string) (*models.Service, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByName", ctx, name)
+	ret0, _ := ret[0].(*models.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByName indicates an expected call of FindByName.
+func (mr *MockServiceRepositoryMockRecorder) FindByName(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByName", reflect.TypeOf((*MockServiceRepository)(nil).FindByName), ctx, name)
+}
+
+// GetByID mocks base method.
+func (m *MockServiceRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Service, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockServiceRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockServiceRepository)(nil).GetByID), ctx, id)
+}
+
+// GetPlan mocks base method.
+func (m *MockServiceRepository) GetPlan(ctx context.Context, id uuid.UUID) (*models.ServicePlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlan", ctx, id)
+	ret0, _ := ret[0].(*models.ServicePlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPlan indicates an expected call of GetPlan.
+func (mr *MockServiceRepositoryMockRecorder) GetPlan(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlan", reflect.TypeOf((*MockServiceRepository)(nil).GetPlan), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockServiceRepository) List(ctx context.Context, filter *models.ServiceFilter, limit, offset int) ([]*models.Service, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, limit, offset)
+	ret0, _ := ret[0].([]*models.Service)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockServiceRepositoryMockRecorder) List(ctx, filter, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockServiceRepository)(nil).List), ctx, filter, limit, offset)
+}
+
+// ListPlans mocks base method.
+func (m *MockServiceRepository) ListPlans(ctx context.Context, serviceID uuid.UUID) ([]*models.ServicePlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPlans", ctx, serviceID)
+	ret0, _ := ret[0].([]*models.ServicePlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPlans indicates an expected call of ListPlans.
+func (mr *MockServiceRepositoryMockRecorder) ListPlans(ctx, serviceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPlans", reflect.TypeOf((*MockServiceRepository)(nil).ListPlans), ctx, serviceID)
+}
+
+// Update mocks base method.
+func (m *MockServiceRepository) Update(ctx context.Context, service *models.Service) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, service)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockServiceRepositoryMockRecorder) Update(ctx, service interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockServiceRepository)(nil).Update), ctx, service)
+}
+
+// UpdatePlan mocks base method.
+func (m *MockServiceRepository) UpdatePlan(ctx context.Context, plan *models.ServicePlan) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePlan", ctx, plan)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePlan indicates an expected call of UpdatePlan.
+func (mr *MockServiceRepositoryMockRecorder) UpdatePlan(ctx, plan interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePlan", reflect.TypeOf((*MockServiceRepository)(nil).UpdatePlan), ctx, plan)
+}
+
+// MockUserRepository is a mock of UserRepository interface.
+type MockUserRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserRepositoryMockRecorder
+}
+
+// MockUserRepositoryMockRecorder is the mock recorder for MockUserRepository.
+type MockUserRepositoryMockRecorder struct {
+	mock *MockUserRepository
+}
+
+// NewMockUserRepository creates a new mock instance.
+func NewMockUserRepository(ctrl *gomock.Controller) *MockUserRepository {
+	mock := &MockUserRepository{ctrl: ctrl}
+	mock.recorder = &MockUserRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUserRepositoryMockRecorder) Create(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), ctx, user)
+}
+
+// Delete mocks base method.
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID, cascade bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id, cascade)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUserRepositoryMockRecorder) Delete(ctx, id, cascade interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUserRepository)(nil).Delete), ctx, id, cascade)
+}
+
+// ExistingIDs mocks base method.
+func (m *MockUserRepository) ExistingIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExistingIDs", ctx, ids)
+	ret0, _ := ret[0].(map[uuid.UUID]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExistingIDs indicates an expected call of ExistingIDs.
+func (mr *MockUserRepositoryMockRecorder) ExistingIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistingIDs", reflect.TypeOf((*MockUserRepository)(nil).ExistingIDs), ctx, ids)
+}
+
+// GetByID mocks base method.
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockUserRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockUserRepository) List(ctx context.Context, filter *models.UserFilter, limit, offset int) ([]*models.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, limit, offset)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockUserRepositoryMockRecorder) List(ctx, filter, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserRepository)(nil).List), ctx, filter, limit, offset)
+}
+
+// Update mocks base method.
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockUserRepositoryMockRecorder) Update(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepository)(nil).Update), ctx, user)
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func (s *serviceCatalogService) CreateService(ctx context.Context, req *models.ServiceRequest) (*models.Service, error) {
+	service, err := s.newService(req)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err = s.repo.Create(ctx, service); err != nil {
+		return nil, fmt.Errorf("failed to create service: %w", err)
+	}
+
+	return service, nil
+}
+
+func (s *serviceCatalogService) GetService(ctx context.Context, id uuid.UUID) (*models.Service, error) {
+	if id == uuid.Nil {
+		return nil, errors.New("service ID is required")
+	}
+
+	service, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+
+	return service, nil
+}
+
+// UpdateService replaces the service. Subscriptions already linked to it keep
+// the name they were created with.
+func (s *serviceCatalogService) UpdateService(
+	ctx context.Context,
+	id uuid.UUID,
+	req *models.ServiceRequest,
+) (*models.Service, error) {
+	if id == uuid.Nil {
+		return nil, errors.New("service ID is required")
+	}
+
+	service, err := s.newService(req)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	service.ID = id
+
+	if err = s.repo.Update(ctx, service); err != nil {
+		return nil, fmt.Errorf("failed to update service: %w", err)
+	}
+
+	return service, nil
+}
+
+// DeleteService removes the service unless subscriptions are linked to it.
+func (s *serviceCatalogService) DeleteService(ctx context.Context, id uuid.UUID) error {
+	if id == uuid.Nil {
+		return errors.New("service ID is required")
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+
+	return nil
+}
+
+func (s *serviceCatalogService) ListServices(ctx context.Context, req *models.ListServicesRequest) (*models.ListResponse, error) {
+	filter := &models.ServiceFilter{
+		Category: strings.TrimSpace(req.Category),
+		Search:   strings.TrimSpace(req.Search),
+	}
+
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+
+	limit := req.Limit
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	services, total, err := s.repo.List(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	return &models.ListResponse{
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+		HasMore: offset+len(services) < total,
+		Data:    services,
+	}, nil
+}
+
+// newService validates the request and builds the service it describes with
+// trimmed names and the currency code upper-cased.
+func (s *serviceCatalogService) newService(req *models.ServiceRequest) (*models.Service, error) {
+	service := &models.Service{
+		Name:            strings.TrimSpace(req.Name),
+		Aliases:         make([]string, 0, len(req.Aliases)),
+		Category:        strings.TrimSpace(req.Category),
+		Website:         strings.TrimSpace(req.Website),
+		DefaultCurrency: models.DefaultCurrency,
+	}
+
+	if service.Name == "" {
+		return nil, errors.New("service name is required")
+	}
+
+	if len(service.Name) > 255 {
+		return nil, errors.New("service name too long")
+	}
+
+	for _, alias := range req.Aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" {
+			return nil, errors.New("alias cannot be empty")
+		}
+
+		service.Aliases = append(service.Aliases, alias)
+	}
+
+	if len(service.Category) > 64 {
+		return nil, errors.New("category too long")
+	}
+
+	if len(service.Website) > 2048 {
+		return nil, errors.New("website too long")
+	}
+
+	if req.DefaultCurrency != "" {
+		currency, err := models.NormalizeCurrency(req.DefaultCurrency)
+		if err != nil {
+			return nil, err
+		}
+
+		service.DefaultCurrency = currency
+	}
+
+	return service, nil
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/service/mocks"
+)
+
+func TestServiceCatalogService_CreateService_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockServiceRepository(ctrl)
+	service := NewServiceCatalogService(mockRepo)
+
+	ctx := context.Background()
+	req := &models.ServiceRequest{
+		Name:            " Yandex Plus ",
+		Aliases:         []string{"Яндекс Плюс", " yandex+ "},
+		Category:        "music",
+		DefaultCurrency: "rub",
+	}
+
+	mockRepo.EXPECT().
+		Create(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, service *models.Service) error {
+			assert.Equal(t, "Yandex Plus", service.Name)
+			assert.Equal(t, []string{"Яндекс Плюс", "yandex+"}, service.Aliases)
+			assert.Equal(t, "RUB", service.DefaultCurrency)
+			service.ID = uuid.New()
+			return nil
+		})
+
+	result, err := service.CreateService(ctx, req)
+
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, result.ID)
+	assert.Equal(t, "music", result.Category)
+}
+
+func TestServiceCatalogService_CreateService_Defaults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockServiceRepository(ctrl)
+	service := NewServiceCatalogService(mockRepo)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	result, err := service.CreateService(context.Background(), &models.ServiceRequest{Name: "Netflix"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, result.Aliases)
+	assert.Equal(t, models.DefaultCurrency, result.DefaultCurrency)
+}
+
+func TestServiceCatalogService_CreateService_InvalidData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockServiceRepository(ctrl)
+	service := NewServiceCatalogService(mockRepo)
+
+	testCases := []struct {
+		name    string
+		request *models.ServiceRequest
+		wantErr string
+	}{
+		{
+			name:    "empty name",
+			request: &models.ServiceRequest{Name: "  "},
+			wantErr: "service name is required",
+		},
+		{
+			name:    "empty alias",
+			request: &models.ServiceRequest{Name: "Netflix", Aliases: []string{""}},
+			wantErr: "alias cannot be empty",
+		},
+		{
+			name:    "invalid currency",
+			request: &models.ServiceRequest{Name: "Netflix", DefaultCurrency: "RUBL"},
+			wantErr: "invalid currency",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := service.CreateService(context.Background(), tc.request)
+
+			require.Error(t, err)
+			assert.Nil(t, result)
+			assert.Contains(t, err.Error(), "validation failed")
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestServiceCatalogService_CreateService_NameTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockServiceRepository(ctrl)
+	service := NewServiceCatalogService(mockRepo)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(models.ErrServiceNameTaken)
+
+	_, err := service.CreateService(context.Background(), &models.ServiceRequest{Name: "Netflix"})
+
+	require.ErrorIs(t, err, models.ErrServiceNameTaken)
+}
+
+func TestServiceCatalogService_UpdateService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockServiceRepository(ctrl)
+	service := NewServiceCatalogService(mockRepo)
+
+	id := uuid.New()
+
+	mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, service *models.Service) error {
+			assert.Equal(t, id, service.ID)
+			assert.Equal(t, "USD", service.DefaultCurrency)
+			return nil
+		})
+
+	result, err := service.UpdateService(context.Background(), id, &models.ServiceRequest{
+		Name:            "Spotify",
+		DefaultCurrency: "usd",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, id, result.ID)
+}
+
+func TestServiceCatalogService_DeleteService_InUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockServiceRepository(ctrl)
+	service := NewServiceCatalogService(mockRepo)
+
+	id := uuid.New()
+
+	mockRepo.EXPECT().Delete(gomock.Any(), id).Return(models.ErrServiceInUse)
+
+	err := service.DeleteService(context.Background(), id)
+
+	require.ErrorIs(t, err, models.ErrServiceInUse)
+}
+
+func TestServiceCatalogService_ListServices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockServiceRepository(ctrl)
+	service := NewServiceCatalogService(mockRepo)
+
+	services := []*models.Service{{ID: uuid.New(), Name: "Netflix"}, {ID: uuid.New(), Name: "Spotify"}}
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), &models.ServiceFilter{Category: "video", Search: "net"}, 2, 2).
+		Return(services, 5, nil)
+
+	result, err := service.ListServices(context.Background(), &models.ListServicesRequest{
+		Category: "video",
+		Search:   " net ",
+		Page:     2,
+		Limit:    2,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Total)
+	assert.True(t, result.HasMore)
+	assert.Equal(t, services, result.Data)
+}
+
+func TestServiceCatalogService_CreatePlan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockServiceRepository(ctrl)
+	service := NewServiceCatalogService(mockRepo)
+
+	serviceID := uuid.New()
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), serviceID).Return(&models.Service{ID: serviceID}, nil)
+	mockRepo.EXPECT().
+		CreatePlan(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, plan *models.ServicePlan) error {
+			assert.Equal(t, serviceID, plan.ServiceID)
+			assert.Equal(t, []*models.PlanPrice{
+				{BillingPeriod: models.BillingPeriodMonthly, Price: models.NewMoney(26900, "RUB")},
+				{BillingPeriod: models.BillingPeriodYearly, Price: models.NewMoney(16999, "USD")},
+			}, plan.Prices)
+			return nil
+		})
+
+	result, err := service.CreatePlan(context.Background(), serviceID, &models.ServicePlanRequest{
+		Name: "Family",
+		Prices: []*models.PlanPriceRequest{
+			{Price: models.Money{Amount: 26900}},
+			{Currency: "usd", BillingPeriod: "yearly", Price: models.Money{Amount: 16999}},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Family", result.Name)
+	require.Len(t, result.Prices, 2)
+	assert.Equal(t, "USD", result.Prices[1].Currency)
+}
+
+func TestServiceCatalogService_CreatePlan_InvalidData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := NewServiceCatalogService(mocks.NewMockServiceRepository(ctrl))
+
+	testCases := []struct {
+		name    string
+		request *models.ServicePlanRequest
+		wantErr string
+	}{
+		{
+			name:    "empty name",
+			request: &models.ServicePlanRequest{},
+			wantErr: "plan name is required",
+		},
+		{
+			name: "duplicate price",
+			request: &models.ServicePlanRequest{Name: "Duo", Prices: []*models.PlanPriceRequest{
+				{Currency: "RUB", Price: models.Money{Amount: 100}},
+				{Currency: "rub", BillingPeriod: "monthly", Price: models.Money{Amount: 200}},
+			}},
+			wantErr: "duplicate monthly price in RUB",
+		},
+		{
+			name: "invalid billing period",
+			request: &models.ServicePlanRequest{Name: "Duo", Prices: []*models.PlanPriceRequest{
+				{BillingPeriod: "daily", Price: models.Money{Amount: 100}},
+			}},
+			wantErr: "invalid billing period",
+		},
+		{
+			name: "zero price",
+			request: &models.ServicePlanRequest{Name: "Duo", Prices: []*models.PlanPriceRequest{
+				{Currency: "RUB"},
+			}},
+			wantErr: "price must be positive",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := service.CreatePlan(context.Background(), uuid.New(), tc.request)
+
+			require.Error(t, err)
+			assert.Nil(t, result)
+			assert.Contains(t, err.Error(), "validation failed")
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestServiceCatalogService_GetPlan_OtherService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockServiceRepository(ctrl)
+	service := NewServiceCatalogService(mockRepo)
+
+	plan := &models.ServicePlan{ID: uuid.New(), ServiceID: uuid.New(), Name: "Family"}
+	serviceID := uuid.New()
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), serviceID).Return(&models.Service{ID: serviceID}, nil)
+	mockRepo.EXPECT().GetPlan(gomock.Any(), plan.ID).Return(plan, nil)
+
+	_, err := service.GetPlan(context.Background(), serviceID, plan.ID)
+
+	require.ErrorIs(t, err, models.ErrPlanNotFound)
+}
+
+func TestServiceCatalogService_ListPlans_UnknownService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockServiceRepository(ctrl)
+	service := NewServiceCatalogService(mockRepo)
+
+	serviceID := uuid.New()
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), serviceID).Return(nil, models.ErrServiceNotFound)
+
+	_, err := service.ListPlans(context.Background(), serviceID)
+
+	require.ErrorIs(t, err, models.ErrServiceNotFound)
+}
+
+func TestServiceCatalogService_Plans_UnknownService(t *testing.T) {
+	request := &models.ServicePlanRequest{Name: "Family"}
+
+	testCases := []struct {
+		name string
+		call func(service ServiceCatalogService, serviceID, planID uuid.UUID) error
+	}{
+		{
+			name: "create",
+			call: func(service ServiceCatalogService, serviceID, _ uuid.UUID) error {
+				_, err := service.CreatePlan(context.Background(), serviceID, request)
+				return err
+			},
+		},
+		{
+			name: "get",
+			call: func(service ServiceCatalogService, serviceID, planID uuid.UUID) error {
+				_, err := service.GetPlan(context.Background(), serviceID, planID)
+				return err
+			},
+		},
+		{
+			name: "update",
+			call: func(service ServiceCatalogService, serviceID, planID uuid.UUID) error {
+				_, err := service.UpdatePlan(context.Background(), serviceID, planID, request)
+				return err
+			},
+		},
+		{
+			name: "delete",
+			call: func(service ServiceCatalogService, serviceID, planID uuid.UUID) error {
+				return service.DeletePlan(context.Background(), serviceID, planID)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockServiceRepository(ctrl)
+			service := NewServiceCatalogService(mockRepo)
+
+			serviceID := uuid.New()
+
+			// Репозиторий тарифов не вызывается для несуществующего сервиса
+			mockRepo.EXPECT().GetByID(gomock.Any(), serviceID).Return(nil, models.ErrServiceNotFound)
+
+			err := tc.call(service, serviceID, uuid.New())
+
+			require.ErrorIs(t, err, models.ErrServiceNotFound)
+		})
+	}
+}
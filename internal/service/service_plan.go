@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func (s *serviceCatalogService) CreatePlan(
+	ctx context.Context,
+	serviceID uuid.UUID,
+	req *models.ServicePlanRequest,
+) (*models.ServicePlanResponse, error) {
+	if serviceID == uuid.Nil {
+		return nil, errors.New("service ID is required")
+	}
+
+	plan, err := s.newPlan(req)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err = s.checkService(ctx, serviceID); err != nil {
+		return nil, err
+	}
+
+	plan.ServiceID = serviceID
+
+	if err = s.repo.CreatePlan(ctx, plan); err != nil {
+		return nil, fmt.Errorf("failed to create plan: %w", err)
+	}
+
+	return s.toPlanResponse(plan), nil
+}
+
+// GetPlan returns the plan of the service; plans of other services are not found.
+func (s *serviceCatalogService) GetPlan(ctx context.Context, serviceID, planID uuid.UUID) (*models.ServicePlanResponse, error) {
+	if serviceID == uuid.Nil || planID == uuid.Nil {
+		return nil, errors.New("service ID and plan ID are required")
+	}
+
+	if err := s.checkService(ctx, serviceID); err != nil {
+		return nil, err
+	}
+
+	plan, err := s.repo.GetPlan(ctx, planID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	if plan.ServiceID != serviceID {
+		return nil, fmt.Errorf("failed to get plan: %w", models.ErrPlanNotFound)
+	}
+
+	return s.toPlanResponse(plan), nil
+}
+
+// UpdatePlan replaces the name and the list prices of the plan. Prices of
+// subscriptions to the plan do not change.
+func (s *serviceCatalogService) UpdatePlan(
+	ctx context.Context,
+	serviceID, planID uuid.UUID,
+	req *models.ServicePlanRequest,
+) (*models.ServicePlanResponse, error) {
+	if serviceID == uuid.Nil || planID == uuid.Nil {
+		return nil, errors.New("service ID and plan ID are required")
+	}
+
+	plan, err := s.newPlan(req)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err = s.checkService(ctx, serviceID); err != nil {
+		return nil, err
+	}
+
+	plan.ID = planID
+	plan.ServiceID = serviceID
+
+	if err = s.repo.UpdatePlan(ctx, plan); err != nil {
+		return nil, fmt.Errorf("failed to update plan: %w", err)
+	}
+
+	return s.toPlanResponse(plan), nil
+}
+
+// DeletePlan removes the plan unless subscriptions are linked to it.
+func (s *serviceCatalogService) DeletePlan(ctx context.Context, serviceID, planID uuid.UUID) error {
+	if serviceID == uuid.Nil || planID == uuid.Nil {
+		return errors.New("service ID and plan ID are required")
+	}
+
+	if err := s.checkService(ctx, serviceID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeletePlan(ctx, serviceID, planID); err != nil {
+		return fmt.Errorf("failed to delete plan: %w", err)
+	}
+
+	return nil
+}
+
+func (s *serviceCatalogService) ListPlans(ctx context.Context, serviceID uuid.UUID) ([]*models.ServicePlanResponse, error) {
+	if serviceID == uuid.Nil {
+		return nil, errors.New("service ID is required")
+	}
+
+	if err := s.checkService(ctx, serviceID); err != nil {
+		return nil, err
+	}
+
+	plans, err := s.repo.ListPlans(ctx, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plans: %w", err)
+	}
+
+	responses := make([]*models.ServicePlanResponse, len(plans))
+	for i, plan := range plans {
+		responses[i] = s.toPlanResponse(plan)
+	}
+
+	return responses, nil
+}
+
+// checkService returns models.ErrServiceNotFound unless the service exists, so
+// that the plans of a missing service are not reported as missing plans.
+func (s *serviceCatalogService) checkService(ctx context.Context, serviceID uuid.UUID) error {
+	if _, err := s.repo.GetByID(ctx, serviceID); err != nil {
+		return fmt.Errorf("failed to get service: %w", err)
+	}
+
+	return nil
+}
+
+// newPlan validates the request and builds the plan it describes. Every
+// currency and billing period pair may have one price.
+func (s *serviceCatalogService) newPlan(req *models.ServicePlanRequest) (*models.ServicePlan, error) {
+	plan := &models.ServicePlan{
+		Name:   strings.TrimSpace(req.Name),
+		Prices: make([]*models.PlanPrice, 0, len(req.Prices)),
+	}
+
+	if plan.Name == "" {
+		return nil, errors.New("plan name is required")
+	}
+
+	if len(plan.Name) > 255 {
+		return nil, errors.New("plan name too long")
+	}
+
+	for _, price := range req.Prices {
+		listPrice := &models.PlanPrice{
+			BillingPeriod: models.DefaultBillingPeriod,
+			Price:         models.NewMoney(price.Price.Amount, models.DefaultCurrency),
+		}
+
+		if price.Currency != "" {
+			currency, err := models.NormalizeCurrency(price.Currency)
+			if err != nil {
+				return nil, err
+			}
+
+			listPrice.Price.Currency = currency
+		}
+
+		if price.BillingPeriod != "" {
+			listPrice.BillingPeriod = models.BillingPeriod(price.BillingPeriod)
+		}
+
+		if !listPrice.BillingPeriod.IsValid() {
+			return nil, models.ErrInvalidBillingPeriod
+		}
+
+		if !listPrice.Price.IsPositive() {
+			return nil, errors.New("price must be positive")
+		}
+
+		if _, ok := plan.ListPrice(listPrice.Price.Currency, listPrice.BillingPeriod); ok {
+			return nil, fmt.Errorf("duplicate %s price in %s", listPrice.BillingPeriod, listPrice.Price.Currency)
+		}
+
+		plan.Prices = append(plan.Prices, listPrice)
+	}
+
+	return plan, nil
+}
+
+func (s *serviceCatalogService) toPlanResponse(plan *models.ServicePlan) *models.ServicePlanResponse {
+	response := &models.ServicePlanResponse{
+		ID:        plan.ID,
+		ServiceID: plan.ServiceID,
+		Name:      plan.Name,
+		Prices:    make([]*models.PlanPriceResponse, len(plan.Prices)),
+		CreatedAt: plan.CreatedAt,
+		UpdatedAt: plan.UpdatedAt,
+	}
+
+	for i, price := range plan.Prices {
+		response.Prices[i] = &models.PlanPriceResponse{
+			Currency:      price.Price.Currency,
+			BillingPeriod: price.BillingPeriod,
+			Price:         price.Price,
+		}
+	}
+
+	return response
+}
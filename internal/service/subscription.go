@@ -1,9 +1,14 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/vnchk1/subscription-aggregator/internal/models"
@@ -12,48 +17,217 @@ import (
 )
 
 func (s *subscriptionService) CreateSubscription(ctx context.Context, req *models.CreateSubscriptionRequest) (*models.SubscriptionResponse, error) {
+	subscription, err := s.newSubscription(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.Create(ctx, subscription); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, fmt.Errorf("validation failed: %w", models.ErrUserNotFound)
+		}
+
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	return s.toResponse(ctx, subscription), nil
+}
+
+// newSubscription validates the request and builds the subscription it
+// creates, linked to its catalog service and plan when there are ones.
+func (s *subscriptionService) newSubscription(
+	ctx context.Context,
+	req *models.CreateSubscriptionRequest,
+) (*models.Subscription, error) {
 	if err := s.validateCreateRequest(req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
-	startDate, err := time.Parse("01-2006", req.StartDate)
+	startDate, err := models.ParseRangeStart(req.StartDate)
 	if err != nil {
 		return nil, fmt.Errorf("invalid start date format: %w", err)
 	}
 
+	var endDate *time.Time
+
+	if req.EndDate != nil {
+		parsed, err := models.ParseRangeEnd(*req.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end date format: %w", err)
+		}
+
+		endDate = &parsed
+	}
+
+	serviceID := req.ServiceID
+
+	var plan *models.ServicePlan
+
+	if req.PlanID != nil {
+		if plan, err = s.getPlan(ctx, *req.PlanID); err != nil {
+			return nil, err
+		}
+
+		if serviceID != nil && *serviceID != plan.ServiceID {
+			return nil, errors.New("validation failed: plan does not belong to the service")
+		}
+
+		serviceID = &plan.ServiceID
+	}
+
+	service, err := s.resolveService(ctx, serviceID, req.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
 	subscription := &models.Subscription{
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		UserID:      req.UserID,
-		StartDate:   startDate,
-		EndDate:     nil,
+		ServiceName:     req.ServiceName,
+		Price:           models.NewMoney(req.Price.Amount, models.DefaultCurrency),
+		BillingPeriod:   models.DefaultBillingPeriod,
+		BillingInterval: 1,
+		UserID:          req.UserID,
+		StartDate:       startDate,
+		EndDate:         endDate,
+	}
+
+	if service != nil {
+		subscription.ServiceID = &service.ID
+		subscription.ServiceName = service.Name
+		subscription.Price.Currency = service.DefaultCurrency
+	}
+
+	if plan != nil {
+		subscription.PlanID = &plan.ID
+	}
+
+	if req.Currency != "" {
+		if subscription.Price.Currency, err = models.NormalizeCurrency(req.Currency); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	if req.BillingPeriod != "" {
+		subscription.BillingPeriod = models.BillingPeriod(req.BillingPeriod)
+	}
+
+	if req.BillingInterval != 0 {
+		subscription.BillingInterval = req.BillingInterval
+	}
+
+	if subscription.Category, err = normalizeCategory(req.Category); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if subscription.Tags, err = normalizeTags(req.Tags); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// Без цены подписка стоит столько, сколько тариф по прейскуранту
+	if plan != nil && req.Price.Amount == 0 && subscription.BillingPeriod.IsValid() {
+		if subscription.Price, err = planPrice(plan, subscription); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
 	}
 
 	if err = s.validateSubscription(subscription); err != nil {
 		return nil, err
 	}
 
-	if err = s.repo.Create(ctx, subscription); err != nil {
-		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	return subscription, nil
+}
+
+// getPlan returns the plan; an unknown plan fails validation.
+func (s *subscriptionService) getPlan(ctx context.Context, id uuid.UUID) (*models.ServicePlan, error) {
+	plan, err := s.services.GetPlan(ctx, id)
+	if errors.Is(err, models.ErrPlanNotFound) {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan: %w", err)
 	}
 
-	return s.toResponse(subscription), nil
+	return plan, nil
 }
 
-func (s *subscriptionService) GetSubscription(ctx context.Context, id uuid.UUID) (*models.SubscriptionResponse, error) {
+// planPrice returns the list price of the plan for the currency and billing
+// period of the subscription times its billing interval.
+func planPrice(plan *models.ServicePlan, sub *models.Subscription) (models.Money, error) {
+	price, ok := plan.ListPrice(sub.Price.Currency, sub.BillingPeriod)
+	if !ok {
+		return models.Money{}, fmt.Errorf("plan %s has no %s list price in %s", plan.Name, sub.BillingPeriod, sub.Price.Currency)
+	}
+
+	// Неположительный интервал отклоняет validateSubscription
+	if sub.BillingInterval < 1 {
+		return price, nil
+	}
+
+	if price.Amount > math.MaxInt64/int64(sub.BillingInterval) {
+		return models.Money{}, models.ErrAmountOverflow
+	}
+
+	return models.NewMoney(price.Amount*int64(sub.BillingInterval), price.Currency), nil
+}
+
+// resolveService returns the catalog service serviceID or, without it, the
+// service known by the name. It returns nil when no service has the name.
+func (s *subscriptionService) resolveService(
+	ctx context.Context,
+	serviceID *uuid.UUID,
+	name string,
+) (*models.Service, error) {
+	if serviceID != nil {
+		service, err := s.services.GetByID(ctx, *serviceID)
+		if errors.Is(err, models.ErrServiceNotFound) {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service: %w", err)
+		}
+
+		return service, nil
+	}
+
+	service, err := s.services.FindByName(ctx, name)
+	if errors.Is(err, models.ErrServiceNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find service: %w", err)
+	}
+
+	return service, nil
+}
+
+func (s *subscriptionService) GetSubscription(
+	ctx context.Context,
+	id uuid.UUID,
+	includeDeleted bool,
+) (*models.SubscriptionResponse, error) {
 	if id == uuid.Nil {
 		return nil, errors.New("subscription ID is required")
 	}
 
-	subscription, err := s.repo.GetByID(ctx, id)
+	subscription, err := s.repo.GetByID(ctx, id, includeDeleted)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get subscription: %w", err)
 	}
 
-	return s.toResponse(subscription), nil
+	return s.toResponse(ctx, subscription), nil
 }
 
-func (s *subscriptionService) UpdateSubscription(ctx context.Context, id uuid.UUID, req *models.UpdateSubscriptionRequest) (*models.SubscriptionResponse, error) {
+// UpdateSubscription replaces the subscription. With expectedVersion set it
+// fails with models.ErrPreconditionFailed unless the subscription still has
+// that version.
+func (s *subscriptionService) UpdateSubscription(
+	ctx context.Context,
+	id uuid.UUID,
+	req *models.UpdateSubscriptionRequest,
+	expectedVersion *int,
+) (*models.SubscriptionResponse, error) {
 	if id == uuid.Nil {
 		return nil, errors.New("subscription ID is required")
 	}
@@ -62,12 +236,57 @@ func (s *subscriptionService) UpdateSubscription(ctx context.Context, id uuid.UU
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
-	existing, err := s.repo.GetByID(ctx, id)
+	existing, err := s.repo.GetByID(ctx, id, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get existing subscription: %w", err)
 	}
 
-	startDate, err := time.Parse("01-2006", req.StartDate)
+	return s.applyUpdate(ctx, existing, req, expectedVersion)
+}
+
+// PatchSubscription applies an RFC 7396 merge patch to the subscription as
+// represented by UpdateSubscriptionRequest: members of the patch replace the
+// current values and null removes them, so "end_date": null ends nothing.
+func (s *subscriptionService) PatchSubscription(
+	ctx context.Context,
+	id uuid.UUID,
+	patch []byte,
+	expectedVersion *int,
+) (*models.SubscriptionResponse, error) {
+	if id == uuid.Nil {
+		return nil, errors.New("subscription ID is required")
+	}
+
+	existing, err := s.repo.GetByID(ctx, id, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing subscription: %w", err)
+	}
+
+	req, err := mergePatchRequest(existing, patch)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err := s.validateUpdateRequest(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return s.applyUpdate(ctx, existing, req, expectedVersion)
+}
+
+// applyUpdate replaces the fields of existing with the request and saves it
+// unless the subscription was changed since existing was read.
+func (s *subscriptionService) applyUpdate(
+	ctx context.Context,
+	existing *models.Subscription,
+	req *models.UpdateSubscriptionRequest,
+	expectedVersion *int,
+) (*models.SubscriptionResponse, error) {
+	if expectedVersion != nil && *expectedVersion != existing.Version {
+		return nil, models.ErrPreconditionFailed
+	}
+
+	startDate, err := models.ParseRangeStart(req.StartDate)
 	if err != nil {
 		return nil, fmt.Errorf("invalid start date format: %w", err)
 	}
@@ -75,7 +294,7 @@ func (s *subscriptionService) UpdateSubscription(ctx context.Context, id uuid.UU
 	var endDate *time.Time
 
 	if req.EndDate != nil {
-		parsed, err := time.Parse("01-2006", *req.EndDate)
+		parsed, err := models.ParseRangeEnd(*req.EndDate)
 		if err != nil {
 			return nil, fmt.Errorf("invalid end date format: %w", err)
 		}
@@ -83,63 +302,568 @@ func (s *subscriptionService) UpdateSubscription(ctx context.Context, id uuid.UU
 		endDate = &parsed
 	}
 
-	existing.ServiceName = req.ServiceName
-	existing.Price = req.Price
+	// Валюта не меняется, если клиент ее не передал
+	currency := existing.Price.Currency
+
+	if req.Currency != "" {
+		currency, err = models.NormalizeCurrency(req.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	// Подписка остается привязанной к сервису, пока не меняется название
+	if req.ServiceID != nil || req.ServiceName != existing.ServiceName {
+		service, err := s.resolveService(ctx, req.ServiceID, req.ServiceName)
+		if err != nil {
+			return nil, err
+		}
+
+		// Тариф другого сервиса к подписке больше не относится
+		if service == nil || existing.ServiceID == nil || service.ID != *existing.ServiceID {
+			existing.PlanID = nil
+		}
+
+		existing.ServiceID = nil
+		existing.ServiceName = req.ServiceName
+
+		if service != nil {
+			existing.ServiceID = &service.ID
+			existing.ServiceName = service.Name
+		}
+	}
+
+	previousPrice := existing.Price
+	existing.Price = models.NewMoney(req.Price.Amount, currency)
+
+	if req.BillingPeriod != "" {
+		existing.BillingPeriod = models.BillingPeriod(req.BillingPeriod)
+	}
+
+	if req.BillingInterval != 0 {
+		existing.BillingInterval = req.BillingInterval
+	}
+
+	// Метки не меняются, если клиент их не передал
+	if req.Category != nil {
+		if existing.Category, err = normalizeCategory(*req.Category); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	if req.Tags != nil {
+		if existing.Tags, err = normalizeTags(req.Tags); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
 	existing.StartDate = startDate
 	existing.EndDate = endDate
 
+	if req.PriceEffectiveFrom != "" {
+		priceFrom, err := models.ParseRangeStart(req.PriceEffectiveFrom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price effective date format: %w", err)
+		}
+
+		if endDate != nil && priceFrom.After(*endDate) {
+			return nil, errors.New("validation failed: price effective date cannot be after end date")
+		}
+
+		// Цена, действующая с начала подписки, заменяет всю историю
+		if priceFrom.After(startDate) {
+			existing.PriceEffectiveFrom = &priceFrom
+		}
+	} else if existing.Price != previousPrice {
+		// Новая цена без даты действует с текущего месяца пользователя, прошлые цены не меняются
+		now, err := s.userNow(ctx, existing.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		priceFrom := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+		if priceFrom.After(startDate) {
+			existing.PriceEffectiveFrom = &priceFrom
+		}
+	}
+
 	if err := s.validateSubscription(existing); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
 	if err := s.repo.Update(ctx, existing); err != nil {
-		return nil, fmt.Errorf("failed to update subscription: %w", err)
+		return nil, fmt.Errorf("failed to update subscription: %w", preconditionError(err, expectedVersion))
+	}
+
+	return s.toResponse(ctx, existing), nil
+}
+
+func (s *subscriptionService) GetSubscriptionPrices(ctx context.Context, id uuid.UUID) (*models.SubscriptionPricesResponse, error) {
+	if id == uuid.Nil {
+		return nil, errors.New("subscription ID is required")
+	}
+
+	prices, err := s.repo.ListPrices(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription prices: %w", err)
+	}
+
+	layout := models.DateFormatFromContext(ctx).Layout()
+
+	response := &models.SubscriptionPricesResponse{
+		SubscriptionID: id,
+		Prices:         make([]*models.SubscriptionPriceResponse, len(prices)),
 	}
 
-	return s.toResponse(existing), nil
+	for i, price := range prices {
+		response.Prices[i] = &models.SubscriptionPriceResponse{
+			EffectiveFrom: price.EffectiveFrom.Format(layout),
+			Price:         price.Price,
+			Currency:      price.Price.Currency,
+		}
+
+		// Цена действует до дня перед следующей ценой
+		if i+1 < len(prices) {
+			response.Prices[i].EffectiveTo = prices[i+1].EffectiveFrom.AddDate(0, 0, -1).Format(layout)
+		}
+	}
+
+	return response, nil
 }
 
-func (s *subscriptionService) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+func (s *subscriptionService) GetSubscriptionHistory(ctx context.Context, id uuid.UUID) (*models.SubscriptionHistoryResponse, error) {
+	if id == uuid.Nil {
+		return nil, errors.New("subscription ID is required")
+	}
+
+	events, err := s.repo.ListEvents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription history: %w", err)
+	}
+
+	response := &models.SubscriptionHistoryResponse{
+		SubscriptionID: id,
+		Events:         make([]*models.SubscriptionEventResponse, len(events)),
+	}
+
+	for i, event := range events {
+		response.Events[i] = &models.SubscriptionEventResponse{
+			ID:        event.ID,
+			Action:    event.Action,
+			Before:    event.Before,
+			After:     event.After,
+			Actor:     event.Actor,
+			RequestID: event.RequestID,
+			CreatedAt: event.CreatedAt,
+		}
+
+		if event.Action == models.SubscriptionEventUpdated {
+			response.Events[i].ChangedFields = changedFields(event.Before, event.After)
+		}
+	}
+
+	return response, nil
+}
+
+// untrackedFields are snapshot fields that change on every update and so
+// never count as changed.
+var untrackedFields = map[string]bool{
+	"updated_at": true,
+	"version":    true,
+}
+
+// changedFields returns the sorted names of the fields that differ between
+// two JSON objects, ignoring updated_at and version which change on every
+// update.
+func changedFields(before, after json.RawMessage) []string {
+	var old, updated map[string]json.RawMessage
+
+	if json.Unmarshal(before, &old) != nil || json.Unmarshal(after, &updated) != nil {
+		return nil
+	}
+
+	var fields []string
+
+	for field, value := range updated {
+		if !untrackedFields[field] && !bytes.Equal(old[field], value) {
+			fields = append(fields, field)
+		}
+	}
+
+	for field := range old {
+		if _, ok := updated[field]; !ok && !untrackedFields[field] {
+			fields = append(fields, field)
+		}
+	}
+
+	slices.Sort(fields)
+
+	return fields
+}
+
+// DeleteSubscription deletes the subscription. With expectedVersion set it
+// fails with models.ErrPreconditionFailed unless the subscription still has
+// that version.
+func (s *subscriptionService) DeleteSubscription(ctx context.Context, id uuid.UUID, expectedVersion *int) error {
 	if id == uuid.Nil {
 		return errors.New("subscription ID is required")
 	}
 
-	if err := s.repo.Delete(ctx, id); err != nil {
-		return fmt.Errorf("failed to delete subscription: %w", err)
+	if err := s.repo.Delete(ctx, id, expectedVersion); err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", preconditionError(err, expectedVersion))
 	}
 
 	return nil
 }
 
-func (s *subscriptionService) ListSubscriptions(ctx context.Context, userID *uuid.UUID, page, limit int) (*models.ListResponse, error) {
+// preconditionError reports a version conflict as a failed If-Match when the
+// client asked for a version.
+func preconditionError(err error, expectedVersion *int) error {
+	if expectedVersion != nil && errors.Is(err, models.ErrVersionConflict) {
+		return models.ErrPreconditionFailed
+	}
+
+	return err
+}
+
+func (s *subscriptionService) RestoreSubscription(ctx context.Context, id uuid.UUID) (*models.SubscriptionResponse, error) {
+	if id == uuid.Nil {
+		return nil, errors.New("subscription ID is required")
+	}
+
+	if err := s.repo.Restore(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to restore subscription: %w", err)
+	}
+
+	subscription, err := s.repo.GetByID(ctx, id, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return s.toResponse(ctx, subscription), nil
+}
+
+// PurgeDeletedSubscriptions permanently removes the subscriptions deleted
+// more than olderThanDays days ago and returns how many were removed.
+func (s *subscriptionService) PurgeDeletedSubscriptions(ctx context.Context, olderThanDays int) (int, error) {
+	if olderThanDays < 0 {
+		return 0, errors.New("validation failed: days cannot be negative")
+	}
+
+	count, err := s.repo.Purge(ctx, time.Now().AddDate(0, 0, -olderThanDays))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge subscriptions: %w", err)
+	}
+
+	return count, nil
+}
+
+func (s *subscriptionService) ListSubscriptions(ctx context.Context, req *models.ListSubscriptionsRequest) (*models.ListResponse, error) {
+	filter, err := s.listFilter(req)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	page := req.Page
 	if page < 1 {
 		page = 1
 	}
 
+	limit := req.Limit
 	if limit < 1 || limit > 100 {
 		limit = 20
 	}
 
 	offset := (page - 1) * limit
 
-	subscriptions, total, err := s.repo.List(ctx, userID, limit, offset)
+	var after *models.ListCursor
+
+	if req.Cursor != "" {
+		if len(filter.Sort) > 0 {
+			return nil, errors.New("validation failed: cursor cannot be combined with sort")
+		}
+
+		after, err = models.DecodeListCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		page = 0
+		offset = 0
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	subscriptions, total, err := s.repo.List(ctx, filter, limit+1, offset, after)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
 	}
 
+	hasMore := len(subscriptions) > limit
+	if hasMore {
+		subscriptions = subscriptions[:limit]
+	}
+
 	responseData := make([]*models.SubscriptionResponse, len(subscriptions))
 	for i, sub := range subscriptions {
-		responseData[i] = s.toResponse(sub)
+		responseData[i] = s.toResponse(ctx, sub)
+	}
+
+	response := &models.ListResponse{
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+		HasMore: hasMore,
+		Data:    responseData,
+	}
+
+	// Курсор кодирует позицию только в порядке по умолчанию
+	if hasMore && len(filter.Sort) == 0 {
+		response.NextCursor = models.NewListCursor(subscriptions[len(subscriptions)-1]).Encode()
 	}
 
-	return &models.ListResponse{
-		Total: total,
-		Data:  responseData,
-	}, nil
+	return response, nil
 }
 
 func (s *subscriptionService) CalculateTotalCost(ctx context.Context, req *models.TotalCostRequest) (*models.TotalCostResponse, error) {
+	filter, err := s.totalCostFilter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	groupBy, err := parseGroupBy(req.GroupBy)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
 
+	// Курсы проверяются до отчета: без них отчет молча пропустил бы начисления
+	exchangeRates, err := s.appliedExchangeRates(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.TotalCostResponse{
+		TotalCost:     models.NewMoney(0, filter.TargetCurrency),
+		Currency:      filter.TargetCurrency,
+		Period:        fmt.Sprintf("%s - %s", req.StartPeriod, req.EndPeriod),
+		Basis:         filter.Basis,
+		Proration:     filter.Proration,
+		ExchangeRates: exchangeRates,
+	}
+
+	if len(groupBy) == 0 {
+		totalCost, err := s.repo.GetTotalCost(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate total cost: %w", err)
+		}
+
+		response.TotalCost = models.NewMoney(totalCost, filter.TargetCurrency)
+
+		return response, nil
+	}
+
+	groups, totalCost, err := s.repo.GetGroupedCost(ctx, filter, groupBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate total cost: %w", err)
+	}
+
+	// Итог округляется один раз и совпадает с отчетом без группировки
+	response.TotalCost = models.NewMoney(totalCost, filter.TargetCurrency)
+	response.Groups = make([]*models.CostGroupResponse, len(groups))
+
+	for i, group := range groups {
+		response.Groups[i] = &models.CostGroupResponse{
+			ServiceName: group.ServiceName,
+			UserID:      group.UserID,
+			Category:    group.Category,
+			Amount:      models.NewMoney(group.Amount, filter.TargetCurrency),
+		}
+
+		if group.Month != nil {
+			month := group.Month.Format("01-2006")
+			response.Groups[i].Month = &month
+		}
+	}
+
+	return response, nil
+}
+
+func (s *subscriptionService) CalculateTotalCostBreakdown(
+	ctx context.Context,
+	req *models.TotalCostRequest,
+) (*models.TotalCostBreakdownResponse, error) {
+	filter, err := s.totalCostFilter(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeRates, err := s.appliedExchangeRates(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	costs, totalCost, err := s.repo.GetMonthlyCost(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate monthly cost: %w", err)
+	}
+
+	response := &models.TotalCostBreakdownResponse{
+		TotalCost:     models.NewMoney(totalCost, filter.TargetCurrency),
+		Currency:      filter.TargetCurrency,
+		Period:        fmt.Sprintf("%s - %s", req.StartPeriod, req.EndPeriod),
+		Basis:         filter.Basis,
+		Proration:     filter.Proration,
+		Months:        []*models.MonthCostResponse{},
+		ExchangeRates: exchangeRates,
+	}
+
+	var current *models.MonthCostResponse
+
+	// Строки отсортированы по месяцу, поэтому собираем месяцы за один проход
+	for _, cost := range costs {
+		month := cost.Month.Format("01-2006")
+
+		if current == nil || current.Month != month {
+			current = &models.MonthCostResponse{
+				Month:    month,
+				Amount:   models.NewMoney(0, filter.TargetCurrency),
+				Services: []*models.ServiceCostResponse{},
+			}
+			response.Months = append(response.Months, current)
+		}
+
+		if cost.Subscriptions == 0 {
+			continue
+		}
+
+		amount := models.NewMoney(cost.Amount, filter.TargetCurrency)
+
+		if current.Amount, err = current.Amount.Add(amount); err != nil {
+			return nil, fmt.Errorf("failed to calculate monthly cost: %w", err)
+		}
+
+		current.ActiveSubscriptions += cost.Subscriptions
+		current.Services = append(current.Services, &models.ServiceCostResponse{
+			ServiceName:   cost.ServiceName,
+			Amount:        amount,
+			Subscriptions: cost.Subscriptions,
+		})
+	}
+
+	return response, nil
+}
+
+// getUser returns the user a request refers to; an unknown user fails
+// validation.
+func (s *subscriptionService) getUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user, err := s.users.GetByID(ctx, id)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// userNow returns the current time in the time zone of the user, so that
+// "this month" starts at the user's midnight rather than the server's.
+func (s *subscriptionService) userNow(ctx context.Context, id uuid.UUID) (time.Time, error) {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	location, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	return time.Now().In(location), nil
+}
+
+// appliedExchangeRates reports the rates a cost report converts charges with.
+// It fails with models.ErrMissingExchangeRate when a charge of the period has
+// no rate, so reports call it before summing.
+func (s *subscriptionService) appliedExchangeRates(
+	ctx context.Context,
+	filter *models.SubscriptionFilter,
+) ([]*models.AppliedExchangeRateResponse, error) {
+	rates, err := s.repo.GetAppliedExchangeRates(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+
+	if len(rates) == 0 {
+		return nil, nil
+	}
+
+	response := make([]*models.AppliedExchangeRateResponse, len(rates))
+	for i, rate := range rates {
+		response[i] = &models.AppliedExchangeRateResponse{
+			Currency: rate.Currency,
+			Month:    rate.Month.Format("01-2006"),
+			RateDate: rate.RateDate.Format(time.DateOnly),
+			Rate:     rate.Rate,
+		}
+	}
+
+	return response, nil
+}
+
+// ComparePlanPrices reports how the price of every active or future
+// subscription with a plan differs from the current list price of the plan.
+func (s *subscriptionService) ComparePlanPrices(
+	ctx context.Context,
+	req *models.PlanPriceReportRequest,
+) (*models.PlanPriceReportResponse, error) {
+	comparisons, err := s.repo.ComparePlanPrices(ctx, &models.PlanPriceFilter{
+		UserID:    req.UserID,
+		ServiceID: req.ServiceID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare plan prices: %w", err)
+	}
+
+	response := &models.PlanPriceReportResponse{
+		Subscriptions: make([]*models.PlanPriceComparisonResponse, len(comparisons)),
+	}
+
+	for i, comparison := range comparisons {
+		item := &models.PlanPriceComparisonResponse{
+			SubscriptionID:  comparison.SubscriptionID,
+			UserID:          comparison.UserID,
+			ServiceName:     comparison.ServiceName,
+			PlanID:          comparison.PlanID,
+			PlanName:        comparison.PlanName,
+			BillingPeriod:   comparison.BillingPeriod,
+			BillingInterval: comparison.BillingInterval,
+			Currency:        comparison.Price.Currency,
+			Price:           comparison.Price,
+			ListPrice:       comparison.ListPrice,
+		}
+
+		if comparison.ListPrice != nil {
+			difference, err := comparison.Price.Add(models.NewMoney(-comparison.ListPrice.Amount, comparison.ListPrice.Currency))
+			if err != nil {
+				return nil, fmt.Errorf("failed to compare plan prices: %w", err)
+			}
+
+			item.Difference = &difference
+		}
+
+		response.Subscriptions[i] = item
+	}
+
+	return response, nil
+}
+
+func (s *subscriptionService) totalCostFilter(
+	ctx context.Context,
+	req *models.TotalCostRequest,
+) (*models.SubscriptionFilter, error) {
 	if err := s.validateTotalCostRequest(req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
@@ -153,27 +877,281 @@ func (s *subscriptionService) CalculateTotalCost(ctx context.Context, req *model
 		return nil, errors.New("end period cannot be before start period")
 	}
 
+	targetCurrency := models.DefaultCurrency
+
+	switch {
+	case req.TargetCurrency != "":
+		if targetCurrency, err = models.NormalizeCurrency(req.TargetCurrency); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	case req.UserID != nil:
+		// Отчет по одному пользователю по умолчанию в его валюте
+		user, err := s.getUser(ctx, *req.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		targetCurrency = user.DefaultCurrency
+	}
+
+	basis := models.CostBasisCharges
+
+	switch models.CostBasis(req.Basis) {
+	case "", models.CostBasisCharges:
+	case models.CostBasisMonthly:
+		basis = models.CostBasisMonthly
+	default:
+		return nil, fmt.Errorf("invalid basis: %s", req.Basis)
+	}
+
+	proration := models.Proration(req.Proration)
+	if proration != models.ProrationNone && proration != models.ProrationDaily {
+		return nil, fmt.Errorf("invalid proration: %s", req.Proration)
+	}
+
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
 	filter := &models.SubscriptionFilter{
-		UserID:      req.UserID,
-		ServiceName: req.ServiceName,
-		StartDate:   startDate,
-		EndDate:     endDate,
+		UserID:         req.UserID,
+		ServiceID:      req.ServiceID,
+		ServiceName:    req.ServiceName,
+		Tags:           tags,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		TargetCurrency: targetCurrency,
+		Basis:          basis,
+		Proration:      proration,
+		IncludeDeleted: req.IncludeDeleted,
 	}
 
-	totalCost, err := s.repo.GetTotalCost(ctx, filter)
+	if req.Category != nil {
+		category, err := normalizeCategory(*req.Category)
+		if err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+
+		filter.Category = &category
+	}
+
+	return filter, nil
+}
+
+// ValidateListRequest checks the list filters without running a query, so
+// callers can reject a bad request before starting any side effects.
+func (s *subscriptionService) ValidateListRequest(req *models.ListSubscriptionsRequest) error {
+	if _, err := s.listFilter(req); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *subscriptionService) listFilter(req *models.ListSubscriptionsRequest) (*models.SubscriptionListFilter, error) {
+	filter := &models.SubscriptionListFilter{
+		UserID:         req.UserID,
+		ServiceID:      req.ServiceID,
+		IncludeDeleted: req.IncludeDeleted,
+	}
+
+	if req.ServiceName != "" {
+		filter.ServiceName = &req.ServiceName
+	}
+
+	if req.ServiceNameSearch != "" {
+		filter.ServiceNamePrefix = &req.ServiceNameSearch
+	}
+
+	if req.Category != "" {
+		category, err := normalizeCategory(req.Category)
+		if err != nil {
+			return nil, err
+		}
+
+		filter.Category = &category
+	}
+
+	tags, err := normalizeTags(req.Tags)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate total cost: %w", err)
+		return nil, err
+	}
+
+	if len(tags) > 0 {
+		filter.Tags = tags
+	}
+
+	prices := []struct {
+		value string
+		dest  **int64
+		name  string
+	}{
+		{req.MinPrice, &filter.MinPrice, "min_price"},
+		{req.MaxPrice, &filter.MaxPrice, "max_price"},
+	}
+
+	for _, price := range prices {
+		if price.value == "" {
+			continue
+		}
+
+		parsed, err := models.ParseMoney(price.value, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", price.name, err)
+		}
+
+		*price.dest = &parsed.Amount
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return nil, errors.New("min price cannot be greater than max price")
+	}
+
+	dates := []struct {
+		value string
+		dest  **time.Time
+		name  string
+	}{
+		{req.ActiveOn, &filter.ActiveOn, "active_on"},
+		{req.StartFrom, &filter.StartFrom, "start_from"},
+		{req.StartTo, &filter.StartTo, "start_to"},
+	}
+
+	for _, date := range dates {
+		if date.value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse("01-2006", date.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s date format: %w", date.name, err)
+		}
+
+		*date.dest = &parsed
+	}
+
+	if filter.StartFrom != nil && filter.StartTo != nil && filter.StartTo.Before(*filter.StartFrom) {
+		return nil, errors.New("start_to cannot be before start_from")
 	}
 
-	return &models.TotalCostResponse{
-		TotalCost: totalCost,
-		Currency:  "RUB",
-		Period:    fmt.Sprintf("%s - %s", req.StartPeriod, req.EndPeriod),
-	}, nil
+	if req.Status != "" {
+		status := models.SubscriptionStatus(req.Status)
+
+		switch status {
+		case models.SubscriptionStatusActive, models.SubscriptionStatusEnded, models.SubscriptionStatusFuture:
+			filter.Status = &status
+		default:
+			return nil, fmt.Errorf("invalid status: %s", req.Status)
+		}
+	}
+
+	filter.Sort, err = parseSort(req.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// parseSort parses a comma-separated list of sort keys where a leading "-"
+// means descending. Unknown field names are rejected before any query runs.
+func parseSort(sort string) ([]models.SortField, error) {
+	var fields []models.SortField
+
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := models.SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if !models.SubscriptionSortFields[field.Field] {
+			return nil, fmt.Errorf("invalid sort field: %s", field.Field)
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// parseGroupBy parses a comma-separated list of cost dimensions, skipping duplicates.
+func parseGroupBy(groupBy string) ([]models.CostDimension, error) {
+	var dimensions []models.CostDimension
+
+	for _, part := range strings.Split(groupBy, ",") {
+		dimension := models.CostDimension(strings.TrimSpace(part))
+
+		switch dimension {
+		case "":
+			continue
+		case models.CostDimensionServiceName, models.CostDimensionUserID, models.CostDimensionMonth, models.CostDimensionCategory:
+		default:
+			return nil, fmt.Errorf("invalid group_by dimension: %s", dimension)
+		}
+
+		if !slices.Contains(dimensions, dimension) {
+			dimensions = append(dimensions, dimension)
+		}
+	}
+
+	return dimensions, nil
+}
+
+// maxLabelLength limits a category or a tag; maxTags limits the tags of a subscription.
+const (
+	maxLabelLength = 64
+	maxTags        = 20
+)
+
+// normalizeCategory trims and lower-cases the category, so that "Work" and
+// "work" are the same category.
+func normalizeCategory(category string) (string, error) {
+	category = strings.ToLower(strings.TrimSpace(category))
+
+	if len(category) > maxLabelLength {
+		return "", errors.New("category too long")
+	}
+
+	return category, nil
+}
+
+// normalizeTags trims and lower-cases the tags and returns them sorted and
+// without duplicates; the result is never nil.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			return nil, errors.New("tag cannot be empty")
+		}
+
+		if len(tag) > maxLabelLength {
+			return nil, fmt.Errorf("tag too long: %s", tag)
+		}
+
+		// Запятая разделяет метки в колонке tags импорта и выгрузки
+		if strings.Contains(tag, models.TagSeparator) {
+			return nil, fmt.Errorf("tag cannot contain %q: %s", models.TagSeparator, tag)
+		}
+
+		normalized = append(normalized, tag)
+	}
+
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+
+	if len(normalized) > maxTags {
+		return nil, fmt.Errorf("too many tags: at most %d are allowed", maxTags)
+	}
+
+	return normalized, nil
 }
 
 func (s *subscriptionService) validateCreateRequest(req *models.CreateSubscriptionRequest) error {
-	if req.ServiceName == "" {
+	if req.ServiceName == "" && req.ServiceID == nil && req.PlanID == nil {
 		return errors.New("service name is required")
 	}
 
@@ -181,7 +1159,8 @@ func (s *subscriptionService) validateCreateRequest(req *models.CreateSubscripti
 		return errors.New("service name too long")
 	}
 
-	if req.Price <= 0 {
+	// Цену без тарифа нужно указать явно
+	if !req.Price.IsPositive() && (req.PlanID == nil || req.Price.Amount != 0) {
 		return errors.New("price must be positive")
 	}
 
@@ -197,7 +1176,7 @@ func (s *subscriptionService) validateCreateRequest(req *models.CreateSubscripti
 }
 
 func (s *subscriptionService) validateUpdateRequest(req *models.UpdateSubscriptionRequest) error {
-	if req.ServiceName == "" {
+	if req.ServiceName == "" && req.ServiceID == nil {
 		return errors.New("service name is required")
 	}
 
@@ -205,7 +1184,7 @@ func (s *subscriptionService) validateUpdateRequest(req *models.UpdateSubscripti
 		return errors.New("service name too long")
 	}
 
-	if req.Price <= 0 {
+	if !req.Price.IsPositive() {
 		return errors.New("price must be positive")
 	}
 
@@ -225,7 +1204,7 @@ func (s *subscriptionService) validateSubscription(sub *models.Subscription) err
 		return errors.New("service name too long")
 	}
 
-	if sub.Price <= 0 {
+	if !sub.Price.IsPositive() {
 		return errors.New("price must be positive")
 	}
 
@@ -233,6 +1212,18 @@ func (s *subscriptionService) validateSubscription(sub *models.Subscription) err
 		return errors.New("user ID is required")
 	}
 
+	if _, err := models.NormalizeCurrency(sub.Price.Currency); err != nil {
+		return err
+	}
+
+	if !sub.BillingPeriod.IsValid() {
+		return models.ErrInvalidBillingPeriod
+	}
+
+	if sub.BillingInterval < 1 {
+		return errors.New("billing interval must be positive")
+	}
+
 	if sub.StartDate.IsZero() {
 		return errors.New("start date is required")
 	}
@@ -256,19 +1247,36 @@ func (s *subscriptionService) validateTotalCostRequest(req *models.TotalCostRequ
 	return nil
 }
 
-func (s *subscriptionService) toResponse(sub *models.Subscription) *models.SubscriptionResponse {
+// toResponse renders the dates in the format requested through ctx: legacy
+// MM-YYYY by default, so day-precision dates lose their day.
+func (s *subscriptionService) toResponse(ctx context.Context, sub *models.Subscription) *models.SubscriptionResponse {
+	layout := models.DateFormatFromContext(ctx).Layout()
+
 	response := &models.SubscriptionResponse{
-		ID:          sub.ID,
-		ServiceName: sub.ServiceName,
-		Price:       sub.Price,
-		UserID:      sub.UserID,
-		StartDate:   sub.StartDate.Format("01-2006"),
-		CreatedAt:   sub.CreatedAt,
-		UpdatedAt:   sub.UpdatedAt,
+		ID:              sub.ID,
+		ServiceID:       sub.ServiceID,
+		PlanID:          sub.PlanID,
+		ServiceName:     sub.ServiceName,
+		Price:           sub.Price,
+		Currency:        sub.Price.Currency,
+		BillingPeriod:   sub.BillingPeriod,
+		BillingInterval: sub.BillingInterval,
+		UserID:          sub.UserID,
+		StartDate:       sub.StartDate.Format(layout),
+		CreatedAt:       sub.CreatedAt,
+		UpdatedAt:       sub.UpdatedAt,
+		DeletedAt:       sub.DeletedAt,
+		Version:         sub.Version,
+		Category:        sub.Category,
+		Tags:            sub.Tags,
+	}
+
+	if response.Tags == nil {
+		response.Tags = []string{}
 	}
 
 	if sub.EndDate != nil {
-		endDateStr := sub.EndDate.Format("01-2006")
+		endDateStr := sub.EndDate.Format(layout)
 		response.EndDate = &endDateStr
 	}
 
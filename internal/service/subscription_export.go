@@ -0,0 +1,298 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+)
+
+// exportColumns are the columns of CSV and XLSX exports.
+var exportColumns = []string{
+	"id", "user_id", "service_name", "price", "currency", "billing_period", "billing_interval",
+	"start_date", "end_date", "created_at", "updated_at", "deleted_at", "version",
+	"service_id", "plan_id", "category", "tags",
+}
+
+// subscriptionWriter writes the subscriptions of an export one at a time.
+// Close finishes the file; nothing more can be written after it.
+type subscriptionWriter interface {
+	Write(subscription *models.SubscriptionResponse) error
+	Close() error
+}
+
+// ExportSubscriptions writes every subscription matching the list filters of
+// the request to w in the format. Paging parameters of the request are
+// ignored. Nothing is written to w when the request is invalid.
+func (s *subscriptionService) ExportSubscriptions(
+	ctx context.Context,
+	req *models.ListSubscriptionsRequest,
+	format models.ExportFormat,
+	w io.Writer,
+) error {
+	filter, err := s.listFilter(req)
+	if err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	var writer subscriptionWriter
+
+	switch format {
+	case models.ExportFormatCSV:
+		writer, err = newCSVSubscriptionWriter(w)
+	case models.ExportFormatNDJSON:
+		writer = &ndjsonSubscriptionWriter{encoder: json.NewEncoder(w)}
+	case models.ExportFormatXLSX:
+		writer, err = newXLSXSubscriptionWriter(w)
+	default:
+		return fmt.Errorf("validation failed: invalid export format %q, expected csv, ndjson or xlsx", format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to start export: %w", err)
+	}
+
+	err = s.repo.Export(ctx, filter, func(subscription *models.Subscription) error {
+		return writer.Write(s.toResponse(ctx, subscription))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export subscriptions: %w", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("failed to finish export: %w", err)
+	}
+
+	return nil
+}
+
+// exportRecord returns the exportColumns of the subscription.
+func exportRecord(sub *models.SubscriptionResponse) []string {
+	record := []string{
+		sub.ID.String(),
+		sub.UserID.String(),
+		sub.ServiceName,
+		sub.Price.String(),
+		sub.Currency,
+		string(sub.BillingPeriod),
+		strconv.Itoa(sub.BillingInterval),
+		sub.StartDate,
+		"",
+		sub.CreatedAt.Format(time.RFC3339),
+		sub.UpdatedAt.Format(time.RFC3339),
+		"",
+		strconv.Itoa(sub.Version),
+		"",
+		"",
+		sub.Category,
+		strings.Join(sub.Tags, models.TagSeparator),
+	}
+
+	if sub.EndDate != nil {
+		record[8] = *sub.EndDate
+	}
+
+	if sub.DeletedAt != nil {
+		record[11] = sub.DeletedAt.Format(time.RFC3339)
+	}
+
+	if sub.ServiceID != nil {
+		record[13] = sub.ServiceID.String()
+	}
+
+	if sub.PlanID != nil {
+		record[14] = sub.PlanID.String()
+	}
+
+	return record
+}
+
+type csvSubscriptionWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVSubscriptionWriter(w io.Writer) (*csvSubscriptionWriter, error) {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(exportColumns); err != nil {
+		return nil, err
+	}
+
+	return &csvSubscriptionWriter{writer: writer}, nil
+}
+
+func (w *csvSubscriptionWriter) Write(subscription *models.SubscriptionResponse) error {
+	return w.writer.Write(exportRecord(subscription))
+}
+
+func (w *csvSubscriptionWriter) Close() error {
+	w.writer.Flush()
+
+	return w.writer.Error()
+}
+
+type ndjsonSubscriptionWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonSubscriptionWriter) Write(subscription *models.SubscriptionResponse) error {
+	return w.encoder.Encode(subscription)
+}
+
+func (w *ndjsonSubscriptionWriter) Close() error {
+	return nil
+}
+
+// xlsxMaxRows is the number of rows of an XLSX worksheet, the header included.
+const xlsxMaxRows = 1048576
+
+// xlsxParts are the parts of a workbook with one worksheet, except the
+// worksheet itself, which is streamed.
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{
+		name: "[Content_Types].xml",
+		content: xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ` +
+			`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ` +
+			`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`,
+	},
+	{
+		name: "_rels/.rels",
+		content: xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" ` +
+			`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" ` +
+			`Target="xl/workbook.xml"/>` +
+			`</Relationships>`,
+	},
+	{
+		name: "xl/workbook.xml",
+		content: xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Subscriptions" sheetId="1" r:id="rId1"/></sheets>` +
+			`</workbook>`,
+	},
+	{
+		name: "xl/_rels/workbook.xml.rels",
+		content: xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" ` +
+			`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" ` +
+			`Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`,
+	},
+}
+
+// xlsxNumericColumns are the exportColumns written as numbers rather than text.
+var xlsxNumericColumns = map[string]bool{"price": true, "billing_interval": true, "version": true}
+
+// xlsxSubscriptionWriter writes a minimal Office Open XML workbook. Cells hold
+// inline strings, so the workbook needs no shared string table and its rows
+// can be written as they come.
+type xlsxSubscriptionWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	rows    int
+}
+
+func newXLSXSubscriptionWriter(w io.Writer) (*xlsxSubscriptionWriter, error) {
+	archive := zip.NewWriter(w)
+
+	for _, part := range xlsxParts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = io.WriteString(file, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	// Лист создается последним: запись в архив возможна только в последний созданный файл
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &xlsxSubscriptionWriter{archive: archive, sheet: bufio.NewWriter(sheet)}
+
+	writer.sheet.WriteString(xml.Header +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	if err = writer.writeRow(exportColumns, false); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (w *xlsxSubscriptionWriter) Write(subscription *models.SubscriptionResponse) error {
+	return w.writeRow(exportRecord(subscription), true)
+}
+
+// writeRow writes the cells of the row; with numbers set, the values of the
+// xlsxNumericColumns are written as numbers.
+func (w *xlsxSubscriptionWriter) writeRow(values []string, numbers bool) error {
+	if w.rows == xlsxMaxRows {
+		return fmt.Errorf("xlsx worksheet cannot contain more than %d rows", xlsxMaxRows)
+	}
+
+	w.rows++
+
+	fmt.Fprintf(w.sheet, `<row r="%d">`, w.rows)
+
+	for i, value := range values {
+		cell := xlsxColumnName(i) + strconv.Itoa(w.rows)
+
+		switch {
+		case value == "":
+			continue
+		case numbers && xlsxNumericColumns[exportColumns[i]]:
+			fmt.Fprintf(w.sheet, `<c r="%s"><v>%s</v></c>`, cell, value)
+		default:
+			fmt.Fprintf(w.sheet, `<c r="%s" t="inlineStr"><is><t>`, cell)
+			xml.EscapeText(w.sheet, []byte(value))
+			w.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+
+	_, err := w.sheet.WriteString(`</row>`)
+
+	return err
+}
+
+func (w *xlsxSubscriptionWriter) Close() error {
+	w.sheet.WriteString(`</sheetData></worksheet>`)
+
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return w.archive.Close()
+}
+
+// xlsxColumnName returns the letters of the column at index i: A, B, ..., Z, AA.
+func xlsxColumnName(i int) string {
+	name := ""
+
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+
+	return name
+}
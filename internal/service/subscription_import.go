@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var requiredSubscriptionCSVColumns = []string{"service_name", "price", "user_id", "start_date"}
+
+// importRow is a subscription read from a line of an import file, or the
+// reason it could not be read.
+type importRow struct {
+	line int
+	req  *models.CreateSubscriptionRequest
+	err  error
+}
+
+// ImportSubscriptions validates every row of the CSV file as a create request
+// and, unless dryRun is set, creates the subscriptions of the valid rows.
+// Invalid rows, including rows of users that do not exist, are reported in
+// the response; only a malformed file fails.
+func (s *subscriptionService) ImportSubscriptions(ctx context.Context, r io.Reader, dryRun bool) (*models.ImportResponse, error) {
+	rows, err := parseSubscriptionsCSV(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV file: %w", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("validation failed: no subscriptions found in file")
+	}
+
+	response := &models.ImportResponse{
+		DryRun: dryRun,
+		Rows:   len(rows),
+		Errors: []*models.ImportRowError{},
+	}
+
+	users, err := s.importUsers(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*models.Subscription, 0, len(rows))
+
+	for _, row := range rows {
+		var subscription *models.Subscription
+
+		err := row.err
+		if err == nil && row.req.UserID != uuid.Nil && !users[row.req.UserID] {
+			err = fmt.Errorf("validation failed: %w", models.ErrUserNotFound)
+		}
+
+		if err == nil {
+			subscription, err = s.newSubscription(ctx, row.req)
+		}
+
+		if err != nil {
+			response.Errors = append(response.Errors, &models.ImportRowError{Line: row.line, Error: err.Error()})
+
+			continue
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	response.Valid = len(subscriptions)
+
+	if dryRun || len(subscriptions) == 0 {
+		return response, nil
+	}
+
+	if err = s.repo.CreateMany(ctx, subscriptions); err != nil {
+		// Пользователя удалили после проверки файла
+		if errors.Is(err, models.ErrUserNotFound) {
+			return nil, fmt.Errorf("validation failed: %w", models.ErrUserNotFound)
+		}
+
+		return nil, fmt.Errorf("failed to import subscriptions: %w", err)
+	}
+
+	response.Imported = len(subscriptions)
+
+	return response, nil
+}
+
+// importUsers reports which users referenced by the rows exist. The users of
+// the whole file are looked up with one query.
+func (s *subscriptionService) importUsers(ctx context.Context, rows []*importRow) (map[uuid.UUID]bool, error) {
+	var ids []uuid.UUID
+
+	for _, row := range rows {
+		if row.err == nil && row.req.UserID != uuid.Nil && !slices.Contains(ids, row.req.UserID) {
+			ids = append(ids, row.req.UserID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	users, err := s.users.ExistingIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check users: %w", err)
+	}
+
+	return users, nil
+}
+
+// parseSubscriptionsCSV reads the rows of a file whose header names the
+// models.SubscriptionCSVColumns it contains.
+func parseSubscriptionsCSV(r io.Reader) ([]*importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	// Excel сохраняет CSV с BOM в начале файла
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		name = strings.TrimSpace(strings.ToLower(name))
+
+		if slices.Contains(models.IgnoredSubscriptionCSVColumns, name) {
+			continue
+		}
+
+		if !slices.Contains(models.SubscriptionCSVColumns, name) {
+			return nil, fmt.Errorf("invalid header: unknown column %q", name)
+		}
+
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("invalid header: duplicate column %q", name)
+		}
+
+		columns[name] = i
+	}
+
+	for _, name := range requiredSubscriptionCSVColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("invalid header: column %q is required", name)
+		}
+	}
+
+	var rows []*importRow
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		// Строка с другим числом полей - ошибка строки, а не всего файла
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, err
+		}
+
+		if len(rows) == models.MaxImportRows {
+			return nil, fmt.Errorf("file cannot contain more than %d rows", models.MaxImportRows)
+		}
+
+		row := &importRow{}
+		row.line, _ = reader.FieldPos(0)
+
+		if err != nil {
+			row.err = fmt.Errorf("validation failed: expected %d fields, got %d", len(header), len(record))
+		} else {
+			row.req, row.err = subscriptionRequestFromCSV(record, columns)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func subscriptionRequestFromCSV(record []string, columns map[string]int) (*models.CreateSubscriptionRequest, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+
+		return ""
+	}
+
+	req := &models.CreateSubscriptionRequest{
+		ServiceName:   field("service_name"),
+		Currency:      field("currency"),
+		BillingPeriod: field("billing_period"),
+		StartDate:     field("start_date"),
+		Category:      field("category"),
+	}
+
+	// Даты разбирает newSubscription, как и в POST /subscriptions
+	if value := field("end_date"); value != "" {
+		req.EndDate = &value
+	}
+
+	var err error
+
+	// Пустые обязательные поля отклонит validateCreateRequest
+	if value := field("price"); value != "" {
+		if req.Price, err = models.ParseMoney(value, ""); err != nil {
+			return nil, fmt.Errorf("validation failed: invalid price: %w", err)
+		}
+	}
+
+	if value := field("billing_interval"); value != "" {
+		if req.BillingInterval, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("validation failed: invalid billing interval %q", value)
+		}
+	}
+
+	if value := field("user_id"); value != "" {
+		if req.UserID, err = uuid.Parse(value); err != nil {
+			return nil, fmt.Errorf("validation failed: invalid user ID %q", value)
+		}
+	}
+
+	ids := []struct {
+		name string
+		dest **uuid.UUID
+	}{
+		{"service_id", &req.ServiceID},
+		{"plan_id", &req.PlanID},
+	}
+
+	for _, id := range ids {
+		value := field(id.name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("validation failed: invalid %s %q", id.name, value)
+		}
+
+		*id.dest = &parsed
+	}
+
+	// Пустые метки между разделителями пропускаются
+	for _, tag := range strings.Split(field("tags"), models.TagSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			req.Tags = append(req.Tags, tag)
+		}
+	}
+
+	return req, nil
+}
@@ -1,8 +1,16 @@
 package service
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +20,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/repository"
 	"github.com/vnchk1/subscription-aggregator/internal/service/mocks"
 )
 
@@ -20,13 +29,13 @@ func TestSubscriptionService_CreateSubscription_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
 	req := &models.CreateSubscriptionRequest{
 		ServiceName: "Netflix",
-		Price:       799,
+		Price:       models.Money{Amount: 79900},
 		UserID:      userID,
 		StartDate:   "01-2024",
 	}
@@ -35,7 +44,7 @@ func TestSubscriptionService_CreateSubscription_Success(t *testing.T) {
 		Create(ctx, gomock.Any()).
 		DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
 			assert.Equal(t, "Netflix", sub.ServiceName)
-			assert.Equal(t, 799, sub.Price)
+			assert.Equal(t, models.NewMoney(79900, "RUB"), sub.Price)
 			assert.Equal(t, userID, sub.UserID)
 			assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), sub.StartDate)
 			assert.Nil(t, sub.EndDate)
@@ -46,7 +55,7 @@ func TestSubscriptionService_CreateSubscription_Success(t *testing.T) {
 
 	require.NoError(t, err)
 	assert.Equal(t, "Netflix", result.ServiceName)
-	assert.Equal(t, 799, result.Price)
+	assert.Equal(t, models.NewMoney(79900, "RUB"), result.Price)
 	assert.Equal(t, userID, result.UserID)
 	assert.Equal(t, "01-2024", result.StartDate)
 }
@@ -56,7 +65,7 @@ func TestSubscriptionService_CreateSubscription_InvalidData(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 
@@ -69,7 +78,7 @@ func TestSubscriptionService_CreateSubscription_InvalidData(t *testing.T) {
 			name: "empty service name",
 			request: &models.CreateSubscriptionRequest{
 				ServiceName: "",
-				Price:       100,
+				Price:       models.Money{Amount: 10000},
 				UserID:      uuid.New(),
 				StartDate:   "01-2024",
 			},
@@ -79,7 +88,7 @@ func TestSubscriptionService_CreateSubscription_InvalidData(t *testing.T) {
 			name: "zero price",
 			request: &models.CreateSubscriptionRequest{
 				ServiceName: "Netflix",
-				Price:       0,
+				Price:       models.Money{Amount: 0},
 				UserID:      uuid.New(),
 				StartDate:   "01-2024",
 			},
@@ -106,12 +115,12 @@ func TestSubscriptionService_CreateSubscription_InvalidDateFormat(t *testing.T)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	req := &models.CreateSubscriptionRequest{
 		ServiceName: "Netflix",
-		Price:       799,
+		Price:       models.Money{Amount: 79900},
 		UserID:      uuid.New(),
 		StartDate:   "invalid-date",
 	}
@@ -130,12 +139,12 @@ func TestSubscriptionService_CreateSubscription_RepositoryError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	req := &models.CreateSubscriptionRequest{
 		ServiceName: "Netflix",
-		Price:       799,
+		Price:       models.Money{Amount: 79900},
 		UserID:      uuid.New(),
 		StartDate:   "01-2024",
 	}
@@ -157,15 +166,14 @@ func TestSubscriptionService_CreateSubscription_ServiceNameTooLong(t *testing.T)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 
-
 	longName := string(make([]byte, 256))
 	req := &models.CreateSubscriptionRequest{
 		ServiceName: longName,
-		Price:       799,
+		Price:       models.Money{Amount: 79900},
 		UserID:      uuid.New(),
 		StartDate:   "01-2024",
 	}
@@ -184,30 +192,32 @@ func TestSubscriptionService_GetSubscription_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
 	expectedSub := &models.Subscription{
-		ID:          subscriptionID,
-		ServiceName: "Yandex Plus",
-		Price:       399,
-		UserID:      uuid.New(),
-		StartDate:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:              subscriptionID,
+		ServiceName:     "Yandex Plus",
+		Price:           models.NewMoney(39900, "RUB"),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          uuid.New(),
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	mockRepo.EXPECT().
-		GetByID(ctx, subscriptionID).
+		GetByID(ctx, subscriptionID, false).
 		Return(expectedSub, nil)
 
-	result, err := service.GetSubscription(ctx, subscriptionID)
+	result, err := service.GetSubscription(ctx, subscriptionID, false)
 
 	require.NoError(t, err)
 	assert.Equal(t, expectedSub.ID, result.ID)
 	assert.Equal(t, "Yandex Plus", result.ServiceName)
-	assert.Equal(t, 399, result.Price)
+	assert.Equal(t, models.NewMoney(39900, "RUB"), result.Price)
 }
 
 func TestSubscriptionService_GetSubscription_NotFound(t *testing.T) {
@@ -215,16 +225,16 @@ func TestSubscriptionService_GetSubscription_NotFound(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
 
 	mockRepo.EXPECT().
-		GetByID(ctx, subscriptionID).
+		GetByID(ctx, subscriptionID, false).
 		Return(nil, models.ErrNotFound)
 
-	result, err := service.GetSubscription(ctx, subscriptionID)
+	result, err := service.GetSubscription(ctx, subscriptionID, false)
 
 	assert.Nil(t, result)
 	assert.ErrorIs(t, err, models.ErrNotFound)
@@ -235,13 +245,13 @@ func TestSubscriptionService_GetSubscription_EmptyID(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 
-	mockRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Times(0)
+	mockRepo.EXPECT().GetByID(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
-	result, err := service.GetSubscription(ctx, uuid.Nil)
+	result, err := service.GetSubscription(ctx, uuid.Nil, false)
 
 	assert.Nil(t, result)
 	assert.Error(t, err)
@@ -253,17 +263,17 @@ func TestSubscriptionService_GetSubscription_RepositoryError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
 
 	expectedErr := errors.New("connection failed")
 	mockRepo.EXPECT().
-		GetByID(ctx, subscriptionID).
+		GetByID(ctx, subscriptionID, false).
 		Return(nil, expectedErr)
 
-	result, err := service.GetSubscription(ctx, subscriptionID)
+	result, err := service.GetSubscription(ctx, subscriptionID, false)
 
 	assert.Nil(t, result)
 	assert.Error(t, err)
@@ -275,29 +285,31 @@ func TestSubscriptionService_UpdateSubscription_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
 	req := &models.UpdateSubscriptionRequest{
 		ServiceName: "Yandex Plus Premium",
-		Price:       599,
+		Price:       models.Money{Amount: 59900},
 		StartDate:   "02-2024",
 		EndDate:     stringPtr("12-2024"),
 	}
 
 	existingSub := &models.Subscription{
-		ID:          subscriptionID,
-		ServiceName: "Yandex Plus",
-		Price:       399,
-		UserID:      uuid.New(),
-		StartDate:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:              subscriptionID,
+		ServiceName:     "Yandex Plus",
+		Price:           models.NewMoney(39900, "RUB"),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          uuid.New(),
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	mockRepo.EXPECT().
-		GetByID(ctx, subscriptionID).
+		GetByID(ctx, subscriptionID, false).
 		Return(existingSub, nil)
 
 	mockRepo.EXPECT().
@@ -305,17 +317,17 @@ func TestSubscriptionService_UpdateSubscription_Success(t *testing.T) {
 		DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
 			assert.Equal(t, subscriptionID, sub.ID)
 			assert.Equal(t, "Yandex Plus Premium", sub.ServiceName)
-			assert.Equal(t, 599, sub.Price)
+			assert.Equal(t, models.NewMoney(59900, "RUB"), sub.Price)
 			assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), sub.StartDate)
-			assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), *sub.EndDate)
+			assert.Equal(t, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), *sub.EndDate)
 			return nil
 		})
 
-	result, err := service.UpdateSubscription(ctx, subscriptionID, req)
+	result, err := service.UpdateSubscription(ctx, subscriptionID, req, nil)
 
 	require.NoError(t, err)
 	assert.Equal(t, "Yandex Plus Premium", result.ServiceName)
-	assert.Equal(t, 599, result.Price)
+	assert.Equal(t, models.NewMoney(59900, "RUB"), result.Price)
 	assert.Equal(t, "02-2024", result.StartDate)
 	assert.Equal(t, "12-2024", *result.EndDate)
 }
@@ -325,23 +337,23 @@ func TestSubscriptionService_UpdateSubscription_NotFound(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
 	req := &models.UpdateSubscriptionRequest{
 		ServiceName: "Updated Service",
-		Price:       999,
+		Price:       models.Money{Amount: 99900},
 		StartDate:   "01-2024",
 	}
 
 	mockRepo.EXPECT().
-		GetByID(ctx, subscriptionID).
+		GetByID(ctx, subscriptionID, false).
 		Return(nil, models.ErrNotFound)
 
 	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
 
-	result, err := service.UpdateSubscription(ctx, subscriptionID, req)
+	result, err := service.UpdateSubscription(ctx, subscriptionID, req, nil)
 
 	assert.Nil(t, result)
 	assert.ErrorIs(t, err, models.ErrNotFound)
@@ -352,7 +364,7 @@ func TestSubscriptionService_UpdateSubscription_InvalidData(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
@@ -366,7 +378,7 @@ func TestSubscriptionService_UpdateSubscription_InvalidData(t *testing.T) {
 			name: "empty service name",
 			request: &models.UpdateSubscriptionRequest{
 				ServiceName: "",
-				Price:       100,
+				Price:       models.Money{Amount: 10000},
 				StartDate:   "01-2024",
 			},
 			wantErr: "service name is required",
@@ -375,7 +387,7 @@ func TestSubscriptionService_UpdateSubscription_InvalidData(t *testing.T) {
 			name: "zero price",
 			request: &models.UpdateSubscriptionRequest{
 				ServiceName: "Service",
-				Price:       0,
+				Price:       models.Money{Amount: 0},
 				StartDate:   "01-2024",
 			},
 			wantErr: "price must be positive",
@@ -384,7 +396,7 @@ func TestSubscriptionService_UpdateSubscription_InvalidData(t *testing.T) {
 			name: "negative price",
 			request: &models.UpdateSubscriptionRequest{
 				ServiceName: "Service",
-				Price:       -100,
+				Price:       models.Money{Amount: -10000},
 				StartDate:   "01-2024",
 			},
 			wantErr: "price must be positive",
@@ -394,10 +406,10 @@ func TestSubscriptionService_UpdateSubscription_InvalidData(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 
-			mockRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Times(0)
+			mockRepo.EXPECT().GetByID(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 			mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
 
-			result, err := service.UpdateSubscription(ctx, subscriptionID, tc.request)
+			result, err := service.UpdateSubscription(ctx, subscriptionID, tc.request, nil)
 
 			assert.Nil(t, result)
 			assert.Error(t, err)
@@ -411,34 +423,36 @@ func TestSubscriptionService_UpdateSubscription_EndDateBeforeStartDate(t *testin
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
 	req := &models.UpdateSubscriptionRequest{
 		ServiceName: "Service",
-		Price:       100,
+		Price:       models.Money{Amount: 10000},
 		StartDate:   "12-2024",
 		EndDate:     stringPtr("01-2024"),
 	}
 
 	existingSub := &models.Subscription{
-		ID:          subscriptionID,
-		ServiceName: "Old Service",
-		Price:       50,
-		UserID:      uuid.New(),
-		StartDate:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:              subscriptionID,
+		ServiceName:     "Old Service",
+		Price:           models.NewMoney(5000, "RUB"),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          uuid.New(),
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	mockRepo.EXPECT().
-		GetByID(ctx, subscriptionID).
+		GetByID(ctx, subscriptionID, false).
 		Return(existingSub, nil)
 
 	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
 
-	result, err := service.UpdateSubscription(ctx, subscriptionID, req)
+	result, err := service.UpdateSubscription(ctx, subscriptionID, req, nil)
 
 	assert.Nil(t, result)
 	assert.Error(t, err)
@@ -450,28 +464,30 @@ func TestSubscriptionService_UpdateSubscription_RepositoryError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
 	req := &models.UpdateSubscriptionRequest{
 		ServiceName: "Updated Service",
-		Price:       999,
+		Price:       models.Money{Amount: 99900},
 		StartDate:   "01-2024",
 	}
 
 	existingSub := &models.Subscription{
-		ID:          subscriptionID,
-		ServiceName: "Old Service",
-		Price:       50,
-		UserID:      uuid.New(),
-		StartDate:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:              subscriptionID,
+		ServiceName:     "Old Service",
+		Price:           models.NewMoney(5000, "RUB"),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          uuid.New(),
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	mockRepo.EXPECT().
-		GetByID(ctx, subscriptionID).
+		GetByID(ctx, subscriptionID, false).
 		Return(existingSub, nil)
 
 	expectedErr := errors.New("update failed")
@@ -479,7 +495,7 @@ func TestSubscriptionService_UpdateSubscription_RepositoryError(t *testing.T) {
 		Update(ctx, gomock.Any()).
 		Return(expectedErr)
 
-	result, err := service.UpdateSubscription(ctx, subscriptionID, req)
+	result, err := service.UpdateSubscription(ctx, subscriptionID, req, nil)
 
 	assert.Nil(t, result)
 	assert.Error(t, err)
@@ -491,19 +507,19 @@ func TestSubscriptionService_UpdateSubscription_EmptyID(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	req := &models.UpdateSubscriptionRequest{
 		ServiceName: "Service",
-		Price:       100,
+		Price:       models.Money{Amount: 10000},
 		StartDate:   "01-2024",
 	}
 
-	mockRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).Times(0)
+	mockRepo.EXPECT().GetByID(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
 
-	result, err := service.UpdateSubscription(ctx, uuid.Nil, req)
+	result, err := service.UpdateSubscription(ctx, uuid.Nil, req, nil)
 
 	assert.Nil(t, result)
 	assert.Error(t, err)
@@ -515,16 +531,16 @@ func TestSubscriptionService_DeleteSubscription_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
 
 	mockRepo.EXPECT().
-		Delete(ctx, subscriptionID).
+		Delete(ctx, subscriptionID, nil).
 		Return(nil)
 
-	err := service.DeleteSubscription(ctx, subscriptionID)
+	err := service.DeleteSubscription(ctx, subscriptionID, nil)
 
 	assert.NoError(t, err)
 }
@@ -534,16 +550,16 @@ func TestSubscriptionService_DeleteSubscription_NotFound(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
 
 	mockRepo.EXPECT().
-		Delete(ctx, subscriptionID).
+		Delete(ctx, subscriptionID, nil).
 		Return(models.ErrNotFound)
 
-	err := service.DeleteSubscription(ctx, subscriptionID)
+	err := service.DeleteSubscription(ctx, subscriptionID, nil)
 
 	assert.ErrorIs(t, err, models.ErrNotFound)
 }
@@ -553,13 +569,13 @@ func TestSubscriptionService_DeleteSubscription_EmptyID(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 
-	mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Times(0)
+	mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
-	err := service.DeleteSubscription(ctx, uuid.Nil)
+	err := service.DeleteSubscription(ctx, uuid.Nil, nil)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "subscription ID is required")
@@ -570,17 +586,17 @@ func TestSubscriptionService_DeleteSubscription_RepositoryError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
 
 	expectedErr := errors.New("delete failed")
 	mockRepo.EXPECT().
-		Delete(ctx, subscriptionID).
+		Delete(ctx, subscriptionID, nil).
 		Return(expectedErr)
 
-	err := service.DeleteSubscription(ctx, subscriptionID)
+	err := service.DeleteSubscription(ctx, subscriptionID, nil)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to delete subscription")
@@ -591,28 +607,30 @@ func TestSubscriptionService_ListSubscriptions_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
 
 	expectedSubs := []*models.Subscription{
 		{
-			ID:          uuid.New(),
-			ServiceName: "Netflix",
-			Price:       799,
-			UserID:      userID,
-			StartDate:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
+			ID:              uuid.New(),
+			ServiceName:     "Netflix",
+			Price:           models.NewMoney(79900, "RUB"),
+			BillingPeriod:   models.BillingPeriodMonthly,
+			BillingInterval: 1,
+			UserID:          userID,
+			StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
 		},
 	}
 
 	mockRepo.EXPECT().
-		List(ctx, &userID, 10, 0).
+		List(ctx, &models.SubscriptionListFilter{UserID: &userID}, 11, 0, nil).
 		Return(expectedSubs, 1, nil)
 
-	result, err := service.ListSubscriptions(ctx, &userID, 1, 10)
+	result, err := service.ListSubscriptions(ctx, &models.ListSubscriptionsRequest{UserID: &userID, Page: 1, Limit: 10})
 
 	require.NoError(t, err)
 	assert.Equal(t, 1, result.Total)
@@ -624,15 +642,15 @@ func TestSubscriptionService_ListSubscriptions_EmptyResult(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 
 	mockRepo.EXPECT().
-		List(ctx, nil, 20, 0).
+		List(ctx, &models.SubscriptionListFilter{}, 21, 0, nil).
 		Return([]*models.Subscription{}, 0, nil)
 
-	result, err := service.ListSubscriptions(ctx, nil, 1, 20)
+	result, err := service.ListSubscriptions(ctx, &models.ListSubscriptionsRequest{Page: 1, Limit: 20})
 
 	require.NoError(t, err)
 	assert.Equal(t, 0, result.Total)
@@ -644,16 +662,15 @@ func TestSubscriptionService_ListSubscriptions_WithPagination(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 
-
 	mockRepo.EXPECT().
-		List(ctx, nil, 5, 5).
+		List(ctx, &models.SubscriptionListFilter{}, 6, 5, nil).
 		Return([]*models.Subscription{}, 0, nil)
 
-	result, err := service.ListSubscriptions(ctx, nil, 2, 5)
+	result, err := service.ListSubscriptions(ctx, &models.ListSubscriptionsRequest{Page: 2, Limit: 5})
 
 	require.NoError(t, err)
 	assert.Equal(t, 0, result.Total)
@@ -664,16 +681,16 @@ func TestSubscriptionService_ListSubscriptions_RepositoryError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 
 	expectedErr := errors.New("list failed")
 	mockRepo.EXPECT().
-		List(ctx, nil, 20, 0).
+		List(ctx, &models.SubscriptionListFilter{}, 21, 0, nil).
 		Return(nil, 0, expectedErr)
 
-	result, err := service.ListSubscriptions(ctx, nil, 1, 20)
+	result, err := service.ListSubscriptions(ctx, &models.ListSubscriptionsRequest{Page: 1, Limit: 20})
 
 	assert.Nil(t, result)
 	assert.Error(t, err)
@@ -685,7 +702,7 @@ func TestSubscriptionService_CalculateTotalCost_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	req := &models.TotalCostRequest{
@@ -693,20 +710,24 @@ func TestSubscriptionService_CalculateTotalCost_Success(t *testing.T) {
 		EndPeriod:   "12-2024",
 	}
 
-	expectedTotal := 2500
+	expectedTotal := int64(2500)
 
 	mockRepo.EXPECT().
 		GetTotalCost(ctx, gomock.Any()).
-		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) (int, error) {
+		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) (int64, error) {
 			assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), filter.StartDate)
-			assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), filter.EndDate)
+			assert.Equal(t, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), filter.EndDate)
 			return expectedTotal, nil
 		})
 
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
+
 	result, err := service.CalculateTotalCost(ctx, req)
 
 	require.NoError(t, err)
-	assert.Equal(t, expectedTotal, result.TotalCost)
+	assert.Equal(t, models.NewMoney(expectedTotal, "RUB"), result.TotalCost)
 	assert.Equal(t, "RUB", result.Currency)
 	assert.Equal(t, "01-2024 - 12-2024", result.Period)
 }
@@ -716,7 +737,7 @@ func TestSubscriptionService_CalculateTotalCost_WithUserFilter(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
@@ -726,18 +747,66 @@ func TestSubscriptionService_CalculateTotalCost_WithUserFilter(t *testing.T) {
 		EndPeriod:   "12-2024",
 	}
 
-	expectedTotal := 1500
+	expectedTotal := int64(1500)
 	mockRepo.EXPECT().
 		GetTotalCost(ctx, gomock.Any()).
-		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) (int, error) {
+		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) (int64, error) {
 			assert.Equal(t, &userID, filter.UserID)
 			return expectedTotal, nil
 		})
 
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
+
 	result, err := service.CalculateTotalCost(ctx, req)
 
 	require.NoError(t, err)
-	assert.Equal(t, expectedTotal, result.TotalCost)
+	assert.Equal(t, models.NewMoney(expectedTotal, "RUB"), result.TotalCost)
+}
+
+func TestSubscriptionService_CalculateTotalCost_UserCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	users := mocks.NewMockUserRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), users)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	unknownID := uuid.New()
+
+	users.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID, DefaultCurrency: "USD"}, nil)
+	users.EXPECT().GetByID(ctx, unknownID).Return(nil, models.ErrUserNotFound)
+
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil).
+		Times(2)
+	mockRepo.EXPECT().
+		GetTotalCost(ctx, gomock.Any()).
+		Return(int64(0), nil).
+		Times(2)
+
+	// Валюта пользователя применяется, только если валюта отчета не задана
+	result, err := service.CalculateTotalCost(ctx, &models.TotalCostRequest{UserID: &userID, StartPeriod: "01-2024", EndPeriod: "01-2024"})
+	require.NoError(t, err)
+	assert.Equal(t, "USD", result.Currency)
+
+	result, err = service.CalculateTotalCost(ctx, &models.TotalCostRequest{
+		UserID:         &userID,
+		StartPeriod:    "01-2024",
+		EndPeriod:      "01-2024",
+		TargetCurrency: "eur",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", result.Currency)
+
+	result, err = service.CalculateTotalCost(ctx, &models.TotalCostRequest{UserID: &unknownID, StartPeriod: "01-2024", EndPeriod: "01-2024"})
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	assert.ErrorContains(t, err, "validation failed")
 }
 
 func TestSubscriptionService_CalculateTotalCost_WithServiceFilter(t *testing.T) {
@@ -745,7 +814,7 @@ func TestSubscriptionService_CalculateTotalCost_WithServiceFilter(t *testing.T)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	serviceName := "Netflix"
@@ -755,18 +824,22 @@ func TestSubscriptionService_CalculateTotalCost_WithServiceFilter(t *testing.T)
 		EndPeriod:   "12-2024",
 	}
 
-	expectedTotal := 799
+	expectedTotal := int64(799)
 	mockRepo.EXPECT().
 		GetTotalCost(ctx, gomock.Any()).
-		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) (int, error) {
+		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) (int64, error) {
 			assert.Equal(t, &serviceName, filter.ServiceName)
 			return expectedTotal, nil
 		})
 
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
+
 	result, err := service.CalculateTotalCost(ctx, req)
 
 	require.NoError(t, err)
-	assert.Equal(t, expectedTotal, result.TotalCost)
+	assert.Equal(t, models.NewMoney(expectedTotal, "RUB"), result.TotalCost)
 }
 
 func TestSubscriptionService_CalculateTotalCost_EmptyResult(t *testing.T) {
@@ -774,7 +847,7 @@ func TestSubscriptionService_CalculateTotalCost_EmptyResult(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	req := &models.TotalCostRequest{
@@ -784,12 +857,16 @@ func TestSubscriptionService_CalculateTotalCost_EmptyResult(t *testing.T) {
 
 	mockRepo.EXPECT().
 		GetTotalCost(ctx, gomock.Any()).
-		Return(0, nil)
+		Return(int64(0), nil)
+
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
 
 	result, err := service.CalculateTotalCost(ctx, req)
 
 	require.NoError(t, err)
-	assert.Equal(t, 0, result.TotalCost)
+	assert.Equal(t, models.NewMoney(0, "RUB"), result.TotalCost)
 }
 
 func TestSubscriptionService_CalculateTotalCost_InvalidPeriod(t *testing.T) {
@@ -797,7 +874,7 @@ func TestSubscriptionService_CalculateTotalCost_InvalidPeriod(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	req := &models.TotalCostRequest{
@@ -819,7 +896,7 @@ func TestSubscriptionService_CalculateTotalCost_MissingRequiredParams(t *testing
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 
@@ -867,7 +944,7 @@ func TestSubscriptionService_CalculateTotalCost_RepositoryError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	req := &models.TotalCostRequest{
@@ -876,9 +953,12 @@ func TestSubscriptionService_CalculateTotalCost_RepositoryError(t *testing.T) {
 	}
 
 	expectedErr := errors.New("calculation failed")
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
 	mockRepo.EXPECT().
 		GetTotalCost(ctx, gomock.Any()).
-		Return(0, expectedErr)
+		Return(int64(0), expectedErr)
 
 	result, err := service.CalculateTotalCost(ctx, req)
 
@@ -892,7 +972,7 @@ func TestSubscriptionService_CalculateTotalCost_InvalidDateFormat(t *testing.T)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	req := &models.TotalCostRequest{
@@ -914,41 +994,2617 @@ func TestSubscriptionService_DateValidation_EndDateBeforeStartDate(t *testing.T)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
-	service := NewSubscriptionService(mockRepo)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
 
 	ctx := context.Background()
 	subscriptionID := uuid.New()
 
 	existingSub := &models.Subscription{
-		ID:          subscriptionID,
-		ServiceName: "Old Service",
-		Price:       50,
-		UserID:      uuid.New(),
-		StartDate:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:              subscriptionID,
+		ServiceName:     "Old Service",
+		Price:           models.NewMoney(5000, "RUB"),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          uuid.New(),
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	req := &models.UpdateSubscriptionRequest{
 		ServiceName: "Service",
-		Price:       100,
+		Price:       models.Money{Amount: 10000},
 		StartDate:   "12-2024",
 		EndDate:     stringPtr("01-2024"),
 	}
 
 	mockRepo.EXPECT().
-		GetByID(ctx, subscriptionID).
+		GetByID(ctx, subscriptionID, false).
 		Return(existingSub, nil)
 
 	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
 
-	result, err := service.UpdateSubscription(ctx, subscriptionID, req)
+	result, err := service.UpdateSubscription(ctx, subscriptionID, req, nil)
 
 	assert.Nil(t, result)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "end date cannot be before start date")
 }
 
+// unknownServices returns a service catalog that knows no service names.
+func unknownServices(ctrl *gomock.Controller) *mocks.MockServiceRepository {
+	services := mocks.NewMockServiceRepository(ctrl)
+	services.EXPECT().FindByName(gomock.Any(), gomock.Any()).Return(nil, models.ErrServiceNotFound).AnyTimes()
+
+	return services
+}
+
+// defaultUsers finds every user, with the default currency and time zone.
+func defaultUsers(ctrl *gomock.Controller) *mocks.MockUserRepository {
+	users := mocks.NewMockUserRepository(ctrl)
+	users.EXPECT().
+		GetByID(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, id uuid.UUID) (*models.User, error) {
+			return &models.User{
+				ID:              id,
+				Locale:          models.DefaultLocale,
+				DefaultCurrency: models.DefaultCurrency,
+				Timezone:        models.DefaultTimezone,
+			}, nil
+		}).
+		AnyTimes()
+
+	return users
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func uuidPtr(id uuid.UUID) *uuid.UUID {
+	return &id
+}
+
+func TestSubscriptionService_CalculateTotalCostBreakdown_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	req := &models.TotalCostRequest{
+		StartPeriod: "01-2024",
+		EndPeriod:   "03-2024",
+	}
+
+	mockRepo.EXPECT().
+		GetMonthlyCost(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) ([]*models.MonthlyCost, int64, error) {
+			assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), filter.StartDate)
+			assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), filter.EndDate)
+			return []*models.MonthlyCost{
+				{Month: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ServiceName: "Netflix", Amount: 1598, Subscriptions: 2},
+				{Month: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ServiceName: "Spotify", Amount: 299, Subscriptions: 1},
+				{Month: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+				{Month: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), ServiceName: "Spotify", Amount: 299, Subscriptions: 1},
+			}, 2197, nil
+		})
+
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
+
+	result, err := service.CalculateTotalCostBreakdown(ctx, req)
+
+	require.NoError(t, err)
+	// Итог округлен один раз и может отличаться от суммы округленных месяцев
+	assert.Equal(t, models.NewMoney(2197, "RUB"), result.TotalCost)
+	assert.Equal(t, "RUB", result.Currency)
+	assert.Equal(t, "01-2024 - 03-2024", result.Period)
+	require.Len(t, result.Months, 3)
+
+	assert.Equal(t, "01-2024", result.Months[0].Month)
+	assert.Equal(t, models.NewMoney(1897, "RUB"), result.Months[0].Amount)
+	assert.Equal(t, 3, result.Months[0].ActiveSubscriptions)
+	require.Len(t, result.Months[0].Services, 2)
+	assert.Equal(t, "Netflix", result.Months[0].Services[0].ServiceName)
+	assert.Equal(t, models.NewMoney(1598, "RUB"), result.Months[0].Services[0].Amount)
+
+	assert.Equal(t, "02-2024", result.Months[1].Month)
+	assert.Equal(t, models.NewMoney(0, "RUB"), result.Months[1].Amount)
+	assert.Empty(t, result.Months[1].Services)
+
+	assert.Equal(t, "03-2024", result.Months[2].Month)
+	assert.Equal(t, models.NewMoney(299, "RUB"), result.Months[2].Amount)
+}
+
+func TestSubscriptionService_CalculateTotalCostBreakdown_InvalidPeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	req := &models.TotalCostRequest{
+		StartPeriod: "12-2024",
+		EndPeriod:   "01-2024",
+	}
+
+	result, err := service.CalculateTotalCostBreakdown(ctx, req)
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "end period cannot be before start period")
+}
+
+func TestSubscriptionService_CalculateTotalCost_GroupBy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	req := &models.TotalCostRequest{
+		UserID:      &userID,
+		StartPeriod: "01-2024",
+		EndPeriod:   "12-2024",
+		GroupBy:     "service_name, month,service_name",
+	}
+
+	netflix := "Netflix"
+	spotify := "Spotify"
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockRepo.EXPECT().
+		GetGroupedCost(ctx, gomock.Any(), []models.CostDimension{models.CostDimensionServiceName, models.CostDimensionMonth}).
+		DoAndReturn(func(
+			ctx context.Context,
+			filter *models.SubscriptionFilter,
+			groupBy []models.CostDimension,
+		) ([]*models.CostGroup, int64, error) {
+			assert.Equal(t, &userID, filter.UserID)
+			return []*models.CostGroup{
+				{ServiceName: &netflix, Month: &january, Amount: 799},
+				{ServiceName: &spotify, Month: &january, Amount: 299},
+			}, 1098, nil
+		})
+
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
+
+	result, err := service.CalculateTotalCost(ctx, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(1098, "RUB"), result.TotalCost)
+	require.Len(t, result.Groups, 2)
+	assert.Equal(t, &netflix, result.Groups[0].ServiceName)
+	assert.Equal(t, "01-2024", *result.Groups[0].Month)
+	assert.Nil(t, result.Groups[0].UserID)
+	assert.Equal(t, models.NewMoney(799, "RUB"), result.Groups[0].Amount)
+}
+
+func TestSubscriptionService_CalculateTotalCost_InvalidGroupBy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	req := &models.TotalCostRequest{
+		StartPeriod: "01-2024",
+		EndPeriod:   "12-2024",
+		GroupBy:     "service_name;DROP TABLE subscriptions",
+	}
+
+	result, err := service.CalculateTotalCost(ctx, req)
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "invalid group_by dimension")
+}
+
+func TestSubscriptionService_ListSubscriptions_HasMore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC)
+	subs := []*models.Subscription{
+		{ID: uuid.New(), CreatedAt: createdAt.Add(time.Minute)},
+		{ID: uuid.New(), CreatedAt: createdAt},
+		{ID: uuid.New(), CreatedAt: createdAt.Add(-time.Minute)},
+	}
+
+	mockRepo.EXPECT().
+		List(ctx, &models.SubscriptionListFilter{}, 3, 2, nil).
+		Return(subs, 5, nil)
+
+	result, err := service.ListSubscriptions(ctx, &models.ListSubscriptionsRequest{Page: 2, Limit: 2})
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Total)
+	assert.Equal(t, 2, result.Page)
+	assert.Equal(t, 2, result.Limit)
+	assert.True(t, result.HasMore)
+	assert.Len(t, result.Data, 2)
+
+	cursor, err := models.DecodeListCursor(result.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, subs[1].ID, cursor.ID)
+	assert.True(t, createdAt.Equal(cursor.CreatedAt))
+
+	mockRepo.EXPECT().
+		List(ctx, &models.SubscriptionListFilter{}, 3, 0, cursor).
+		Return(subs[2:], 5, nil)
+
+	result, err = service.ListSubscriptions(ctx, &models.ListSubscriptionsRequest{Page: 2, Limit: 2, Cursor: result.NextCursor})
+
+	require.NoError(t, err)
+	assert.False(t, result.HasMore)
+	assert.Empty(t, result.NextCursor)
+	assert.Equal(t, 0, result.Page)
+	assert.Len(t, result.Data, 1)
+}
+
+func TestSubscriptionService_ListSubscriptions_InvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	result, err := service.ListSubscriptions(context.Background(), &models.ListSubscriptionsRequest{Cursor: "not-a-cursor"})
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, models.ErrInvalidCursor)
+}
+
+func TestSubscriptionService_ListSubscriptions_Filters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	req := &models.ListSubscriptionsRequest{
+		ServiceName:       "Netflix",
+		ServiceNameSearch: "net",
+		MinPrice:          "1",
+		MaxPrice:          "5.00",
+		ActiveOn:          "03-2024",
+		Status:            "active",
+		StartFrom:         "01-2023",
+		StartTo:           "12-2023",
+	}
+
+	mockRepo.EXPECT().
+		List(ctx, gomock.Any(), 21, 0, nil).
+		DoAndReturn(func(
+			ctx context.Context,
+			filter *models.SubscriptionListFilter,
+			limit, offset int,
+			cursor *models.ListCursor,
+		) ([]*models.Subscription, int, error) {
+			assert.Equal(t, "Netflix", *filter.ServiceName)
+			assert.Equal(t, "net", *filter.ServiceNamePrefix)
+			assert.Equal(t, int64(100), *filter.MinPrice)
+			assert.Equal(t, int64(500), *filter.MaxPrice)
+			assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), *filter.ActiveOn)
+			assert.Equal(t, models.SubscriptionStatusActive, *filter.Status)
+			assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), *filter.StartFrom)
+			assert.Equal(t, time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), *filter.StartTo)
+			return []*models.Subscription{}, 0, nil
+		})
+
+	_, err := service.ListSubscriptions(ctx, req)
+
+	require.NoError(t, err)
+}
+
+func TestSubscriptionService_ListSubscriptions_InvalidFilters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	testCases := []struct {
+		name    string
+		request *models.ListSubscriptionsRequest
+		wantErr string
+	}{
+		{
+			name:    "min price above max price",
+			request: &models.ListSubscriptionsRequest{MinPrice: "5", MaxPrice: "1"},
+			wantErr: "min price cannot be greater than max price",
+		},
+		{
+			name:    "unknown status",
+			request: &models.ListSubscriptionsRequest{Status: "paused"},
+			wantErr: "invalid status",
+		},
+		{
+			name:    "invalid active on",
+			request: &models.ListSubscriptionsRequest{ActiveOn: "2024-03"},
+			wantErr: "invalid active_on date format",
+		},
+		{
+			name:    "start range reversed",
+			request: &models.ListSubscriptionsRequest{StartFrom: "06-2024", StartTo: "01-2024"},
+			wantErr: "start_to cannot be before start_from",
+		},
+		{
+			name:    "unknown sort field",
+			request: &models.ListSubscriptionsRequest{Sort: "price,-password"},
+			wantErr: "invalid sort field: password",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := service.ListSubscriptions(context.Background(), tc.request)
+
+			assert.Nil(t, result)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+
+			// Та же проверка без запроса к базе, которую CLI делает до создания файла
+			err = service.ValidateListRequest(tc.request)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestSubscriptionService_ListSubscriptions_Sort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subs := []*models.Subscription{{ID: uuid.New()}, {ID: uuid.New()}, {ID: uuid.New()}}
+
+	mockRepo.EXPECT().
+		List(ctx, gomock.Any(), 3, 0, nil).
+		DoAndReturn(func(
+			ctx context.Context,
+			filter *models.SubscriptionListFilter,
+			limit, offset int,
+			cursor *models.ListCursor,
+		) ([]*models.Subscription, int, error) {
+			assert.Equal(t, []models.SortField{
+				{Field: "price"},
+				{Field: "start_date", Desc: true},
+				{Field: "service_name"},
+			}, filter.Sort)
+			return subs, 3, nil
+		})
+
+	result, err := service.ListSubscriptions(ctx, &models.ListSubscriptionsRequest{Limit: 2, Sort: "price, -start_date,,service_name"})
+
+	require.NoError(t, err)
+	assert.True(t, result.HasMore)
+	assert.Empty(t, result.NextCursor)
+}
+
+func TestSubscriptionService_ListSubscriptions_SortWithCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	cursor := models.NewListCursor(&models.Subscription{ID: uuid.New(), CreatedAt: time.Now()}).Encode()
+
+	result, err := service.ListSubscriptions(context.Background(), &models.ListSubscriptionsRequest{Sort: "-price", Cursor: cursor})
+
+	assert.Nil(t, result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cursor cannot be combined with sort")
+}
+
+func TestSubscriptionService_CreateSubscription_Currency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		currency string
+		want     string
+	}{
+		{name: "default", currency: "", want: "RUB"},
+		{name: "lower case", currency: "usd", want: "USD"},
+		{name: "upper case", currency: "EUR", want: "EUR"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				Create(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+					assert.Equal(t, tc.want, sub.Price.Currency)
+					return nil
+				})
+
+			result, err := service.CreateSubscription(ctx, &models.CreateSubscriptionRequest{
+				ServiceName: "Netflix",
+				Price:       models.Money{Amount: 1000},
+				Currency:    tc.currency,
+				UserID:      uuid.New(),
+				StartDate:   "01-2024",
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, result.Currency)
+		})
+	}
+}
+
+func TestSubscriptionService_CreateSubscription_InvalidCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
+
+	testCases := []struct {
+		name     string
+		currency string
+		wantErr  error
+	}{
+		{name: "not a code", currency: "dollars", wantErr: models.ErrInvalidCurrency},
+		{name: "zero decimals", currency: "jpy", wantErr: models.ErrUnsupportedCurrency},
+		{name: "three decimals", currency: "KWD", wantErr: models.ErrUnsupportedCurrency},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := service.CreateSubscription(context.Background(), &models.CreateSubscriptionRequest{
+				ServiceName: "Netflix",
+				Price:       models.Money{Amount: 1000},
+				Currency:    tc.currency,
+				UserID:      uuid.New(),
+				StartDate:   "01-2024",
+			})
+
+			assert.Nil(t, result)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestSubscriptionService_UpdateSubscription_KeepsCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subscriptionID := uuid.New()
+	existingSub := &models.Subscription{
+		ID:              subscriptionID,
+		ServiceName:     "Spotify",
+		Price:           models.NewMoney(1000, "USD"),
+		BillingPeriod:   models.BillingPeriodMonthly,
+		BillingInterval: 1,
+		UserID:          uuid.New(),
+		StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	mockRepo.EXPECT().GetByID(ctx, subscriptionID, false).Return(existingSub, nil)
+	mockRepo.EXPECT().
+		Update(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+			assert.Equal(t, "USD", sub.Price.Currency)
+			return nil
+		})
+
+	_, err := service.UpdateSubscription(ctx, subscriptionID, &models.UpdateSubscriptionRequest{
+		ServiceName: "Spotify",
+		Price:       models.Money{Amount: 1200},
+		StartDate:   "01-2024",
+	}, nil)
+
+	require.NoError(t, err)
+}
+
+func TestSubscriptionService_CalculateTotalCost_TargetCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	req := &models.TotalCostRequest{
+		StartPeriod:    "01-2024",
+		EndPeriod:      "12-2024",
+		TargetCurrency: "usd",
+	}
+
+	mockRepo.EXPECT().
+		GetTotalCost(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) (int64, error) {
+			assert.Equal(t, "USD", filter.TargetCurrency)
+			return 120, nil
+		})
+
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
+
+	result, err := service.CalculateTotalCost(ctx, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(120, "USD"), result.TotalCost)
+	assert.Equal(t, "USD", result.Currency)
+}
+
+func TestSubscriptionService_CalculateTotalCost_MissingExchangeRate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+
+	// Отчет не запрашивается, если для начисления нет курса
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, fmt.Errorf("%w: EUR 01-2024 to RUB", models.ErrMissingExchangeRate))
+
+	result, err := service.CalculateTotalCost(ctx, &models.TotalCostRequest{StartPeriod: "01-2024", EndPeriod: "01-2024", GroupBy: "month"})
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, models.ErrMissingExchangeRate)
+	assert.Contains(t, err.Error(), "to RUB")
+}
+
+func TestSubscriptionService_CalculateTotalCostBreakdown_MonthOverflow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	req := &models.TotalCostRequest{
+		StartPeriod: "01-2024",
+		EndPeriod:   "12-2024",
+	}
+
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
+	mockRepo.EXPECT().
+		GetMonthlyCost(ctx, gomock.Any()).
+		Return([]*models.MonthlyCost{
+			{Month: january, ServiceName: "Netflix", Amount: math.MaxInt64, Subscriptions: 1},
+			{Month: january, ServiceName: "Spotify", Amount: 1, Subscriptions: 1},
+		}, int64(0), nil)
+
+	result, err := service.CalculateTotalCostBreakdown(ctx, req)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, models.ErrAmountOverflow)
+}
+
+func TestSubscriptionService_CreateSubscription_DecimalPrice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+
+	var req models.CreateSubscriptionRequest
+
+	body := `{"service_name":"ChatGPT","price":"299.99","currency":"USD",` +
+		`"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"07-2025"}`
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	mockRepo.EXPECT().
+		Create(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+			assert.Equal(t, models.NewMoney(29999, "USD"), sub.Price)
+			return nil
+		})
+
+	result, err := service.CreateSubscription(ctx, &req)
+
+	require.NoError(t, err)
+
+	response, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.Contains(t, string(response), `"price":"299.99","currency":"USD"`)
+}
+
+func TestSubscriptionService_ListSubscriptions_InvalidPrice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	for _, price := range []string{"abc", "1.234", "1e3", "-"} {
+		t.Run(price, func(t *testing.T) {
+			result, err := service.ListSubscriptions(context.Background(), &models.ListSubscriptionsRequest{MinPrice: price})
+
+			assert.Nil(t, result)
+			assert.ErrorIs(t, err, models.ErrInvalidAmount)
+		})
+	}
+}
+
+func TestSubscriptionService_CreateSubscription_BillingPeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+
+	testCases := []struct {
+		name         string
+		period       string
+		interval     int
+		wantPeriod   models.BillingPeriod
+		wantInterval int
+	}{
+		{name: "default", wantPeriod: models.BillingPeriodMonthly, wantInterval: 1},
+		{name: "yearly", period: "yearly", wantPeriod: models.BillingPeriodYearly, wantInterval: 1},
+		{name: "every two weeks", period: "weekly", interval: 2, wantPeriod: models.BillingPeriodWeekly, wantInterval: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				Create(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+					assert.Equal(t, tc.wantPeriod, sub.BillingPeriod)
+					assert.Equal(t, tc.wantInterval, sub.BillingInterval)
+					return nil
+				})
+
+			result, err := service.CreateSubscription(ctx, &models.CreateSubscriptionRequest{
+				ServiceName:     "Netflix",
+				Price:           models.Money{Amount: 1000},
+				BillingPeriod:   tc.period,
+				BillingInterval: tc.interval,
+				UserID:          uuid.New(),
+				StartDate:       "01-2024",
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantPeriod, result.BillingPeriod)
+			assert.Equal(t, tc.wantInterval, result.BillingInterval)
+		})
+	}
+}
+
+func TestSubscriptionService_CreateSubscription_InvalidBillingPeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
+
+	testCases := []struct {
+		name     string
+		period   string
+		interval int
+		wantErr  string
+	}{
+		{name: "unknown period", period: "daily", wantErr: "invalid billing period"},
+		{name: "negative interval", period: "monthly", interval: -1, wantErr: "billing interval must be positive"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+			result, err := service.CreateSubscription(context.Background(), &models.CreateSubscriptionRequest{
+				ServiceName:     "Netflix",
+				Price:           models.Money{Amount: 1000},
+				BillingPeriod:   tc.period,
+				BillingInterval: tc.interval,
+				UserID:          uuid.New(),
+				StartDate:       "01-2024",
+			})
+
+			assert.Nil(t, result)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestSubscriptionService_CalculateTotalCost_Basis(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+
+	testCases := []struct {
+		basis string
+		want  models.CostBasis
+	}{
+		{basis: "", want: models.CostBasisCharges},
+		{basis: "charges", want: models.CostBasisCharges},
+		{basis: "monthly", want: models.CostBasisMonthly},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.basis, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetTotalCost(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) (int64, error) {
+					assert.Equal(t, tc.want, filter.Basis)
+					return 100, nil
+				})
+
+			mockRepo.EXPECT().
+				GetAppliedExchangeRates(ctx, gomock.Any()).
+				Return(nil, nil)
+
+			result, err := service.CalculateTotalCost(ctx, &models.TotalCostRequest{
+				StartPeriod: "01-2024",
+				EndPeriod:   "12-2024",
+				Basis:       tc.basis,
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, result.Basis)
+		})
+	}
+
+	mockRepo.EXPECT().GetTotalCost(gomock.Any(), gomock.Any()).Times(0)
+
+	result, err := service.CalculateTotalCost(ctx, &models.TotalCostRequest{
+		StartPeriod: "01-2024",
+		EndPeriod:   "12-2024",
+		Basis:       "daily",
+	})
+
+	assert.Nil(t, result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid basis")
+}
+
+func TestSubscriptionService_CreateSubscription_ISODate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
+
+	testCases := []struct {
+		name      string
+		format    models.DateFormat
+		wantStart string
+	}{
+		{name: "legacy response by default", wantStart: "07-2025"},
+		{name: "iso response on request", format: models.DateFormatISO, wantStart: "2025-07-20"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.format != "" {
+				ctx = models.WithDateFormat(ctx, tc.format)
+			}
+
+			mockRepo.EXPECT().
+				Create(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+					assert.Equal(t, time.Date(2025, 7, 20, 0, 0, 0, 0, time.UTC), sub.StartDate)
+					return nil
+				})
+
+			result, err := service.CreateSubscription(ctx, &models.CreateSubscriptionRequest{
+				ServiceName: "Netflix",
+				Price:       models.Money{Amount: 79900},
+				UserID:      uuid.New(),
+				StartDate:   "2025-07-20",
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantStart, result.StartDate)
+		})
+	}
+}
+
+func TestSubscriptionService_UpdateSubscription_LegacyEndDate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := models.WithDateFormat(context.Background(), models.DateFormatISO)
+	subscriptionID := uuid.New()
+
+	mockRepo.EXPECT().
+		GetByID(ctx, subscriptionID, false).
+		Return(&models.Subscription{
+			ID:              subscriptionID,
+			ServiceName:     "Netflix",
+			Price:           models.NewMoney(79900, "RUB"),
+			BillingPeriod:   models.BillingPeriodMonthly,
+			BillingInterval: 1,
+			UserID:          uuid.New(),
+			StartDate:       time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC),
+		}, nil)
+
+	mockRepo.EXPECT().
+		Update(ctx, gomock.Any()).
+		Return(nil)
+
+	// Месяц окончания включается целиком
+	endDate := "02-2024"
+	result, err := service.UpdateSubscription(ctx, subscriptionID, &models.UpdateSubscriptionRequest{
+		ServiceName: "Netflix",
+		Price:       models.Money{Amount: 79900},
+		StartDate:   "2024-01-20",
+		EndDate:     &endDate,
+	}, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-20", result.StartDate)
+	assert.Equal(t, "2024-02-29", *result.EndDate)
+}
+
+func TestSubscriptionService_CalculateTotalCost_Proration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+
+	mockRepo.EXPECT().
+		GetTotalCost(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) (int64, error) {
+			assert.Equal(t, models.ProrationDaily, filter.Proration)
+			assert.Equal(t, time.Date(2025, 7, 20, 0, 0, 0, 0, time.UTC), filter.StartDate)
+			assert.Equal(t, time.Date(2025, 8, 31, 0, 0, 0, 0, time.UTC), filter.EndDate)
+			return 100, nil
+		})
+
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
+
+	result, err := service.CalculateTotalCost(ctx, &models.TotalCostRequest{
+		StartPeriod: "2025-07-20",
+		EndPeriod:   "08-2025",
+		Proration:   "daily",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, models.ProrationDaily, result.Proration)
+
+	mockRepo.EXPECT().GetTotalCost(gomock.Any(), gomock.Any()).Times(0)
+
+	result, err = service.CalculateTotalCost(ctx, &models.TotalCostRequest{
+		StartPeriod: "07-2025",
+		EndPeriod:   "08-2025",
+		Proration:   "hourly",
+	})
+
+	assert.Nil(t, result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid proration")
+}
+
+func TestSubscriptionService_UpdateSubscription_PriceEffectiveFrom(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subscriptionID := uuid.New()
+
+	existing := func() *models.Subscription {
+		return &models.Subscription{
+			ID:              subscriptionID,
+			ServiceName:     "Netflix",
+			Price:           models.NewMoney(79900, "RUB"),
+			BillingPeriod:   models.BillingPeriodMonthly,
+			BillingInterval: 1,
+			UserID:          uuid.New(),
+			StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		priceFrom string
+		want      *time.Time
+	}{
+		{
+			name:      "new price from a month",
+			priceFrom: "06-2024",
+			want:      timePtr(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:      "price from the start replaces the history",
+			priceFrom: "01-2024",
+			want:      nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetByID(ctx, subscriptionID, false).
+				Return(existing(), nil)
+
+			mockRepo.EXPECT().
+				Update(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+					assert.Equal(t, models.NewMoney(99900, "RUB"), sub.Price)
+					assert.Equal(t, tc.want, sub.PriceEffectiveFrom)
+					return nil
+				})
+
+			result, err := service.UpdateSubscription(ctx, subscriptionID, &models.UpdateSubscriptionRequest{
+				ServiceName:        "Netflix",
+				Price:              models.Money{Amount: 99900},
+				PriceEffectiveFrom: tc.priceFrom,
+				StartDate:          "01-2024",
+			}, nil)
+
+			require.NoError(t, err)
+			assert.Equal(t, models.NewMoney(99900, "RUB"), result.Price)
+		})
+	}
+
+	mockRepo.EXPECT().
+		GetByID(ctx, subscriptionID, false).
+		Return(existing(), nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
+
+	result, err := service.UpdateSubscription(ctx, subscriptionID, &models.UpdateSubscriptionRequest{
+		ServiceName:        "Netflix",
+		Price:              models.Money{Amount: 99900},
+		PriceEffectiveFrom: "06-2024",
+		StartDate:          "01-2024",
+		EndDate:            stringPtr("03-2024"),
+	}, nil)
+
+	assert.Nil(t, result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "price effective date cannot be after end date")
+}
+
+func TestSubscriptionService_UpdateSubscription_KeepsPastPrices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subscriptionID := uuid.New()
+	// Текущий месяц считается в часовом поясе пользователя
+	location, err := time.LoadLocation(models.DefaultTimezone)
+	require.NoError(t, err)
+
+	now := time.Now().In(location)
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		price    int64
+		currency string
+		want     *time.Time
+	}{
+		{name: "new price starts this month", price: 99900, want: &currentMonth},
+		{name: "new currency starts this month", price: 79900, currency: "USD", want: &currentMonth},
+		{name: "same price keeps the history", price: 79900, want: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetByID(ctx, subscriptionID, false).
+				Return(&models.Subscription{
+					ID:              subscriptionID,
+					ServiceName:     "Netflix",
+					Price:           models.NewMoney(79900, "RUB"),
+					BillingPeriod:   models.BillingPeriodMonthly,
+					BillingInterval: 1,
+					UserID:          uuid.New(),
+					StartDate:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				}, nil)
+
+			// Цены до PriceEffectiveFrom репозиторий не трогает
+			mockRepo.EXPECT().
+				Update(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+					assert.Equal(t, tc.want, sub.PriceEffectiveFrom)
+					return nil
+				})
+
+			_, err := service.UpdateSubscription(ctx, subscriptionID, &models.UpdateSubscriptionRequest{
+				ServiceName: "Netflix",
+				Price:       models.Money{Amount: tc.price},
+				Currency:    tc.currency,
+				StartDate:   "01-2020",
+			}, nil)
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSubscriptionService_GetSubscriptionPrices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subscriptionID := uuid.New()
+
+	mockRepo.EXPECT().
+		ListPrices(gomock.Any(), subscriptionID).
+		Return([]*models.SubscriptionPrice{
+			{
+				SubscriptionID: subscriptionID,
+				EffectiveFrom:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Price:          models.NewMoney(79900, "RUB"),
+			},
+			{
+				SubscriptionID: subscriptionID,
+				EffectiveFrom:  time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+				Price:          models.NewMoney(99900, "RUB"),
+			},
+		}, nil)
+
+	result, err := service.GetSubscriptionPrices(models.WithDateFormat(ctx, models.DateFormatISO), subscriptionID)
+
+	require.NoError(t, err)
+	require.Len(t, result.Prices, 2)
+	assert.Equal(t, "2024-01-01", result.Prices[0].EffectiveFrom)
+	assert.Equal(t, "2024-05-31", result.Prices[0].EffectiveTo)
+	assert.Equal(t, models.NewMoney(79900, "RUB"), result.Prices[0].Price)
+	assert.Equal(t, "2024-06-01", result.Prices[1].EffectiveFrom)
+	assert.Empty(t, result.Prices[1].EffectiveTo)
+
+	mockRepo.EXPECT().
+		ListPrices(ctx, subscriptionID).
+		Return(nil, models.ErrNotFound)
+
+	result, err = service.GetSubscriptionPrices(ctx, subscriptionID)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestSubscriptionService_GetSubscriptionHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subscriptionID := uuid.New()
+
+	mockRepo.EXPECT().
+		ListEvents(ctx, subscriptionID).
+		Return([]*models.SubscriptionEvent{
+			{
+				ID:             1,
+				SubscriptionID: subscriptionID,
+				Action:         models.SubscriptionEventCreated,
+				After:          json.RawMessage(`{"price": 79900, "end_date": null, "updated_at": "2024-01-01T00:00:00Z"}`),
+				Actor:          "support@example.com",
+				RequestID:      "req-1",
+			},
+			{
+				ID:             2,
+				SubscriptionID: subscriptionID,
+				Action:         models.SubscriptionEventUpdated,
+				Before:         json.RawMessage(`{"price": 79900, "end_date": null, "version": 1, "updated_at": "2024-01-01T00:00:00Z"}`),
+				After:          json.RawMessage(`{"price": 99900, "end_date": "2024-12-31", "version": 2, "updated_at": "2024-06-01T00:00:00Z"}`),
+				Actor:          "support@example.com",
+				RequestID:      "req-2",
+			},
+			{
+				ID:             3,
+				SubscriptionID: subscriptionID,
+				Action:         models.SubscriptionEventUpdated,
+				Before:         json.RawMessage(`{"price": 99900, "end_date": "2024-12-31", "version": 2}`),
+				After:          json.RawMessage(`{"price": 59900, "end_date": "2024-12-31", "version": 3}`),
+				Actor:          "support@example.com",
+				RequestID:      "req-3",
+			},
+		}, nil)
+
+	result, err := service.GetSubscriptionHistory(ctx, subscriptionID)
+
+	require.NoError(t, err)
+	assert.Equal(t, subscriptionID, result.SubscriptionID)
+	require.Len(t, result.Events, 3)
+	assert.Equal(t, models.SubscriptionEventCreated, result.Events[0].Action)
+	assert.Nil(t, result.Events[0].ChangedFields)
+	assert.Equal(t, []string{"end_date", "price"}, result.Events[1].ChangedFields)
+	assert.Equal(t, []string{"price"}, result.Events[2].ChangedFields)
+	assert.Equal(t, "support@example.com", result.Events[1].Actor)
+	assert.Equal(t, "req-2", result.Events[1].RequestID)
+
+	mockRepo.EXPECT().
+		ListEvents(ctx, subscriptionID).
+		Return(nil, models.ErrNotFound)
+
+	result, err = service.GetSubscriptionHistory(ctx, subscriptionID)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestSubscriptionService_RestoreSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subscriptionID := uuid.New()
+
+	gomock.InOrder(
+		mockRepo.EXPECT().Restore(ctx, subscriptionID).Return(nil),
+		mockRepo.EXPECT().
+			GetByID(ctx, subscriptionID, false).
+			Return(&models.Subscription{
+				ID:          subscriptionID,
+				ServiceName: "Netflix",
+				Price:       models.NewMoney(79900, "RUB"),
+				StartDate:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			}, nil),
+	)
+
+	result, err := service.RestoreSubscription(ctx, subscriptionID)
+
+	require.NoError(t, err)
+	assert.Equal(t, subscriptionID, result.ID)
+	assert.Nil(t, result.DeletedAt)
+
+	mockRepo.EXPECT().Restore(ctx, subscriptionID).Return(models.ErrNotFound)
+
+	result, err = service.RestoreSubscription(ctx, subscriptionID)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestSubscriptionService_PurgeDeletedSubscriptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+
+	mockRepo.EXPECT().
+		Purge(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, deletedBefore time.Time) (int, error) {
+			assert.WithinDuration(t, time.Now().AddDate(0, 0, -30), deletedBefore, time.Minute)
+			return 2, nil
+		})
+
+	count, err := service.PurgeDeletedSubscriptions(ctx, 30)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	mockRepo.EXPECT().Purge(gomock.Any(), gomock.Any()).Times(0)
+
+	count, err = service.PurgeDeletedSubscriptions(ctx, -1)
+
+	assert.Zero(t, count)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "days cannot be negative")
+}
+
+func TestSubscriptionService_CalculateTotalCost_IncludeDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+
+	mockRepo.EXPECT().
+		GetTotalCost(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) (int64, error) {
+			assert.True(t, filter.IncludeDeleted)
+			return 100, nil
+		})
+
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
+
+	_, err := service.CalculateTotalCost(ctx, &models.TotalCostRequest{
+		StartPeriod:    "01-2024",
+		EndPeriod:      "12-2024",
+		IncludeDeleted: true,
+	})
+
+	require.NoError(t, err)
+}
+
+func TestSubscriptionService_PatchSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subscriptionID := uuid.New()
+	endDate := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	existing := func() *models.Subscription {
+		return &models.Subscription{
+			ID:              subscriptionID,
+			ServiceName:     "Netflix",
+			Price:           models.NewMoney(79900, "USD"),
+			BillingPeriod:   models.BillingPeriodYearly,
+			BillingInterval: 1,
+			UserID:          uuid.New(),
+			StartDate:       time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC),
+			EndDate:         &endDate,
+		}
+	}
+
+	testCases := []struct {
+		name  string
+		patch string
+		check func(t *testing.T, sub *models.Subscription)
+	}{
+		{
+			name:  "null clears end date",
+			patch: `{"end_date": null}`,
+			check: func(t *testing.T, sub *models.Subscription) {
+				assert.Nil(t, sub.EndDate)
+				assert.Equal(t, "Netflix", sub.ServiceName)
+				assert.Equal(t, models.NewMoney(79900, "USD"), sub.Price)
+				assert.Equal(t, models.BillingPeriodYearly, sub.BillingPeriod)
+				assert.Equal(t, time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), sub.StartDate)
+			},
+		},
+		{
+			name:  "only the given fields change",
+			patch: `{"price": "999.00", "end_date": "06-2025"}`,
+			check: func(t *testing.T, sub *models.Subscription) {
+				assert.Equal(t, models.NewMoney(99900, "USD"), sub.Price)
+				assert.Equal(t, time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC), *sub.EndDate)
+				assert.Equal(t, time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), sub.StartDate)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetByID(ctx, subscriptionID, false).
+				Return(existing(), nil)
+
+			mockRepo.EXPECT().
+				Update(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+					tc.check(t, sub)
+					return nil
+				})
+
+			_, err := service.PatchSubscription(ctx, subscriptionID, []byte(tc.patch), nil)
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSubscriptionService_PatchSubscription_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subscriptionID := uuid.New()
+
+	testCases := []struct {
+		name    string
+		patch   string
+		wantErr string
+	}{
+		{name: "not an object", patch: `["end_date"]`, wantErr: "merge patch must be a JSON object"},
+		{name: "malformed", patch: `{"end_date":`, wantErr: "invalid merge patch"},
+		{name: "unknown field", patch: `{"user_id": "` + uuid.NewString() + `"}`, wantErr: "unknown field"},
+		{name: "removed price", patch: `{"price": null}`, wantErr: "price must be positive"},
+		{name: "end before start", patch: `{"end_date": "12-2023"}`, wantErr: "end date cannot be before start date"},
+	}
+
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetByID(ctx, subscriptionID, false).
+				Return(&models.Subscription{
+					ID:              subscriptionID,
+					ServiceName:     "Netflix",
+					Price:           models.NewMoney(79900, "RUB"),
+					BillingPeriod:   models.BillingPeriodMonthly,
+					BillingInterval: 1,
+					UserID:          uuid.New(),
+					StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				}, nil)
+
+			result, err := service.PatchSubscription(ctx, subscriptionID, []byte(tc.patch), nil)
+
+			assert.Nil(t, result)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestSubscriptionService_UpdateSubscription_IfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subscriptionID := uuid.New()
+
+	existing := func() *models.Subscription {
+		return &models.Subscription{
+			ID:              subscriptionID,
+			ServiceName:     "Netflix",
+			Price:           models.NewMoney(79900, "RUB"),
+			BillingPeriod:   models.BillingPeriodMonthly,
+			BillingInterval: 1,
+			UserID:          uuid.New(),
+			StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Version:         3,
+		}
+	}
+
+	req := &models.UpdateSubscriptionRequest{
+		ServiceName: "Netflix",
+		Price:       models.Money{Amount: 99900},
+		StartDate:   "01-2024",
+	}
+
+	// Клиент видел вторую версию, а подписку уже изменили
+	mockRepo.EXPECT().GetByID(ctx, subscriptionID, false).Return(existing(), nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
+
+	stale := 2
+	result, err := service.UpdateSubscription(ctx, subscriptionID, req, &stale)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, models.ErrPreconditionFailed)
+
+	// Подписку изменили между чтением и записью
+	current := 3
+
+	mockRepo.EXPECT().GetByID(ctx, subscriptionID, false).Return(existing(), nil)
+	mockRepo.EXPECT().Update(ctx, gomock.Any()).Return(models.ErrVersionConflict)
+
+	result, err = service.UpdateSubscription(ctx, subscriptionID, req, &current)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, models.ErrPreconditionFailed)
+
+	mockRepo.EXPECT().GetByID(ctx, subscriptionID, false).Return(existing(), nil)
+	mockRepo.EXPECT().Update(ctx, gomock.Any()).Return(models.ErrVersionConflict)
+
+	result, err = service.UpdateSubscription(ctx, subscriptionID, req, nil)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, models.ErrVersionConflict)
+
+	mockRepo.EXPECT().GetByID(ctx, subscriptionID, false).Return(existing(), nil)
+	mockRepo.EXPECT().
+		Update(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+			assert.Equal(t, 3, sub.Version)
+			sub.Version++
+			return nil
+		})
+
+	result, err = service.UpdateSubscription(ctx, subscriptionID, req, &current)
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Version)
+}
+
+func TestSubscriptionService_DeleteSubscription_IfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subscriptionID := uuid.New()
+	version := 2
+
+	mockRepo.EXPECT().
+		Delete(ctx, subscriptionID, &version).
+		Return(models.ErrVersionConflict)
+
+	err := service.DeleteSubscription(ctx, subscriptionID, &version)
+
+	assert.ErrorIs(t, err, models.ErrPreconditionFailed)
+}
+
+// withinTransaction makes the mocked repository run fn in place of a transaction.
+func withinTransaction(mockRepo *mocks.MockSubscriptionRepository) {
+	mockRepo.EXPECT().
+		WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(repository.SubscriptionRepository) error) error {
+			return fn(mockRepo)
+		})
+}
+
+func batchOperations(t *testing.T, deleteID uuid.UUID) []*models.BatchOperation {
+	data, err := json.Marshal(&models.CreateSubscriptionRequest{
+		ServiceName: "Netflix",
+		Price:       models.Money{Amount: 79900},
+		UserID:      uuid.New(),
+		StartDate:   "01-2024",
+	})
+	require.NoError(t, err)
+
+	return []*models.BatchOperation{
+		{Op: models.BatchOperationCreate, Data: data},
+		{Op: models.BatchOperationDelete, ID: deleteID},
+	}
+}
+
+func TestSubscriptionService_ProcessBatch_Atomic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	deleteID := uuid.New()
+
+	withinTransaction(mockRepo)
+	mockRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Delete(ctx, deleteID, nil).Return(nil)
+
+	result, err := service.ProcessBatch(ctx, &models.BatchRequest{Operations: batchOperations(t, deleteID)})
+
+	require.NoError(t, err)
+	assert.Equal(t, models.BatchModeAtomic, result.Mode)
+	assert.Equal(t, 2, result.Succeeded)
+	assert.Zero(t, result.Failed)
+	require.Len(t, result.Results, 2)
+	assert.Equal(t, "Netflix", result.Results[0].Subscription.ServiceName)
+	assert.Equal(t, 1, result.Results[1].Index)
+	assert.Equal(t, deleteID, *result.Results[1].ID)
+	assert.NoError(t, result.Results[1].Err)
+}
+
+func TestSubscriptionService_ProcessBatch_AtomicRollback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	deleteID := uuid.New()
+
+	withinTransaction(mockRepo)
+	mockRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Delete(ctx, deleteID, nil).Return(models.ErrNotFound)
+
+	result, err := service.ProcessBatch(ctx, &models.BatchRequest{
+		Mode:       models.BatchModeAtomic,
+		Operations: batchOperations(t, deleteID),
+	})
+
+	require.NoError(t, err)
+	assert.Zero(t, result.Succeeded)
+	assert.Equal(t, 2, result.Failed)
+	assert.ErrorIs(t, result.Results[0].Err, models.ErrBatchRolledBack)
+	assert.Nil(t, result.Results[0].Subscription)
+	assert.ErrorIs(t, result.Results[1].Err, models.ErrNotFound)
+}
+
+func TestSubscriptionService_ProcessBatch_BestEffort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	deleteID := uuid.New()
+	operations := append(batchOperations(t, deleteID),
+		&models.BatchOperation{Op: models.BatchOperationUpdate, ID: uuid.New()},
+		&models.BatchOperation{Op: "upsert"},
+	)
+
+	mockRepo.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(0)
+	mockRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+	mockRepo.EXPECT().Delete(ctx, deleteID, nil).Return(models.ErrNotFound)
+
+	result, err := service.ProcessBatch(ctx, &models.BatchRequest{
+		Mode:       models.BatchModeBestEffort,
+		Operations: operations,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, 3, result.Failed)
+	assert.NotNil(t, result.Results[0].Subscription)
+	assert.ErrorIs(t, result.Results[1].Err, models.ErrNotFound)
+	assert.ErrorContains(t, result.Results[2].Err, "data is required")
+	assert.ErrorContains(t, result.Results[3].Err, "invalid operation")
+}
+
+func TestSubscriptionService_ProcessBatch_InvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	testCases := []struct {
+		name    string
+		request *models.BatchRequest
+		wantErr string
+	}{
+		{
+			name:    "no operations",
+			request: &models.BatchRequest{},
+			wantErr: "operations are required",
+		},
+		{
+			name:    "unknown mode",
+			request: &models.BatchRequest{Mode: "partial", Operations: batchOperations(t, uuid.New())},
+			wantErr: "invalid batch mode",
+		},
+		{
+			name: "too many operations",
+			request: &models.BatchRequest{
+				Operations: make([]*models.BatchOperation, models.MaxBatchOperations+1),
+			},
+			wantErr: "more than 1000 operations",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := service.ProcessBatch(context.Background(), tc.request)
+
+			assert.Nil(t, result)
+			assert.ErrorContains(t, err, "validation failed")
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+const subscriptionsCSV = "\ufeffService_Name,price,user_id,start_date,currency,billing_period\n" +
+	"Netflix,799.00,60601fee-2bf1-4721-ae6f-7636e79a0cba,01-2024,,\n" +
+	"Spotify,9.99,60601fee-2bf1-4721-ae6f-7636e79a0cba,2024-02-15,usd,yearly\n" +
+	"Yandex Plus,0,60601fee-2bf1-4721-ae6f-7636e79a0cba,01-2024,,\n" +
+	"Kinopoisk,299,not-a-uuid,01-2024,,\n" +
+	"Ivi,199,0d0b8a3e-51a4-4d3e-8a4c-2f0c8f1e9b7a,01-2024,,\n" +
+	"Okko,399\n"
+
+// knownImportUsers expects the users of subscriptionsCSV to be looked up once;
+// only the first one exists.
+func knownImportUsers(ctrl *gomock.Controller) *mocks.MockUserRepository {
+	known := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+
+	users := mocks.NewMockUserRepository(ctrl)
+	users.EXPECT().
+		ExistingIDs(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+			existing := map[uuid.UUID]bool{}
+
+			for _, id := range ids {
+				existing[id] = id == known
+			}
+
+			return existing, nil
+		})
+
+	return users
+}
+
+func TestSubscriptionService_ImportSubscriptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), knownImportUsers(ctrl))
+
+	ctx := context.Background()
+
+	mockRepo.EXPECT().
+		CreateMany(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, subscriptions []*models.Subscription) error {
+			require.Len(t, subscriptions, 2)
+			assert.Equal(t, models.NewMoney(79900, "RUB"), subscriptions[0].Price)
+			assert.Equal(t, models.NewMoney(999, "USD"), subscriptions[1].Price)
+			assert.Equal(t, models.BillingPeriodYearly, subscriptions[1].BillingPeriod)
+			assert.Equal(t, time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), subscriptions[1].StartDate)
+			return nil
+		})
+
+	result, err := service.ImportSubscriptions(ctx, strings.NewReader(subscriptionsCSV), false)
+
+	require.NoError(t, err)
+	assert.False(t, result.DryRun)
+	assert.Equal(t, 6, result.Rows)
+	assert.Equal(t, 2, result.Valid)
+	assert.Equal(t, 2, result.Imported)
+	require.Len(t, result.Errors, 4)
+	assert.Equal(t, 4, result.Errors[0].Line)
+	assert.Contains(t, result.Errors[0].Error, "price must be positive")
+	assert.Equal(t, 5, result.Errors[1].Line)
+	assert.Contains(t, result.Errors[1].Error, "invalid user ID")
+	assert.Equal(t, 6, result.Errors[2].Line)
+	assert.Contains(t, result.Errors[2].Error, models.ErrUserNotFound.Error())
+	assert.Equal(t, 7, result.Errors[3].Line)
+	assert.Contains(t, result.Errors[3].Error, "expected 6 fields")
+}
+
+func TestSubscriptionService_ImportSubscriptions_Labels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	mockServices := mocks.NewMockServiceRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mockServices, knownImportUsers(ctrl))
+
+	ctx := context.Background()
+	serviceID := uuid.MustParse("33333333-3333-3333-3333-333333333333")
+
+	mockServices.EXPECT().
+		GetByID(ctx, serviceID).
+		Return(&models.Service{ID: serviceID, Name: "Spotify", DefaultCurrency: "RUB"}, nil)
+	mockServices.EXPECT().FindByName(ctx, gomock.Any()).Return(nil, models.ErrServiceNotFound).AnyTimes()
+
+	mockRepo.EXPECT().
+		CreateMany(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, subscriptions []*models.Subscription) error {
+			require.Len(t, subscriptions, 2)
+			assert.Equal(t, &serviceID, subscriptions[0].ServiceID)
+			assert.Equal(t, "music", subscriptions[0].Category)
+			assert.Equal(t, []string{"family", "work"}, subscriptions[0].Tags)
+			assert.Nil(t, subscriptions[1].ServiceID)
+			assert.Empty(t, subscriptions[1].Category)
+			assert.Empty(t, subscriptions[1].Tags)
+			return nil
+		})
+
+	file := "service_name,price,user_id,start_date,service_id,plan_id,category,tags\n" +
+		`Spotify,169,60601fee-2bf1-4721-ae6f-7636e79a0cba,01-2024,33333333-3333-3333-3333-333333333333,,Music,"work, Family,"` + "\n" +
+		"Netflix,799,60601fee-2bf1-4721-ae6f-7636e79a0cba,01-2024,,,,\n" +
+		"Okko,399,60601fee-2bf1-4721-ae6f-7636e79a0cba,01-2024,,not-a-uuid,,\n"
+
+	result, err := service.ImportSubscriptions(ctx, strings.NewReader(file), false)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Imported)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, 4, result.Errors[0].Line)
+	assert.Contains(t, result.Errors[0].Error, "invalid plan_id")
+}
+
+func TestSubscriptionService_ImportSubscriptions_ExportRoundTrip(t *testing.T) {
+	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	ended := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	exported := []*models.Subscription{
+		{
+			ID: uuid.New(), ServiceName: "Netflix", Price: models.NewMoney(79900, "RUB"), BillingPeriod: models.BillingPeriodMonthly,
+			BillingInterval: 1, UserID: userID, StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Version: 1,
+		},
+		{
+			ID: uuid.New(), ServiceName: "Spotify", Price: models.NewMoney(1599, "USD"), BillingPeriod: models.BillingPeriodYearly,
+			BillingInterval: 1, UserID: userID, StartDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), EndDate: &ended, Version: 3,
+		},
+	}
+
+	for _, format := range []models.DateFormat{models.DateFormatLegacy, models.DateFormatISO} {
+		t.Run(string(format), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+			service := NewSubscriptionService(mockRepo, unknownServices(ctrl), knownImportUsers(ctrl))
+
+			ctx := models.WithDateFormat(context.Background(), format)
+
+			exportSubscriptions(mockRepo, exported...)
+
+			var file strings.Builder
+
+			require.NoError(t, service.ExportSubscriptions(ctx, &models.ListSubscriptionsRequest{}, models.ExportFormatCSV, &file))
+
+			mockRepo.EXPECT().
+				CreateMany(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, subscriptions []*models.Subscription) error {
+					require.Len(t, subscriptions, len(exported))
+
+					for i, sub := range subscriptions {
+						assert.Equal(t, exported[i].StartDate, sub.StartDate)
+						assert.Equal(t, exported[i].EndDate, sub.EndDate)
+						assert.Equal(t, exported[i].Price, sub.Price)
+						assert.Equal(t, exported[i].BillingPeriod, sub.BillingPeriod)
+					}
+					return nil
+				})
+
+			result, err := service.ImportSubscriptions(ctx, strings.NewReader(file.String()), false)
+
+			require.NoError(t, err)
+			assert.Empty(t, result.Errors)
+			assert.Equal(t, len(exported), result.Imported)
+		})
+	}
+}
+
+func TestSubscriptionService_ImportSubscriptions_DryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), knownImportUsers(ctrl))
+
+	mockRepo.EXPECT().CreateMany(gomock.Any(), gomock.Any()).Times(0)
+
+	result, err := service.ImportSubscriptions(context.Background(), strings.NewReader(subscriptionsCSV), true)
+
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 2, result.Valid)
+	assert.Zero(t, result.Imported)
+	require.Len(t, result.Errors, 4)
+	assert.Equal(t, 6, result.Errors[2].Line)
+	assert.Contains(t, result.Errors[2].Error, "user not found")
+}
+
+func TestSubscriptionService_ImportSubscriptions_InvalidFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	testCases := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{
+			name:    "unknown column",
+			file:    "service_name,price,user_id,start_date,comment\n",
+			wantErr: `unknown column "comment"`,
+		},
+		{
+			name:    "missing column",
+			file:    "service_name,price,start_date\n",
+			wantErr: `column "user_id" is required`,
+		},
+		{
+			name:    "no rows",
+			file:    "service_name,price,user_id,start_date\n",
+			wantErr: "no subscriptions found",
+		},
+		{
+			name:    "empty file",
+			file:    "",
+			wantErr: "failed to read header",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := service.ImportSubscriptions(context.Background(), strings.NewReader(tc.file), false)
+
+			assert.Nil(t, result)
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+// exportSubscriptions makes the mocked repository export the subscriptions.
+func exportSubscriptions(mockRepo *mocks.MockSubscriptionRepository, subscriptions ...*models.Subscription) {
+	mockRepo.EXPECT().
+		Export(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionListFilter, fn func(*models.Subscription) error) error {
+			for _, sub := range subscriptions {
+				if err := fn(sub); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func exportTestSubscriptions() []*models.Subscription {
+	end := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)
+
+	return []*models.Subscription{
+		{
+			ID:              uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+			ServiceName:     "Netflix",
+			Price:           models.NewMoney(79900, "RUB"),
+			BillingPeriod:   models.BillingPeriodMonthly,
+			BillingInterval: 1,
+			UserID:          uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+			StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			CreatedAt:       created,
+			UpdatedAt:       created,
+			Version:         1,
+		},
+		{
+			ID:              uuid.MustParse("22222222-2222-2222-2222-222222222222"),
+			ServiceName:     `Spotify "Family" <&>`,
+			Price:           models.NewMoney(1599, "USD"),
+			BillingPeriod:   models.BillingPeriodYearly,
+			BillingInterval: 1,
+			UserID:          uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+			StartDate:       time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:         &end,
+			CreatedAt:       created,
+			UpdatedAt:       created,
+			Version:         3,
+			ServiceID:       uuidPtr(uuid.MustParse("33333333-3333-3333-3333-333333333333")),
+			PlanID:          uuidPtr(uuid.MustParse("44444444-4444-4444-4444-444444444444")),
+			Category:        "music",
+			Tags:            []string{"family", "work"},
+		},
+	}
+}
+
+func TestSubscriptionService_ExportSubscriptions_CSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	exportSubscriptions(mockRepo, exportTestSubscriptions()...)
+
+	var out strings.Builder
+
+	err := service.ExportSubscriptions(context.Background(), &models.ListSubscriptionsRequest{}, models.ExportFormatCSV, &out)
+
+	require.NoError(t, err)
+	assert.Equal(t,
+		"id,user_id,service_name,price,currency,billing_period,billing_interval,start_date,end_date,created_at,updated_at,deleted_at,version,"+
+			"service_id,plan_id,category,tags\n"+
+			"11111111-1111-1111-1111-111111111111,60601fee-2bf1-4721-ae6f-7636e79a0cba,Netflix,799.00,RUB,monthly,1,01-2024,,"+
+			"2024-01-05T10:00:00Z,2024-01-05T10:00:00Z,,1,,,,\n"+
+			`22222222-2222-2222-2222-222222222222,60601fee-2bf1-4721-ae6f-7636e79a0cba,"Spotify ""Family"" <&>",15.99,USD,`+
+			"yearly,1,02-2024,12-2024,2024-01-05T10:00:00Z,2024-01-05T10:00:00Z,,3,"+
+			`33333333-3333-3333-3333-333333333333,44444444-4444-4444-4444-444444444444,music,"family,work"`+"\n",
+		out.String())
+}
+
+func TestSubscriptionService_ExportSubscriptions_NDJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	exportSubscriptions(mockRepo, exportTestSubscriptions()...)
+
+	var out strings.Builder
+
+	ctx := models.WithDateFormat(context.Background(), models.DateFormatISO)
+	err := service.ExportSubscriptions(ctx, &models.ListSubscriptionsRequest{}, models.ExportFormatNDJSON, &out)
+
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	var sub models.SubscriptionResponse
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &sub))
+	assert.Equal(t, `Spotify "Family" <&>`, sub.ServiceName)
+	assert.Equal(t, "2024-12-31", *sub.EndDate)
+	assert.Equal(t, 3, sub.Version)
+}
+
+func TestSubscriptionService_ExportSubscriptions_XLSX(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	exportSubscriptions(mockRepo, exportTestSubscriptions()...)
+
+	var out bytes.Buffer
+
+	err := service.ExportSubscriptions(context.Background(), &models.ListSubscriptionsRequest{}, models.ExportFormatXLSX, &out)
+	require.NoError(t, err)
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	require.NoError(t, err)
+
+	parts := make(map[string]string)
+
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+
+		parts[file.Name] = string(content)
+	}
+
+	assert.Contains(t, parts, "[Content_Types].xml")
+	assert.Contains(t, parts, "xl/workbook.xml")
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				String string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+
+	require.NoError(t, xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet))
+	require.Len(t, sheet.Rows, 3)
+	assert.Equal(t, "A1", sheet.Rows[0].Cells[0].Ref)
+	assert.Equal(t, "version", sheet.Rows[0].Cells[12].String)
+
+	spotify := sheet.Rows[2].Cells
+	assert.Equal(t, `Spotify "Family" <&>`, spotify[2].String)
+	assert.Equal(t, "D3", spotify[3].Ref)
+	assert.Empty(t, spotify[3].Type)
+	assert.Equal(t, "15.99", spotify[3].Value)
+	assert.Equal(t, "I3", spotify[8].Ref)
+	assert.Equal(t, "12-2024", spotify[8].String)
+	// deleted_at пуст, поэтому за updated_at (K) сразу идет version (M)
+	assert.Equal(t, "M3", spotify[11].Ref)
+}
+
+func TestSubscriptionService_ExportSubscriptions_InvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	mockRepo.EXPECT().Export(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	var out strings.Builder
+
+	err := service.ExportSubscriptions(context.Background(), &models.ListSubscriptionsRequest{}, "pdf", &out)
+	assert.ErrorContains(t, err, "invalid export format")
+
+	err = service.ExportSubscriptions(context.Background(), &models.ListSubscriptionsRequest{Status: "paused"}, models.ExportFormatXLSX, &out)
+	assert.ErrorContains(t, err, "validation failed")
+
+	assert.Empty(t, out.String())
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 12: "M", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, want, xlsxColumnName(i))
+	}
+}
+
+func TestSubscriptionService_CreateSubscription_ResolvesServiceAlias(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	mockServices := mocks.NewMockServiceRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mockServices, defaultUsers(ctrl))
+
+	ctx := context.Background()
+	catalogService := &models.Service{ID: uuid.New(), Name: "Yandex Plus", DefaultCurrency: "KZT"}
+
+	mockServices.EXPECT().FindByName(ctx, "яндекс плюс").Return(catalogService, nil)
+
+	mockRepo.EXPECT().
+		Create(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+			assert.Equal(t, &catalogService.ID, sub.ServiceID)
+			assert.Equal(t, "Yandex Plus", sub.ServiceName)
+			assert.Equal(t, "KZT", sub.Price.Currency)
+			return nil
+		})
+
+	result, err := service.CreateSubscription(ctx, &models.CreateSubscriptionRequest{
+		ServiceName: "яндекс плюс",
+		Price:       models.Money{Amount: 29900},
+		UserID:      uuid.New(),
+		StartDate:   "01-2024",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &catalogService.ID, result.ServiceID)
+	assert.Equal(t, "Yandex Plus", result.ServiceName)
+}
+
+func TestSubscriptionService_CreateSubscription_ServiceID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	mockServices := mocks.NewMockServiceRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mockServices, defaultUsers(ctrl))
+
+	ctx := context.Background()
+	catalogService := &models.Service{ID: uuid.New(), Name: "Netflix", DefaultCurrency: "USD"}
+
+	mockServices.EXPECT().GetByID(ctx, catalogService.ID).Return(catalogService, nil)
+
+	mockRepo.EXPECT().
+		Create(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+			assert.Equal(t, "Netflix", sub.ServiceName)
+			// Валюта запроса важнее валюты сервиса
+			assert.Equal(t, "EUR", sub.Price.Currency)
+			return nil
+		})
+
+	_, err := service.CreateSubscription(ctx, &models.CreateSubscriptionRequest{
+		ServiceID: &catalogService.ID,
+		Price:     models.Money{Amount: 999},
+		Currency:  "EUR",
+		UserID:    uuid.New(),
+		StartDate: "01-2024",
+	})
+
+	require.NoError(t, err)
+}
+
+func TestSubscriptionService_CreateSubscription_UnknownServiceID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	mockServices := mocks.NewMockServiceRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mockServices, defaultUsers(ctrl))
+
+	serviceID := uuid.New()
+
+	mockServices.EXPECT().GetByID(gomock.Any(), serviceID).Return(nil, models.ErrServiceNotFound)
+
+	result, err := service.CreateSubscription(context.Background(), &models.CreateSubscriptionRequest{
+		ServiceID: &serviceID,
+		Price:     models.Money{Amount: 999},
+		UserID:    uuid.New(),
+		StartDate: "01-2024",
+	})
+
+	require.ErrorIs(t, err, models.ErrServiceNotFound)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "validation failed")
+}
+
+func TestSubscriptionService_UpdateSubscription_ServiceLink(t *testing.T) {
+	serviceID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		serviceName   string
+		found         *models.Service
+		wantServiceID *uuid.UUID
+		wantName      string
+	}{
+		{
+			name:          "same name keeps the service",
+			serviceName:   "Netflix",
+			wantServiceID: &serviceID,
+			wantName:      "Netflix",
+		},
+		{
+			name:          "unknown name unlinks the service",
+			serviceName:   "Kinopoisk",
+			wantServiceID: nil,
+			wantName:      "Kinopoisk",
+		},
+		{
+			name:          "alias links the service",
+			serviceName:   "okko tv",
+			found:         &models.Service{ID: serviceID, Name: "Okko"},
+			wantServiceID: &serviceID,
+			wantName:      "Okko",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+			mockServices := mocks.NewMockServiceRepository(ctrl)
+			service := NewSubscriptionService(mockRepo, mockServices, defaultUsers(ctrl))
+
+			ctx := context.Background()
+			subscriptionID := uuid.New()
+
+			mockRepo.EXPECT().
+				GetByID(ctx, subscriptionID, false).
+				Return(&models.Subscription{
+					ID:              subscriptionID,
+					ServiceID:       &serviceID,
+					ServiceName:     "Netflix",
+					Price:           models.NewMoney(79900, "RUB"),
+					BillingPeriod:   models.BillingPeriodMonthly,
+					BillingInterval: 1,
+					UserID:          uuid.New(),
+					StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				}, nil)
+
+			if tc.serviceName != "Netflix" {
+				err := models.ErrServiceNotFound
+				if tc.found != nil {
+					err = nil
+				}
+
+				mockServices.EXPECT().FindByName(ctx, tc.serviceName).Return(tc.found, err)
+			}
+
+			mockRepo.EXPECT().
+				Update(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+					assert.Equal(t, tc.wantServiceID, sub.ServiceID)
+					assert.Equal(t, tc.wantName, sub.ServiceName)
+					return nil
+				})
+
+			_, err := service.UpdateSubscription(ctx, subscriptionID, &models.UpdateSubscriptionRequest{
+				ServiceName: tc.serviceName,
+				Price:       models.Money{Amount: 79900},
+				StartDate:   "01-2024",
+			}, nil)
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSubscriptionService_CalculateTotalCost_ServiceID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	serviceID := uuid.New()
+
+	mockRepo.EXPECT().
+		GetTotalCost(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, filter *models.SubscriptionFilter) (int64, error) {
+			assert.Equal(t, &serviceID, filter.ServiceID)
+			return 79900, nil
+		})
+
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(gomock.Any(), gomock.Any()).
+		Return(nil, nil)
+
+	result, err := service.CalculateTotalCost(context.Background(), &models.TotalCostRequest{
+		ServiceID:   &serviceID,
+		StartPeriod: "01-2024",
+		EndPeriod:   "01-2024",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(79900, "RUB"), result.TotalCost)
+}
+
+func TestSubscriptionService_CreateSubscription_PlanListPrice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	mockServices := mocks.NewMockServiceRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mockServices, defaultUsers(ctrl))
+
+	ctx := context.Background()
+	catalogService := &models.Service{ID: uuid.New(), Name: "Spotify", DefaultCurrency: "USD"}
+	plan := &models.ServicePlan{
+		ID:        uuid.New(),
+		ServiceID: catalogService.ID,
+		Name:      "Family",
+		Prices: []*models.PlanPrice{
+			{BillingPeriod: models.BillingPeriodMonthly, Price: models.NewMoney(1699, "USD")},
+			{BillingPeriod: models.BillingPeriodMonthly, Price: models.NewMoney(26900, "RUB")},
+		},
+	}
+
+	mockServices.EXPECT().GetPlan(ctx, plan.ID).Return(plan, nil)
+	mockServices.EXPECT().GetByID(ctx, catalogService.ID).Return(catalogService, nil)
+
+	mockRepo.EXPECT().
+		Create(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+			assert.Equal(t, &plan.ID, sub.PlanID)
+			assert.Equal(t, &catalogService.ID, sub.ServiceID)
+			assert.Equal(t, "Spotify", sub.ServiceName)
+			// Цена за два месяца по прейскуранту в валюте сервиса
+			assert.Equal(t, models.NewMoney(2*1699, "USD"), sub.Price)
+			return nil
+		})
+
+	result, err := service.CreateSubscription(ctx, &models.CreateSubscriptionRequest{
+		PlanID:          &plan.ID,
+		BillingInterval: 2,
+		UserID:          uuid.New(),
+		StartDate:       "01-2024",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &plan.ID, result.PlanID)
+}
+
+func TestSubscriptionService_CreateSubscription_PlanErrors(t *testing.T) {
+	serviceID := uuid.New()
+	plan := &models.ServicePlan{
+		ID:        uuid.New(),
+		ServiceID: serviceID,
+		Name:      "Individual",
+		Prices:    []*models.PlanPrice{{BillingPeriod: models.BillingPeriodMonthly, Price: models.NewMoney(16900, "RUB")}},
+	}
+	otherServiceID := uuid.New()
+
+	testCases := []struct {
+		name    string
+		request *models.CreateSubscriptionRequest
+		wantErr string
+	}{
+		{
+			name:    "no list price for the period",
+			request: &models.CreateSubscriptionRequest{PlanID: &plan.ID, BillingPeriod: "yearly"},
+			wantErr: "plan Individual has no yearly list price in RUB",
+		},
+		{
+			name:    "plan of another service",
+			request: &models.CreateSubscriptionRequest{PlanID: &plan.ID, ServiceID: &otherServiceID},
+			wantErr: "plan does not belong to the service",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockServices := mocks.NewMockServiceRepository(ctrl)
+			service := NewSubscriptionService(mocks.NewMockSubscriptionRepository(ctrl), mockServices, mocks.NewMockUserRepository(ctrl))
+
+			mockServices.EXPECT().GetPlan(gomock.Any(), plan.ID).Return(plan, nil)
+			mockServices.EXPECT().
+				GetByID(gomock.Any(), serviceID).
+				Return(&models.Service{ID: serviceID, Name: "Spotify", DefaultCurrency: "RUB"}, nil).
+				AnyTimes()
+
+			tc.request.UserID = uuid.New()
+			tc.request.StartDate = "01-2024"
+
+			result, err := service.CreateSubscription(context.Background(), tc.request)
+
+			require.Error(t, err)
+			assert.Nil(t, result)
+			assert.Contains(t, err.Error(), "validation failed")
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestSubscriptionService_ComparePlanPrices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	userID := uuid.New()
+	listPrice := models.NewMoney(26900, "RUB")
+
+	mockRepo.EXPECT().
+		ComparePlanPrices(gomock.Any(), &models.PlanPriceFilter{UserID: &userID}).
+		Return([]*models.PlanPriceComparison{
+			{SubscriptionID: uuid.New(), PlanName: "Family", Price: models.NewMoney(29900, "RUB"), ListPrice: &listPrice},
+			{SubscriptionID: uuid.New(), PlanName: "Duo", Price: models.NewMoney(100, "EUR")},
+		}, nil)
+
+	result, err := service.ComparePlanPrices(context.Background(), &models.PlanPriceReportRequest{UserID: &userID})
+
+	require.NoError(t, err)
+	require.Len(t, result.Subscriptions, 2)
+	assert.Equal(t, "RUB", result.Subscriptions[0].Currency)
+	assert.Equal(t, models.NewMoney(3000, "RUB"), *result.Subscriptions[0].Difference)
+	assert.Nil(t, result.Subscriptions[1].ListPrice)
+	assert.Nil(t, result.Subscriptions[1].Difference)
+}
+
+func TestSubscriptionService_CreateSubscription_Tags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+
+	mockRepo.EXPECT().
+		Create(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+			assert.Equal(t, "work", sub.Category)
+			assert.Equal(t, []string{"family", "shared"}, sub.Tags)
+			return nil
+		})
+
+	result, err := service.CreateSubscription(ctx, &models.CreateSubscriptionRequest{
+		ServiceName: "Netflix",
+		Price:       models.Money{Amount: 999},
+		UserID:      uuid.New(),
+		StartDate:   "01-2024",
+		Category:    " Work ",
+		Tags:        []string{"Shared", "family", " shared"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "work", result.Category)
+	assert.Equal(t, []string{"family", "shared"}, result.Tags)
+}
+
+func TestSubscriptionService_CreateSubscription_InvalidTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := NewSubscriptionService(mocks.NewMockSubscriptionRepository(ctrl), unknownServices(ctrl), mocks.NewMockUserRepository(ctrl))
+
+	tooMany := make([]string, 21)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag-%d", i)
+	}
+
+	testCases := []struct {
+		name     string
+		category string
+		tags     []string
+		wantErr  string
+	}{
+		{name: "empty tag", tags: []string{"work", " "}, wantErr: "tag cannot be empty"},
+		{name: "long tag", tags: []string{strings.Repeat("a", 65)}, wantErr: "tag too long"},
+		{name: "tag with a comma", tags: []string{"work,family"}, wantErr: "tag cannot contain"},
+		{name: "too many tags", tags: tooMany, wantErr: "too many tags"},
+		{name: "long category", category: strings.Repeat("a", 65), wantErr: "category too long"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := service.CreateSubscription(context.Background(), &models.CreateSubscriptionRequest{
+				ServiceName: "Netflix",
+				Price:       models.Money{Amount: 999},
+				UserID:      uuid.New(),
+				StartDate:   "01-2024",
+				Category:    tc.category,
+				Tags:        tc.tags,
+			})
+
+			require.Error(t, err)
+			assert.Nil(t, result)
+			assert.Contains(t, err.Error(), "validation failed")
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestSubscriptionService_PatchSubscription_Tags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	subscriptionID := uuid.New()
+
+	existing := func() *models.Subscription {
+		return &models.Subscription{
+			ID:              subscriptionID,
+			ServiceName:     "Netflix",
+			Price:           models.NewMoney(799, "USD"),
+			BillingPeriod:   models.BillingPeriodMonthly,
+			BillingInterval: 1,
+			UserID:          uuid.New(),
+			StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Category:        "entertainment",
+			Tags:            []string{"family"},
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		patch        string
+		wantCategory string
+		wantTags     []string
+	}{
+		{name: "other fields keep labels", patch: `{"price": "9.99"}`, wantCategory: "entertainment", wantTags: []string{"family"}},
+		{name: "tags replace tags", patch: `{"tags": ["Work", "shared"]}`, wantCategory: "entertainment", wantTags: []string{"shared", "work"}},
+		{name: "null clears labels", patch: `{"category": null, "tags": null}`, wantCategory: "", wantTags: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetByID(ctx, subscriptionID, false).
+				Return(existing(), nil)
+
+			mockRepo.EXPECT().
+				Update(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, sub *models.Subscription) error {
+					assert.Equal(t, tc.wantCategory, sub.Category)
+					assert.Equal(t, tc.wantTags, sub.Tags)
+					return nil
+				})
+
+			_, err := service.PatchSubscription(ctx, subscriptionID, []byte(tc.patch), nil)
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSubscriptionService_ListSubscriptions_Tags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), nil).
+		DoAndReturn(func(
+			ctx context.Context,
+			filter *models.SubscriptionListFilter,
+			limit, offset int,
+			cursor *models.ListCursor,
+		) ([]*models.Subscription, int, error) {
+			assert.Equal(t, stringPtr("work"), filter.Category)
+			assert.Equal(t, []string{"family", "shared"}, filter.Tags)
+			return nil, 0, nil
+		})
+
+	_, err := service.ListSubscriptions(context.Background(), &models.ListSubscriptionsRequest{
+		Category: "Work",
+		Tags:     []string{"shared", "Family"},
+	})
+
+	require.NoError(t, err)
+}
+
+func TestSubscriptionService_CalculateTotalCost_GroupByCategory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, mocks.NewMockServiceRepository(ctrl), defaultUsers(ctrl))
+
+	ctx := context.Background()
+	work := "work"
+
+	mockRepo.EXPECT().
+		GetGroupedCost(ctx, gomock.Any(), []models.CostDimension{models.CostDimensionCategory}).
+		DoAndReturn(func(
+			ctx context.Context,
+			filter *models.SubscriptionFilter,
+			groupBy []models.CostDimension,
+		) ([]*models.CostGroup, int64, error) {
+			assert.Equal(t, []string{"family"}, filter.Tags)
+			return []*models.CostGroup{
+				{Category: &work, Amount: 1500},
+				{Amount: 500},
+			}, 2000, nil
+		})
+
+	mockRepo.EXPECT().
+		GetAppliedExchangeRates(ctx, gomock.Any()).
+		Return(nil, nil)
+
+	result, err := service.CalculateTotalCost(ctx, &models.TotalCostRequest{
+		StartPeriod: "01-2024",
+		EndPeriod:   "12-2024",
+		Tags:        []string{"Family"},
+		GroupBy:     "category",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(2000, "RUB"), result.TotalCost)
+	require.Len(t, result.Groups, 2)
+	assert.Equal(t, &work, result.Groups[0].Category)
+	assert.Nil(t, result.Groups[1].Category)
+}
+
+func TestSubscriptionService_CreateSubscription_UnknownUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSubscriptionRepository(ctrl)
+	service := NewSubscriptionService(mockRepo, unknownServices(ctrl), defaultUsers(ctrl))
+
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(fmt.Errorf("failed to create subscription: %w", models.ErrUserNotFound))
+
+	result, err := service.CreateSubscription(context.Background(), &models.CreateSubscriptionRequest{
+		ServiceName: "Netflix",
+		Price:       models.Money{Amount: 999},
+		UserID:      uuid.New(),
+		StartDate:   "01-2024",
+	})
+
+	require.ErrorIs(t, err, models.ErrUserNotFound)
+	assert.Nil(t, result)
+	assert.Equal(t, "validation failed: user not found", err.Error())
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// localePattern accepts BCP 47 tags of a language with an optional script and
+// region: "ru", "en-US", "sr-Latn-RS".
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z][a-z]{3})?(-([A-Z]{2}|[0-9]{3}))?$`)
+
+func (s *userService) CreateUser(ctx context.Context, req *models.UserRequest) (*models.User, error) {
+	user, err := s.newUser(req)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if err = s.repo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *userService) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if id == uuid.Nil {
+		return nil, errors.New("user ID is required")
+	}
+
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *userService) UpdateUser(ctx context.Context, id uuid.UUID, req *models.UserRequest) (*models.User, error) {
+	if id == uuid.Nil {
+		return nil, errors.New("user ID is required")
+	}
+
+	user, err := s.newUser(req)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	user.ID = id
+
+	if err = s.repo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return user, nil
+}
+
+// DeleteUser removes the user. Without cascade it fails while the user has
+// subscriptions; with cascade the subscriptions are removed permanently too.
+func (s *userService) DeleteUser(ctx context.Context, id uuid.UUID, cascade bool) error {
+	if id == uuid.Nil {
+		return errors.New("user ID is required")
+	}
+
+	if err := s.repo.Delete(ctx, id, cascade); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return nil
+}
+
+func (s *userService) ListUsers(ctx context.Context, req *models.ListUsersRequest) (*models.ListResponse, error) {
+	filter := &models.UserFilter{
+		Search: strings.TrimSpace(req.Search),
+	}
+
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+
+	limit := req.Limit
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	users, total, err := s.repo.List(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return &models.ListResponse{
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+		HasMore: offset+len(users) < total,
+		Data:    users,
+	}, nil
+}
+
+// newUser validates the request and builds the user it describes with
+// trimmed fields and the defaults of the omitted ones.
+func (s *userService) newUser(req *models.UserRequest) (*models.User, error) {
+	user := &models.User{
+		DisplayName:     strings.TrimSpace(req.DisplayName),
+		Email:           strings.TrimSpace(req.Email),
+		Locale:          models.DefaultLocale,
+		DefaultCurrency: models.DefaultCurrency,
+		Timezone:        models.DefaultTimezone,
+	}
+
+	if user.DisplayName == "" {
+		return nil, errors.New("display name is required")
+	}
+
+	if len(user.DisplayName) > 255 {
+		return nil, errors.New("display name too long")
+	}
+
+	if user.Email == "" {
+		return nil, errors.New("email is required")
+	}
+
+	if len(user.Email) > 255 {
+		return nil, errors.New("email too long")
+	}
+
+	// Адрес с именем ("Ivan <ivan@example.com>") не принимаем
+	if address, err := mail.ParseAddress(user.Email); err != nil || address.Address != user.Email {
+		return nil, fmt.Errorf("invalid email: %s", user.Email)
+	}
+
+	if locale := strings.TrimSpace(req.Locale); locale != "" {
+		if !localePattern.MatchString(locale) {
+			return nil, fmt.Errorf("invalid locale: %s", locale)
+		}
+
+		user.Locale = locale
+	}
+
+	if req.DefaultCurrency != "" {
+		currency, err := models.NormalizeCurrency(req.DefaultCurrency)
+		if err != nil {
+			return nil, err
+		}
+
+		user.DefaultCurrency = currency
+	}
+
+	if timezone := strings.TrimSpace(req.Timezone); timezone != "" {
+		// "Local" зависит от сервера и часовым поясом пользователя быть не может
+		if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+			return nil, fmt.Errorf("invalid timezone: %s", timezone)
+		}
+
+		user.Timezone = timezone
+	}
+
+	return user, nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vnchk1/subscription-aggregator/internal/models"
+	"github.com/vnchk1/subscription-aggregator/internal/service/mocks"
+)
+
+func TestUserService_CreateUser_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	service := NewUserService(mockRepo)
+
+	ctx := context.Background()
+
+	mockRepo.EXPECT().
+		Create(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, user *models.User) error {
+			assert.Equal(t, "Иван Петров", user.DisplayName)
+			assert.Equal(t, "ivan@example.com", user.Email)
+			assert.Equal(t, "USD", user.DefaultCurrency)
+			user.ID = uuid.New()
+			return nil
+		})
+
+	result, err := service.CreateUser(ctx, &models.UserRequest{
+		DisplayName:     " Иван Петров ",
+		Email:           " ivan@example.com ",
+		Locale:          "en-US",
+		DefaultCurrency: "usd",
+		Timezone:        "Asia/Yekaterinburg",
+	})
+
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, result.ID)
+	assert.Equal(t, "en-US", result.Locale)
+	assert.Equal(t, "Asia/Yekaterinburg", result.Timezone)
+}
+
+func TestUserService_CreateUser_Defaults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	service := NewUserService(mockRepo)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	result, err := service.CreateUser(context.Background(), &models.UserRequest{
+		DisplayName: "Ivan",
+		Email:       "ivan@example.com",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultLocale, result.Locale)
+	assert.Equal(t, models.DefaultCurrency, result.DefaultCurrency)
+	assert.Equal(t, models.DefaultTimezone, result.Timezone)
+}
+
+func TestUserService_CreateUser_InvalidData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := NewUserService(mocks.NewMockUserRepository(ctrl))
+
+	valid := func(change func(req *models.UserRequest)) *models.UserRequest {
+		req := &models.UserRequest{DisplayName: "Ivan", Email: "ivan@example.com"}
+		change(req)
+
+		return req
+	}
+
+	testCases := []struct {
+		name    string
+		request *models.UserRequest
+		wantErr string
+	}{
+		{
+			name:    "empty display name",
+			request: valid(func(req *models.UserRequest) { req.DisplayName = " " }),
+			wantErr: "display name is required",
+		},
+		{
+			name:    "empty email",
+			request: valid(func(req *models.UserRequest) { req.Email = "" }),
+			wantErr: "email is required",
+		},
+		{
+			name:    "invalid email",
+			request: valid(func(req *models.UserRequest) { req.Email = "ivan.example.com" }),
+			wantErr: "invalid email",
+		},
+		{
+			name:    "email with name",
+			request: valid(func(req *models.UserRequest) { req.Email = "Ivan <ivan@example.com>" }),
+			wantErr: "invalid email",
+		},
+		{
+			name:    "invalid locale",
+			request: valid(func(req *models.UserRequest) { req.Locale = "russian" }),
+			wantErr: "invalid locale",
+		},
+		{
+			name:    "invalid currency",
+			request: valid(func(req *models.UserRequest) { req.DefaultCurrency = "RUBL" }),
+			wantErr: "invalid currency",
+		},
+		{
+			name:    "unknown timezone",
+			request: valid(func(req *models.UserRequest) { req.Timezone = "Mars/Olympus" }),
+			wantErr: "invalid timezone",
+		},
+		{
+			name:    "local timezone",
+			request: valid(func(req *models.UserRequest) { req.Timezone = "Local" }),
+			wantErr: "invalid timezone",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := service.CreateUser(context.Background(), tc.request)
+
+			require.Error(t, err)
+			assert.Nil(t, result)
+			assert.Contains(t, err.Error(), "validation failed")
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestUserService_CreateUser_EmailTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	service := NewUserService(mockRepo)
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(models.ErrUserEmailTaken)
+
+	_, err := service.CreateUser(context.Background(), &models.UserRequest{DisplayName: "Ivan", Email: "ivan@example.com"})
+
+	require.ErrorIs(t, err, models.ErrUserEmailTaken)
+}
+
+func TestUserService_UpdateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	service := NewUserService(mockRepo)
+
+	id := uuid.New()
+
+	mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, user *models.User) error {
+			assert.Equal(t, id, user.ID)
+			assert.Equal(t, "UTC", user.Timezone)
+			return nil
+		})
+
+	result, err := service.UpdateUser(context.Background(), id, &models.UserRequest{
+		DisplayName: "Ivan",
+		Email:       "ivan@example.com",
+		Timezone:    "UTC",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, id, result.ID)
+}
+
+func TestUserService_DeleteUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	service := NewUserService(mockRepo)
+
+	id := uuid.New()
+
+	mockRepo.EXPECT().Delete(gomock.Any(), id, false).Return(models.ErrUserInUse)
+	mockRepo.EXPECT().Delete(gomock.Any(), id, true).Return(nil)
+
+	require.ErrorIs(t, service.DeleteUser(context.Background(), id, false), models.ErrUserInUse)
+	require.NoError(t, service.DeleteUser(context.Background(), id, true))
+}
+
+func TestUserService_ListUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockUserRepository(ctrl)
+	service := NewUserService(mockRepo)
+
+	users := []*models.User{{ID: uuid.New(), DisplayName: "Ivan"}}
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), &models.UserFilter{Search: "iv"}, 20, 0).
+		Return(users, 1, nil)
+
+	result, err := service.ListUsers(context.Background(), &models.ListUsersRequest{Search: " iv "})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+	assert.False(t, result.HasMore)
+	assert.Equal(t, users, result.Data)
+}
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE subscriptions
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$');
+
+CREATE INDEX idx_subscriptions_currency ON subscriptions(currency);
+
+-- Курс: 1 единица base_currency стоит rate единиц quote_currency на дату rate_date
+CREATE TABLE exchange_rates (
+    rate_date DATE NOT NULL,
+    base_currency CHAR(3) NOT NULL CHECK (base_currency ~ '^[A-Z]{3}$'),
+    quote_currency CHAR(3) NOT NULL CHECK (quote_currency ~ '^[A-Z]{3}$'),
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (base_currency, quote_currency, rate_date),
+    CHECK (base_currency <> quote_currency)
+);
+
+CREATE TRIGGER update_exchange_rates_updated_at
+    BEFORE UPDATE ON exchange_rates
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS update_exchange_rates_updated_at ON exchange_rates;
+DROP TABLE IF EXISTS exchange_rates;
+
+DROP INDEX IF EXISTS idx_subscriptions_currency;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS currency;
+-- +goose StatementEnd
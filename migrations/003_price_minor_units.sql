@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Цена хранится в минимальных единицах валюты (копейках, центах).
+-- Поддерживаются только валюты с двумя знаками после запятой, поэтому множитель 100 общий.
+ALTER TABLE subscriptions
+    ALTER COLUMN price TYPE BIGINT USING price::BIGINT * 100;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscriptions
+    ALTER COLUMN price TYPE INTEGER USING GREATEST(ROUND(price / 100.0), 1)::INTEGER;
+-- +goose StatementEnd
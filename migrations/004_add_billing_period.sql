@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE subscriptions
+    ADD COLUMN billing_period VARCHAR(16) NOT NULL DEFAULT 'monthly'
+        CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly')),
+    ADD COLUMN billing_interval INTEGER NOT NULL DEFAULT 1 CHECK (billing_interval > 0);
+
+-- Даты списаний подписки внутри окна [window_start, window_end]. Каждая дата
+-- отсчитывается от start_date, чтобы списания 31-го числа не сдвигались
+-- после коротких месяцев.
+CREATE OR REPLACE FUNCTION subscription_charge_dates(
+    start_date DATE,
+    end_date DATE,
+    billing_period VARCHAR,
+    billing_interval INTEGER,
+    window_start DATE,
+    window_end DATE
+)
+RETURNS SETOF DATE AS $$
+DECLARE
+    step INTERVAL;
+    last_date DATE := LEAST(COALESCE(end_date, window_end), window_end);
+    charge_date DATE;
+    n INTEGER := 0;
+BEGIN
+    step := CASE billing_period
+        WHEN 'weekly' THEN make_interval(weeks => billing_interval)
+        WHEN 'monthly' THEN make_interval(months => billing_interval)
+        WHEN 'quarterly' THEN make_interval(months => 3 * billing_interval)
+        WHEN 'yearly' THEN make_interval(years => billing_interval)
+    END;
+
+    LOOP
+        charge_date := (start_date + n * step)::DATE;
+        EXIT WHEN charge_date > last_date;
+
+        IF charge_date >= window_start THEN
+            RETURN NEXT charge_date;
+        END IF;
+
+        n := n + 1;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS subscription_charge_dates(DATE, DATE, VARCHAR, INTEGER, DATE, DATE);
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS billing_interval,
+    DROP COLUMN IF EXISTS billing_period;
+-- +goose StatementEnd
@@ -0,0 +1,67 @@
+-- +goose Up
+-- +goose StatementBegin
+-- end_date теперь последний оплаченный день включительно, а не первое число
+-- последнего месяца подписки
+UPDATE subscriptions
+SET end_date = (end_date + INTERVAL '1 month' - INTERVAL '1 day')::DATE
+WHERE end_date IS NOT NULL;
+
+-- Части периодов оплаты, попавшие в окно [window_start, window_end], по
+-- календарным месяцам: дата списания, месяц, число дней периода в этом месяце
+-- и длина всего периода в днях.
+CREATE OR REPLACE FUNCTION subscription_charge_segments(
+    start_date DATE,
+    end_date DATE,
+    billing_period VARCHAR,
+    billing_interval INTEGER,
+    window_start DATE,
+    window_end DATE
+)
+RETURNS TABLE (charged_on DATE, month DATE, days INTEGER, period_days INTEGER) AS $$
+DECLARE
+    step INTERVAL;
+    last_date DATE := LEAST(COALESCE(end_date, window_end), window_end);
+    next_charge DATE;
+    segment_start DATE;
+    segment_end DATE;
+    n INTEGER := 0;
+BEGIN
+    step := CASE billing_period
+        WHEN 'weekly' THEN make_interval(weeks => billing_interval)
+        WHEN 'monthly' THEN make_interval(months => billing_interval)
+        WHEN 'quarterly' THEN make_interval(months => 3 * billing_interval)
+        WHEN 'yearly' THEN make_interval(years => billing_interval)
+    END;
+
+    LOOP
+        charged_on := (start_date + n * step)::DATE;
+        EXIT WHEN charged_on > last_date;
+
+        next_charge := (start_date + (n + 1) * step)::DATE;
+        period_days := next_charge - charged_on;
+        segment_start := GREATEST(charged_on, window_start);
+        segment_end := LEAST(next_charge - 1, last_date);
+
+        WHILE segment_start <= segment_end LOOP
+            month := date_trunc('month', segment_start)::DATE;
+            days := LEAST(segment_end, (month + INTERVAL '1 month' - INTERVAL '1 day')::DATE) - segment_start + 1;
+
+            RETURN NEXT;
+
+            segment_start := (month + INTERVAL '1 month')::DATE;
+        END LOOP;
+
+        n := n + 1;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS subscription_charge_segments(DATE, DATE, VARCHAR, INTEGER, DATE, DATE);
+
+UPDATE subscriptions
+SET end_date = date_trunc('month', end_date)::DATE
+WHERE end_date IS NOT NULL;
+-- +goose StatementEnd
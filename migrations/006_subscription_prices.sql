@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- История цен подписки: цена действует с effective_from до следующей записи.
+-- Самая ранняя запись действует и до своей даты, поэтому перенос start_date
+-- на более ранний срок не оставляет месяцев без цены.
+CREATE TABLE subscription_prices (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL,
+    price BIGINT NOT NULL CHECK (price > 0),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (subscription_id, effective_from)
+);
+
+INSERT INTO subscription_prices (subscription_id, effective_from, price, currency)
+SELECT id, start_date, price, currency
+FROM subscriptions;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS subscription_prices;
+-- +goose StatementEnd
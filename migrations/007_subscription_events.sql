@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Журнал изменений подписок. Внешнего ключа нет: события удаленной подписки
+-- должны остаться в журнале.
+CREATE TABLE subscription_events (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    action VARCHAR(16) NOT NULL CHECK (action IN ('created', 'updated', 'deleted')),
+    before JSONB,
+    after JSONB,
+    actor TEXT,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_subscription_events_subscription_id ON subscription_events(subscription_id, id);
+
+CREATE OR REPLACE FUNCTION reject_subscription_event_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'subscription_events is append-only';
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER subscription_events_append_only
+    BEFORE UPDATE OR DELETE ON subscription_events
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_subscription_event_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS subscription_events_append_only ON subscription_events;
+DROP FUNCTION IF EXISTS reject_subscription_event_change();
+
+DROP INDEX IF EXISTS idx_subscription_events_subscription_id;
+DROP TABLE IF EXISTS subscription_events;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE subscriptions ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_subscriptions_deleted_at ON subscriptions(deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE subscription_events
+    DROP CONSTRAINT subscription_events_action_check,
+    ADD CONSTRAINT subscription_events_action_check
+        CHECK (action IN ('created', 'updated', 'deleted', 'restored', 'purged'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Удаленные подписки без deleted_at стали бы снова активными
+DELETE FROM subscriptions WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE subscription_events DISABLE TRIGGER subscription_events_append_only;
+DELETE FROM subscription_events WHERE action IN ('restored', 'purged');
+ALTER TABLE subscription_events ENABLE TRIGGER subscription_events_append_only;
+
+ALTER TABLE subscription_events
+    DROP CONSTRAINT subscription_events_action_check,
+    ADD CONSTRAINT subscription_events_action_check
+        CHECK (action IN ('created', 'updated', 'deleted'));
+
+DROP INDEX IF EXISTS idx_subscriptions_deleted_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Версия подписки растет при каждом изменении и служит ETag
+ALTER TABLE subscriptions ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Каталог сервисов: каноническое название и псевдонимы, под которыми сервис
+-- встречается в подписках ("yandex plus", "Яндекс Плюс").
+CREATE TABLE services (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    category VARCHAR(64),
+    website VARCHAR(2048),
+    default_currency CHAR(3) NOT NULL DEFAULT 'RUB' CHECK (default_currency ~ '^[A-Z]{3}$'),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_services_category ON services(category);
+
+CREATE TRIGGER update_services_updated_at
+    BEFORE UPDATE ON services
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Ключ названия: без учета регистра и лишних пробелов
+CREATE OR REPLACE FUNCTION service_name_key(name TEXT)
+RETURNS TEXT AS $$
+    SELECT lower(regexp_replace(btrim(name), '\s+', ' ', 'g'));
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Ключи названия и псевдонимов каждого сервиса. Первичный ключ не дает двум
+-- сервисам откликаться на одно название.
+CREATE TABLE service_names (
+    name_key TEXT PRIMARY KEY,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_service_names_service_id ON service_names(service_id);
+
+-- Сервис нельзя удалить, пока на него ссылаются подписки, в том числе удаленные
+ALTER TABLE subscriptions
+    ADD COLUMN service_id UUID REFERENCES services(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_subscriptions_service_id ON subscriptions(service_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_subscriptions_service_id;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS service_id;
+
+DROP TABLE IF EXISTS service_names;
+DROP FUNCTION IF EXISTS service_name_key(TEXT);
+
+DROP TRIGGER IF EXISTS update_services_updated_at ON services;
+DROP TABLE IF EXISTS services;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Тарифы сервиса ("Spotify Individual", "Spotify Family")
+CREATE TABLE service_plans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (service_id, name)
+);
+
+CREATE TRIGGER update_service_plans_updated_at
+    BEFORE UPDATE ON service_plans
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Прейскурант тарифа: цена за один период оплаты в каждой валюте
+CREATE TABLE plan_prices (
+    plan_id UUID NOT NULL REFERENCES service_plans(id) ON DELETE CASCADE,
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    billing_period VARCHAR(16) NOT NULL
+        CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly')),
+    price BIGINT NOT NULL CHECK (price > 0),
+    PRIMARY KEY (plan_id, currency, billing_period)
+);
+
+-- Тариф нельзя удалить, пока на него ссылаются подписки
+ALTER TABLE subscriptions
+    ADD COLUMN plan_id UUID REFERENCES service_plans(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_subscriptions_plan_id ON subscriptions(plan_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_subscriptions_plan_id;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS plan_id;
+
+DROP TABLE IF EXISTS plan_prices;
+
+DROP TRIGGER IF EXISTS update_service_plans_updated_at ON service_plans;
+DROP TABLE IF EXISTS service_plans;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Категория (entertainment, work, education) и произвольные метки подписки
+ALTER TABLE subscriptions
+    ADD COLUMN category VARCHAR(64),
+    ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_subscriptions_category ON subscriptions(category);
+CREATE INDEX idx_subscriptions_tags ON subscriptions USING GIN (tags);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_subscriptions_tags;
+DROP INDEX IF EXISTS idx_subscriptions_category;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS category;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Пользователи, которым принадлежат подписки
+CREATE TABLE users (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    display_name VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    locale VARCHAR(35) NOT NULL DEFAULT 'ru-RU',
+    default_currency CHAR(3) NOT NULL DEFAULT 'RUB' CHECK (default_currency ~ '^[A-Z]{3}$'),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'Europe/Moscow',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_users_email ON users(lower(email));
+
+CREATE TRIGGER update_users_updated_at
+    BEFORE UPDATE ON users
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Владельцы существующих подписок становятся пользователями без email
+INSERT INTO users (id, display_name)
+SELECT DISTINCT user_id, user_id::TEXT
+FROM subscriptions;
+
+-- Пользователя нельзя удалить, пока у него есть подписки, в том числе удаленные;
+-- каскадное удаление выполняет приложение по явному запросу
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_user_id_fkey;
+
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+DROP TABLE IF EXISTS users;
+-- +goose StatementEnd